@@ -0,0 +1,42 @@
+package main
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+	"fmt"
+
+	"go.uber.org/zap"
+
+	"shotr/config"
+	"shotr/store"
+)
+
+// runBackup implements "shotr backup [dir]". It is safe to run while the
+// server is up.
+func runBackup(dbConn *sql.DB, cfg *config.Config, args []string) error {
+	dir := cfg.BackupDir
+	if len(args) > 0 {
+		dir = args[0]
+	}
+	path, err := store.Backup(context.Background(), dbConn, dir, cfg.BackupKeep)
+	if err != nil {
+		return err
+	}
+	fmt.Println(path)
+	return nil
+}
+
+// runRestore implements "shotr restore <backup>". It refuses while the
+// server is running; the database it replaces is kept beside it.
+func runRestore(cfg *config.Config, logger *zap.Logger, args []string) error {
+	if len(args) != 1 {
+		return errors.New("usage: shotr restore <backup file>")
+	}
+	aside, err := store.Restore(context.Background(), args[0], cfg.DatabasePath)
+	if err != nil {
+		return err
+	}
+	logger.Info("database restored", zap.String("from", args[0]), zap.String("path", cfg.DatabasePath), zap.String("previous", aside))
+	return nil
+}
@@ -3,19 +3,56 @@ package config
 
 import (
 	"errors"
+	"net"
 	"os"
+	"strconv"
+	"strings"
+	"time"
 )
 
 // Config holds runtime configuration for the app.
 type Config struct {
 	Port        string
 	BaseHost    string
-	DatabaseURL string // libsql://... (Turso)
+	DatabaseURL string // libsql://... (Turso), postgres://... (PostgreSQL), or memory:// for an ephemeral in-memory store
 	DatabasePath string
 	AppEnv      string // "development" | "production"
 	LogLevel    string
+	GeoIPPath   string // optional MaxMind .mmdb file for country lookups
+	UnlockSecret string // HMAC key for password-unlock cookies; random per process if empty
+	URLAllowlistPath string // optional file of host patterns; if set only these may be shortened
+	URLDenylistPath  string // optional file of host patterns that may never be shortened
+	ShortenerDomains []string // host patterns of other shorteners that destinations may not use
+	MaxChainDepth    int      // how many of our own short links a destination may pass through
+	AdminToken       string   // bearer token for /api/v1/admin; admin API is off when empty
+	SlugSigningKeys  string   // "kid:secret,..." keyring for signed slugs; first key signs
+	SignAllSlugs     bool     // sign every new slug instead of only those that ask for it
+	SlugStrategy     string   // default slug strategy: nanoid, sequential, words or nocase
+	SlugSequenceKey  string   // seeds the permutation that hides sequential slug order
+	SlugWordlistPath string   // optional file of words slugs may not contain; a built-in list otherwise
+	AutoMigrate      bool     // apply pending migrations at startup; otherwise refuse to start with any pending
+	SQLiteReaders     int           // read-only connections beside the single sqlite writer
+	SQLiteBusyTimeout time.Duration // how long a sqlite connection waits on a lock before SQLITE_BUSY
+	BackupDir         string        // where shotr backup and scheduled backups write snapshots
+	BackupInterval    time.Duration // how often to snapshot the sqlite database; 0 disables scheduled backups
+	BackupKeep        int           // how many snapshots to keep in BackupDir; 0 keeps all
+	APIKeys           string        // "name:secret,..."; when set, creating links needs one as a bearer token
+	LinkQuota         int           // links each API key (or client IP without keys) may create per hour; 0 is unlimited
+	BatchMaxItems     int           // most links one POST /api/v1/links/batch array may hold
+	IdempotencyTTL    time.Duration // how long a response is replayed for a repeated Idempotency-Key
+	StripTracking     bool          // remove TrackingParams from destinations before storing them
+	TrackingParams    []string      // query parameter names; a trailing '*' matches any suffix
+	TrustedProxies    []*net.IPNet  // proxies whose X-Forwarded-For is believed; without any the peer address is the client
+	CacheSize         int           // links kept in the in-process redirect cache; 0 disables it
+	CacheTTL          time.Duration // how long a cached link is trusted, which bounds staleness across instances
 }
 
+// defaultShorteners is used when SHORTENER_DOMAINS isn't set.
+const defaultShorteners = "bit.ly,*.bit.ly,tinyurl.com,t.co,goo.gl,ow.ly,is.gd,buff.ly,rebrand.ly,cutt.ly,shorturl.at,rb.gy,tiny.cc,s.id"
+
+// defaultTrackingParams is used when TRACKING_PARAMS isn't set.
+const defaultTrackingParams = "utm_*,fbclid,gclid,dclid,gbraid,wbraid,msclkid,yclid,twclid,igshid,mc_cid,mc_eid,_ga,_gl"
+
 func Load() (*Config, error) {
 	cfg := &Config{
 		Port:         getenv("PORT", "8080"),
@@ -24,6 +61,107 @@ func Load() (*Config, error) {
 		DatabasePath: getenv("DATABASE_PATH", "data/db.sqlite3"),
 		AppEnv:       getenv("APP_ENV", "production"),
 		LogLevel:     getenv("LOG_LEVEL", "info"),
+		GeoIPPath:    os.Getenv("GEOIP_DB_PATH"),
+		UnlockSecret: os.Getenv("UNLOCK_SECRET"),
+		URLAllowlistPath: os.Getenv("URL_ALLOWLIST_PATH"),
+		URLDenylistPath:  os.Getenv("URL_DENYLIST_PATH"),
+		ShortenerDomains: splitList(getenv("SHORTENER_DOMAINS", defaultShorteners)),
+		AdminToken:       os.Getenv("ADMIN_TOKEN"),
+		SlugSigningKeys:  os.Getenv("SLUG_SIGNING_KEYS"),
+		SignAllSlugs:     os.Getenv("SIGN_ALL_SLUGS") == "true",
+		SlugStrategy:     getenv("SLUG_STRATEGY", "nanoid"),
+		SlugSequenceKey:  os.Getenv("SLUG_SEQUENCE_KEY"),
+		SlugWordlistPath: os.Getenv("SLUG_WORDLIST_PATH"),
+		AutoMigrate:      getenv("AUTO_MIGRATE", "true") == "true",
+		BackupDir:        getenv("BACKUP_DIR", "data/backups"),
+		APIKeys:          os.Getenv("API_KEYS"),
+		StripTracking:    os.Getenv("STRIP_TRACKING_PARAMS") == "true",
+		TrackingParams:   splitList(getenv("TRACKING_PARAMS", defaultTrackingParams)),
+	}
+
+	depth, err := strconv.Atoi(getenv("MAX_CHAIN_DEPTH", "1"))
+	if err != nil || depth < 0 {
+		return nil, errors.New("MAX_CHAIN_DEPTH must be a non-negative integer")
+	}
+	cfg.MaxChainDepth = depth
+
+	readers, err := strconv.Atoi(getenv("SQLITE_READERS", "4"))
+	if err != nil || readers < 1 {
+		return nil, errors.New("SQLITE_READERS must be a positive integer")
+	}
+	cfg.SQLiteReaders = readers
+
+	busy, err := time.ParseDuration(getenv("SQLITE_BUSY_TIMEOUT", "5s"))
+	if err != nil || busy < 0 {
+		return nil, errors.New("SQLITE_BUSY_TIMEOUT must be a non-negative duration")
+	}
+	cfg.SQLiteBusyTimeout = busy
+
+	every, err := time.ParseDuration(getenv("BACKUP_INTERVAL", "0"))
+	if err != nil || every < 0 {
+		return nil, errors.New("BACKUP_INTERVAL must be a non-negative duration")
+	}
+	cfg.BackupInterval = every
+
+	keep, err := strconv.Atoi(getenv("BACKUP_KEEP", "7"))
+	if err != nil || keep < 0 {
+		return nil, errors.New("BACKUP_KEEP must be a non-negative integer")
+	}
+	cfg.BackupKeep = keep
+
+	quota, err := strconv.Atoi(getenv("LINK_QUOTA", "0"))
+	if err != nil || quota < 0 {
+		return nil, errors.New("LINK_QUOTA must be a non-negative integer")
+	}
+	cfg.LinkQuota = quota
+
+	batch, err := strconv.Atoi(getenv("BATCH_MAX_ITEMS", "1000"))
+	if err != nil || batch < 1 {
+		return nil, errors.New("BATCH_MAX_ITEMS must be a positive integer")
+	}
+	cfg.BatchMaxItems = batch
+
+	ttl, err := time.ParseDuration(getenv("IDEMPOTENCY_TTL", "24h"))
+	if err != nil || ttl <= 0 {
+		return nil, errors.New("IDEMPOTENCY_TTL must be a positive duration")
+	}
+	cfg.IdempotencyTTL = ttl
+
+	size, err := strconv.Atoi(getenv("CACHE_SIZE", "0"))
+	if err != nil || size < 0 {
+		return nil, errors.New("CACHE_SIZE must be a non-negative integer")
+	}
+	cfg.CacheSize = size
+
+	cacheTTL, err := time.ParseDuration(getenv("CACHE_TTL", "1m"))
+	if err != nil || cacheTTL <= 0 {
+		return nil, errors.New("CACHE_TTL must be a positive duration")
+	}
+	cfg.CacheTTL = cacheTTL
+
+	for _, p := range splitList(os.Getenv("TRUSTED_PROXIES")) {
+		if !strings.Contains(p, "/") {
+			if ip := net.ParseIP(p); ip != nil && ip.To4() != nil {
+				p += "/32"
+			} else {
+				p += "/128"
+			}
+		}
+		_, ipnet, err := net.ParseCIDR(p)
+		if err != nil {
+			return nil, errors.New("TRUSTED_PROXIES must be a comma-separated list of IP addresses or CIDR ranges")
+		}
+		cfg.TrustedProxies = append(cfg.TrustedProxies, ipnet)
+	}
+
+	if cfg.SignAllSlugs && cfg.SlugSigningKeys == "" {
+		return nil, errors.New("SIGN_ALL_SLUGS requires SLUG_SIGNING_KEYS")
+	}
+
+	switch cfg.SlugStrategy {
+	case "nanoid", "sequential", "words", "nocase":
+	default:
+		return nil, errors.New("SLUG_STRATEGY must be one of nanoid, sequential, words, nocase")
 	}
 
 	// Required validations
@@ -39,4 +177,15 @@ func getenv(key, def string) string {
 		return v
 	}
 	return def
-}
\ No newline at end of file
+}
+
+// splitList parses a comma-separated list, dropping blanks.
+func splitList(s string) []string {
+	var out []string
+	for _, v := range strings.Split(s, ",") {
+		if v = strings.ToLower(strings.TrimSpace(v)); v != "" {
+			out = append(out, v)
+		}
+	}
+	return out
+}
@@ -30,18 +30,150 @@ func Prepare(ctx context.Context, db DBTX) (*Queries, error) {
 	if q.addLinkStmt, err = db.PrepareContext(ctx, addLink); err != nil {
 		return nil, fmt.Errorf("error preparing query AddLink: %w", err)
 	}
+	if q.addLinkDestinationStmt, err = db.PrepareContext(ctx, addLinkDestination); err != nil {
+		return nil, fmt.Errorf("error preparing query AddLinkDestination: %w", err)
+	}
+	if q.addLinkScheduleStmt, err = db.PrepareContext(ctx, addLinkSchedule); err != nil {
+		return nil, fmt.Errorf("error preparing query AddLinkSchedule: %w", err)
+	}
+	if q.addLinkTargetStmt, err = db.PrepareContext(ctx, addLinkTarget); err != nil {
+		return nil, fmt.Errorf("error preparing query AddLinkTarget: %w", err)
+	}
+	if q.addLinkVariantStmt, err = db.PrepareContext(ctx, addLinkVariant); err != nil {
+		return nil, fmt.Errorf("error preparing query AddLinkVariant: %w", err)
+	}
+	if q.addReportStmt, err = db.PrepareContext(ctx, addReport); err != nil {
+		return nil, fmt.Errorf("error preparing query AddReport: %w", err)
+	}
+	if q.addVariantClickStmt, err = db.PrepareContext(ctx, addVariantClick); err != nil {
+		return nil, fmt.Errorf("error preparing query AddVariantClick: %w", err)
+	}
+	if q.countLinksStmt, err = db.PrepareContext(ctx, countLinks); err != nil {
+		return nil, fmt.Errorf("error preparing query CountLinks: %w", err)
+	}
+	if q.deleteCountryClicksStmt, err = db.PrepareContext(ctx, deleteCountryClicks); err != nil {
+		return nil, fmt.Errorf("error preparing query DeleteCountryClicks: %w", err)
+	}
+	if q.deleteDailyClicksStmt, err = db.PrepareContext(ctx, deleteDailyClicks); err != nil {
+		return nil, fmt.Errorf("error preparing query DeleteDailyClicks: %w", err)
+	}
+	if q.deleteExpiredIdempotencyKeysStmt, err = db.PrepareContext(ctx, deleteExpiredIdempotencyKeys); err != nil {
+		return nil, fmt.Errorf("error preparing query DeleteExpiredIdempotencyKeys: %w", err)
+	}
+	if q.deleteLanguageClicksStmt, err = db.PrepareContext(ctx, deleteLanguageClicks); err != nil {
+		return nil, fmt.Errorf("error preparing query DeleteLanguageClicks: %w", err)
+	}
+	if q.deleteLinkByIDStmt, err = db.PrepareContext(ctx, deleteLinkByID); err != nil {
+		return nil, fmt.Errorf("error preparing query DeleteLinkByID: %w", err)
+	}
+	if q.deleteLinkDestinationsStmt, err = db.PrepareContext(ctx, deleteLinkDestinations); err != nil {
+		return nil, fmt.Errorf("error preparing query DeleteLinkDestinations: %w", err)
+	}
+	if q.deleteLinkSchedulesStmt, err = db.PrepareContext(ctx, deleteLinkSchedules); err != nil {
+		return nil, fmt.Errorf("error preparing query DeleteLinkSchedules: %w", err)
+	}
+	if q.deleteLinkTargetsStmt, err = db.PrepareContext(ctx, deleteLinkTargets); err != nil {
+		return nil, fmt.Errorf("error preparing query DeleteLinkTargets: %w", err)
+	}
+	if q.deleteLinkVariantsStmt, err = db.PrepareContext(ctx, deleteLinkVariants); err != nil {
+		return nil, fmt.Errorf("error preparing query DeleteLinkVariants: %w", err)
+	}
+	if q.deleteSourceClicksStmt, err = db.PrepareContext(ctx, deleteSourceClicks); err != nil {
+		return nil, fmt.Errorf("error preparing query DeleteSourceClicks: %w", err)
+	}
+	if q.findLinkByDestinationStmt, err = db.PrepareContext(ctx, findLinkByDestination); err != nil {
+		return nil, fmt.Errorf("error preparing query FindLinkByDestination: %w", err)
+	}
+	if q.getCountryClicksStmt, err = db.PrepareContext(ctx, getCountryClicks); err != nil {
+		return nil, fmt.Errorf("error preparing query GetCountryClicks: %w", err)
+	}
 	if q.getDailyClicksStmt, err = db.PrepareContext(ctx, getDailyClicks); err != nil {
 		return nil, fmt.Errorf("error preparing query GetDailyClicks: %w", err)
 	}
+	if q.getIdempotentResponseStmt, err = db.PrepareContext(ctx, getIdempotentResponse); err != nil {
+		return nil, fmt.Errorf("error preparing query GetIdempotentResponse: %w", err)
+	}
+	if q.getLanguageClicksStmt, err = db.PrepareContext(ctx, getLanguageClicks); err != nil {
+		return nil, fmt.Errorf("error preparing query GetLanguageClicks: %w", err)
+	}
 	if q.getLinkStmt, err = db.PrepareContext(ctx, getLink); err != nil {
 		return nil, fmt.Errorf("error preparing query GetLink: %w", err)
 	}
+	if q.getLinkSchedulesStmt, err = db.PrepareContext(ctx, getLinkSchedules); err != nil {
+		return nil, fmt.Errorf("error preparing query GetLinkSchedules: %w", err)
+	}
 	if q.getLinkStatsStmt, err = db.PrepareContext(ctx, getLinkStats); err != nil {
 		return nil, fmt.Errorf("error preparing query GetLinkStats: %w", err)
 	}
+	if q.getLinkTargetsStmt, err = db.PrepareContext(ctx, getLinkTargets); err != nil {
+		return nil, fmt.Errorf("error preparing query GetLinkTargets: %w", err)
+	}
+	if q.getLinkVariantsStmt, err = db.PrepareContext(ctx, getLinkVariants); err != nil {
+		return nil, fmt.Errorf("error preparing query GetLinkVariants: %w", err)
+	}
+	if q.getReportStmt, err = db.PrepareContext(ctx, getReport); err != nil {
+		return nil, fmt.Errorf("error preparing query GetReport: %w", err)
+	}
+	if q.getSourceClicksStmt, err = db.PrepareContext(ctx, getSourceClicks); err != nil {
+		return nil, fmt.Errorf("error preparing query GetSourceClicks: %w", err)
+	}
+	if q.importLinkStmt, err = db.PrepareContext(ctx, importLink); err != nil {
+		return nil, fmt.Errorf("error preparing query ImportLink: %w", err)
+	}
+	if q.listDailyClicksStmt, err = db.PrepareContext(ctx, listDailyClicks); err != nil {
+		return nil, fmt.Errorf("error preparing query ListDailyClicks: %w", err)
+	}
+	if q.listLinkDestinationsStmt, err = db.PrepareContext(ctx, listLinkDestinations); err != nil {
+		return nil, fmt.Errorf("error preparing query ListLinkDestinations: %w", err)
+	}
+	if q.listLinksStmt, err = db.PrepareContext(ctx, listLinks); err != nil {
+		return nil, fmt.Errorf("error preparing query ListLinks: %w", err)
+	}
+	if q.listReportsStmt, err = db.PrepareContext(ctx, listReports); err != nil {
+		return nil, fmt.Errorf("error preparing query ListReports: %w", err)
+	}
+	if q.replaceLinkStmt, err = db.PrepareContext(ctx, replaceLink); err != nil {
+		return nil, fmt.Errorf("error preparing query ReplaceLink: %w", err)
+	}
+	if q.resolveReportsStmt, err = db.PrepareContext(ctx, resolveReports); err != nil {
+		return nil, fmt.Errorf("error preparing query ResolveReports: %w", err)
+	}
+	if q.saveCountryClicksStmt, err = db.PrepareContext(ctx, saveCountryClicks); err != nil {
+		return nil, fmt.Errorf("error preparing query SaveCountryClicks: %w", err)
+	}
 	if q.saveDailyClicksStmt, err = db.PrepareContext(ctx, saveDailyClicks); err != nil {
 		return nil, fmt.Errorf("error preparing query SaveDailyClicks: %w", err)
 	}
+	if q.saveIdempotentResponseStmt, err = db.PrepareContext(ctx, saveIdempotentResponse); err != nil {
+		return nil, fmt.Errorf("error preparing query SaveIdempotentResponse: %w", err)
+	}
+	if q.saveLanguageClicksStmt, err = db.PrepareContext(ctx, saveLanguageClicks); err != nil {
+		return nil, fmt.Errorf("error preparing query SaveLanguageClicks: %w", err)
+	}
+	if q.saveSourceClicksStmt, err = db.PrepareContext(ctx, saveSourceClicks); err != nil {
+		return nil, fmt.Errorf("error preparing query SaveSourceClicks: %w", err)
+	}
+	if q.setDailyClicksStmt, err = db.PrepareContext(ctx, setDailyClicks); err != nil {
+		return nil, fmt.Errorf("error preparing query SetDailyClicks: %w", err)
+	}
+	if q.setLinkInterstitialStmt, err = db.PrepareContext(ctx, setLinkInterstitial); err != nil {
+		return nil, fmt.Errorf("error preparing query SetLinkInterstitial: %w", err)
+	}
+	if q.setLinkOriginalURLStmt, err = db.PrepareContext(ctx, setLinkOriginalURL); err != nil {
+		return nil, fmt.Errorf("error preparing query SetLinkOriginalURL: %w", err)
+	}
+	if q.setLinkPasswordStmt, err = db.PrepareContext(ctx, setLinkPassword); err != nil {
+		return nil, fmt.Errorf("error preparing query SetLinkPassword: %w", err)
+	}
+	if q.setLinkTakedownStmt, err = db.PrepareContext(ctx, setLinkTakedown); err != nil {
+		return nil, fmt.Errorf("error preparing query SetLinkTakedown: %w", err)
+	}
+	if q.updateLinkSlugStmt, err = db.PrepareContext(ctx, updateLinkSlug); err != nil {
+		return nil, fmt.Errorf("error preparing query UpdateLinkSlug: %w", err)
+	}
+	if q.updateLinkURLStmt, err = db.PrepareContext(ctx, updateLinkURL); err != nil {
+		return nil, fmt.Errorf("error preparing query UpdateLinkURL: %w", err)
+	}
 	return &q, nil
 }
 
@@ -57,26 +189,246 @@ func (q *Queries) Close() error {
 			err = fmt.Errorf("error closing addLinkStmt: %w", cerr)
 		}
 	}
+	if q.addLinkDestinationStmt != nil {
+		if cerr := q.addLinkDestinationStmt.Close(); cerr != nil {
+			err = fmt.Errorf("error closing addLinkDestinationStmt: %w", cerr)
+		}
+	}
+	if q.addLinkScheduleStmt != nil {
+		if cerr := q.addLinkScheduleStmt.Close(); cerr != nil {
+			err = fmt.Errorf("error closing addLinkScheduleStmt: %w", cerr)
+		}
+	}
+	if q.addLinkTargetStmt != nil {
+		if cerr := q.addLinkTargetStmt.Close(); cerr != nil {
+			err = fmt.Errorf("error closing addLinkTargetStmt: %w", cerr)
+		}
+	}
+	if q.addLinkVariantStmt != nil {
+		if cerr := q.addLinkVariantStmt.Close(); cerr != nil {
+			err = fmt.Errorf("error closing addLinkVariantStmt: %w", cerr)
+		}
+	}
+	if q.addReportStmt != nil {
+		if cerr := q.addReportStmt.Close(); cerr != nil {
+			err = fmt.Errorf("error closing addReportStmt: %w", cerr)
+		}
+	}
+	if q.addVariantClickStmt != nil {
+		if cerr := q.addVariantClickStmt.Close(); cerr != nil {
+			err = fmt.Errorf("error closing addVariantClickStmt: %w", cerr)
+		}
+	}
+	if q.countLinksStmt != nil {
+		if cerr := q.countLinksStmt.Close(); cerr != nil {
+			err = fmt.Errorf("error closing countLinksStmt: %w", cerr)
+		}
+	}
+	if q.deleteCountryClicksStmt != nil {
+		if cerr := q.deleteCountryClicksStmt.Close(); cerr != nil {
+			err = fmt.Errorf("error closing deleteCountryClicksStmt: %w", cerr)
+		}
+	}
+	if q.deleteDailyClicksStmt != nil {
+		if cerr := q.deleteDailyClicksStmt.Close(); cerr != nil {
+			err = fmt.Errorf("error closing deleteDailyClicksStmt: %w", cerr)
+		}
+	}
+	if q.deleteExpiredIdempotencyKeysStmt != nil {
+		if cerr := q.deleteExpiredIdempotencyKeysStmt.Close(); cerr != nil {
+			err = fmt.Errorf("error closing deleteExpiredIdempotencyKeysStmt: %w", cerr)
+		}
+	}
+	if q.deleteLanguageClicksStmt != nil {
+		if cerr := q.deleteLanguageClicksStmt.Close(); cerr != nil {
+			err = fmt.Errorf("error closing deleteLanguageClicksStmt: %w", cerr)
+		}
+	}
+	if q.deleteLinkByIDStmt != nil {
+		if cerr := q.deleteLinkByIDStmt.Close(); cerr != nil {
+			err = fmt.Errorf("error closing deleteLinkByIDStmt: %w", cerr)
+		}
+	}
+	if q.deleteLinkDestinationsStmt != nil {
+		if cerr := q.deleteLinkDestinationsStmt.Close(); cerr != nil {
+			err = fmt.Errorf("error closing deleteLinkDestinationsStmt: %w", cerr)
+		}
+	}
+	if q.deleteLinkSchedulesStmt != nil {
+		if cerr := q.deleteLinkSchedulesStmt.Close(); cerr != nil {
+			err = fmt.Errorf("error closing deleteLinkSchedulesStmt: %w", cerr)
+		}
+	}
+	if q.deleteLinkTargetsStmt != nil {
+		if cerr := q.deleteLinkTargetsStmt.Close(); cerr != nil {
+			err = fmt.Errorf("error closing deleteLinkTargetsStmt: %w", cerr)
+		}
+	}
+	if q.deleteLinkVariantsStmt != nil {
+		if cerr := q.deleteLinkVariantsStmt.Close(); cerr != nil {
+			err = fmt.Errorf("error closing deleteLinkVariantsStmt: %w", cerr)
+		}
+	}
+	if q.deleteSourceClicksStmt != nil {
+		if cerr := q.deleteSourceClicksStmt.Close(); cerr != nil {
+			err = fmt.Errorf("error closing deleteSourceClicksStmt: %w", cerr)
+		}
+	}
+	if q.findLinkByDestinationStmt != nil {
+		if cerr := q.findLinkByDestinationStmt.Close(); cerr != nil {
+			err = fmt.Errorf("error closing findLinkByDestinationStmt: %w", cerr)
+		}
+	}
+	if q.getCountryClicksStmt != nil {
+		if cerr := q.getCountryClicksStmt.Close(); cerr != nil {
+			err = fmt.Errorf("error closing getCountryClicksStmt: %w", cerr)
+		}
+	}
 	if q.getDailyClicksStmt != nil {
 		if cerr := q.getDailyClicksStmt.Close(); cerr != nil {
 			err = fmt.Errorf("error closing getDailyClicksStmt: %w", cerr)
 		}
 	}
+	if q.getIdempotentResponseStmt != nil {
+		if cerr := q.getIdempotentResponseStmt.Close(); cerr != nil {
+			err = fmt.Errorf("error closing getIdempotentResponseStmt: %w", cerr)
+		}
+	}
+	if q.getLanguageClicksStmt != nil {
+		if cerr := q.getLanguageClicksStmt.Close(); cerr != nil {
+			err = fmt.Errorf("error closing getLanguageClicksStmt: %w", cerr)
+		}
+	}
 	if q.getLinkStmt != nil {
 		if cerr := q.getLinkStmt.Close(); cerr != nil {
 			err = fmt.Errorf("error closing getLinkStmt: %w", cerr)
 		}
 	}
+	if q.getLinkSchedulesStmt != nil {
+		if cerr := q.getLinkSchedulesStmt.Close(); cerr != nil {
+			err = fmt.Errorf("error closing getLinkSchedulesStmt: %w", cerr)
+		}
+	}
 	if q.getLinkStatsStmt != nil {
 		if cerr := q.getLinkStatsStmt.Close(); cerr != nil {
 			err = fmt.Errorf("error closing getLinkStatsStmt: %w", cerr)
 		}
 	}
+	if q.getLinkTargetsStmt != nil {
+		if cerr := q.getLinkTargetsStmt.Close(); cerr != nil {
+			err = fmt.Errorf("error closing getLinkTargetsStmt: %w", cerr)
+		}
+	}
+	if q.getLinkVariantsStmt != nil {
+		if cerr := q.getLinkVariantsStmt.Close(); cerr != nil {
+			err = fmt.Errorf("error closing getLinkVariantsStmt: %w", cerr)
+		}
+	}
+	if q.getReportStmt != nil {
+		if cerr := q.getReportStmt.Close(); cerr != nil {
+			err = fmt.Errorf("error closing getReportStmt: %w", cerr)
+		}
+	}
+	if q.getSourceClicksStmt != nil {
+		if cerr := q.getSourceClicksStmt.Close(); cerr != nil {
+			err = fmt.Errorf("error closing getSourceClicksStmt: %w", cerr)
+		}
+	}
+	if q.importLinkStmt != nil {
+		if cerr := q.importLinkStmt.Close(); cerr != nil {
+			err = fmt.Errorf("error closing importLinkStmt: %w", cerr)
+		}
+	}
+	if q.listDailyClicksStmt != nil {
+		if cerr := q.listDailyClicksStmt.Close(); cerr != nil {
+			err = fmt.Errorf("error closing listDailyClicksStmt: %w", cerr)
+		}
+	}
+	if q.listLinkDestinationsStmt != nil {
+		if cerr := q.listLinkDestinationsStmt.Close(); cerr != nil {
+			err = fmt.Errorf("error closing listLinkDestinationsStmt: %w", cerr)
+		}
+	}
+	if q.listLinksStmt != nil {
+		if cerr := q.listLinksStmt.Close(); cerr != nil {
+			err = fmt.Errorf("error closing listLinksStmt: %w", cerr)
+		}
+	}
+	if q.listReportsStmt != nil {
+		if cerr := q.listReportsStmt.Close(); cerr != nil {
+			err = fmt.Errorf("error closing listReportsStmt: %w", cerr)
+		}
+	}
+	if q.replaceLinkStmt != nil {
+		if cerr := q.replaceLinkStmt.Close(); cerr != nil {
+			err = fmt.Errorf("error closing replaceLinkStmt: %w", cerr)
+		}
+	}
+	if q.resolveReportsStmt != nil {
+		if cerr := q.resolveReportsStmt.Close(); cerr != nil {
+			err = fmt.Errorf("error closing resolveReportsStmt: %w", cerr)
+		}
+	}
+	if q.saveCountryClicksStmt != nil {
+		if cerr := q.saveCountryClicksStmt.Close(); cerr != nil {
+			err = fmt.Errorf("error closing saveCountryClicksStmt: %w", cerr)
+		}
+	}
 	if q.saveDailyClicksStmt != nil {
 		if cerr := q.saveDailyClicksStmt.Close(); cerr != nil {
 			err = fmt.Errorf("error closing saveDailyClicksStmt: %w", cerr)
 		}
 	}
+	if q.saveIdempotentResponseStmt != nil {
+		if cerr := q.saveIdempotentResponseStmt.Close(); cerr != nil {
+			err = fmt.Errorf("error closing saveIdempotentResponseStmt: %w", cerr)
+		}
+	}
+	if q.saveLanguageClicksStmt != nil {
+		if cerr := q.saveLanguageClicksStmt.Close(); cerr != nil {
+			err = fmt.Errorf("error closing saveLanguageClicksStmt: %w", cerr)
+		}
+	}
+	if q.saveSourceClicksStmt != nil {
+		if cerr := q.saveSourceClicksStmt.Close(); cerr != nil {
+			err = fmt.Errorf("error closing saveSourceClicksStmt: %w", cerr)
+		}
+	}
+	if q.setDailyClicksStmt != nil {
+		if cerr := q.setDailyClicksStmt.Close(); cerr != nil {
+			err = fmt.Errorf("error closing setDailyClicksStmt: %w", cerr)
+		}
+	}
+	if q.setLinkInterstitialStmt != nil {
+		if cerr := q.setLinkInterstitialStmt.Close(); cerr != nil {
+			err = fmt.Errorf("error closing setLinkInterstitialStmt: %w", cerr)
+		}
+	}
+	if q.setLinkOriginalURLStmt != nil {
+		if cerr := q.setLinkOriginalURLStmt.Close(); cerr != nil {
+			err = fmt.Errorf("error closing setLinkOriginalURLStmt: %w", cerr)
+		}
+	}
+	if q.setLinkPasswordStmt != nil {
+		if cerr := q.setLinkPasswordStmt.Close(); cerr != nil {
+			err = fmt.Errorf("error closing setLinkPasswordStmt: %w", cerr)
+		}
+	}
+	if q.setLinkTakedownStmt != nil {
+		if cerr := q.setLinkTakedownStmt.Close(); cerr != nil {
+			err = fmt.Errorf("error closing setLinkTakedownStmt: %w", cerr)
+		}
+	}
+	if q.updateLinkSlugStmt != nil {
+		if cerr := q.updateLinkSlugStmt.Close(); cerr != nil {
+			err = fmt.Errorf("error closing updateLinkSlugStmt: %w", cerr)
+		}
+	}
+	if q.updateLinkURLStmt != nil {
+		if cerr := q.updateLinkURLStmt.Close(); cerr != nil {
+			err = fmt.Errorf("error closing updateLinkURLStmt: %w", cerr)
+		}
+	}
 	return err
 }
 
@@ -114,25 +466,113 @@ func (q *Queries) queryRow(ctx context.Context, stmt *sql.Stmt, query string, ar
 }
 
 type Queries struct {
-	db                  DBTX
-	tx                  *sql.Tx
-	addClickStmt        *sql.Stmt
-	addLinkStmt         *sql.Stmt
-	getDailyClicksStmt  *sql.Stmt
-	getLinkStmt         *sql.Stmt
-	getLinkStatsStmt    *sql.Stmt
-	saveDailyClicksStmt *sql.Stmt
+	db                               DBTX
+	tx                               *sql.Tx
+	addClickStmt                     *sql.Stmt
+	addLinkStmt                      *sql.Stmt
+	addLinkDestinationStmt           *sql.Stmt
+	addLinkScheduleStmt              *sql.Stmt
+	addLinkTargetStmt                *sql.Stmt
+	addLinkVariantStmt               *sql.Stmt
+	addReportStmt                    *sql.Stmt
+	addVariantClickStmt              *sql.Stmt
+	countLinksStmt                   *sql.Stmt
+	deleteCountryClicksStmt          *sql.Stmt
+	deleteDailyClicksStmt            *sql.Stmt
+	deleteExpiredIdempotencyKeysStmt *sql.Stmt
+	deleteLanguageClicksStmt         *sql.Stmt
+	deleteLinkByIDStmt               *sql.Stmt
+	deleteLinkDestinationsStmt       *sql.Stmt
+	deleteLinkSchedulesStmt          *sql.Stmt
+	deleteLinkTargetsStmt            *sql.Stmt
+	deleteLinkVariantsStmt           *sql.Stmt
+	deleteSourceClicksStmt           *sql.Stmt
+	findLinkByDestinationStmt        *sql.Stmt
+	getCountryClicksStmt             *sql.Stmt
+	getDailyClicksStmt               *sql.Stmt
+	getIdempotentResponseStmt        *sql.Stmt
+	getLanguageClicksStmt            *sql.Stmt
+	getLinkStmt                      *sql.Stmt
+	getLinkSchedulesStmt             *sql.Stmt
+	getLinkStatsStmt                 *sql.Stmt
+	getLinkTargetsStmt               *sql.Stmt
+	getLinkVariantsStmt              *sql.Stmt
+	getReportStmt                    *sql.Stmt
+	getSourceClicksStmt              *sql.Stmt
+	importLinkStmt                   *sql.Stmt
+	listDailyClicksStmt              *sql.Stmt
+	listLinkDestinationsStmt         *sql.Stmt
+	listLinksStmt                    *sql.Stmt
+	listReportsStmt                  *sql.Stmt
+	replaceLinkStmt                  *sql.Stmt
+	resolveReportsStmt               *sql.Stmt
+	saveCountryClicksStmt            *sql.Stmt
+	saveDailyClicksStmt              *sql.Stmt
+	saveIdempotentResponseStmt       *sql.Stmt
+	saveLanguageClicksStmt           *sql.Stmt
+	saveSourceClicksStmt             *sql.Stmt
+	setDailyClicksStmt               *sql.Stmt
+	setLinkInterstitialStmt          *sql.Stmt
+	setLinkOriginalURLStmt           *sql.Stmt
+	setLinkPasswordStmt              *sql.Stmt
+	setLinkTakedownStmt              *sql.Stmt
+	updateLinkSlugStmt               *sql.Stmt
+	updateLinkURLStmt                *sql.Stmt
 }
 
 func (q *Queries) WithTx(tx *sql.Tx) *Queries {
 	return &Queries{
-		db:                  tx,
-		tx:                  tx,
-		addClickStmt:        q.addClickStmt,
-		addLinkStmt:         q.addLinkStmt,
-		getDailyClicksStmt:  q.getDailyClicksStmt,
-		getLinkStmt:         q.getLinkStmt,
-		getLinkStatsStmt:    q.getLinkStatsStmt,
-		saveDailyClicksStmt: q.saveDailyClicksStmt,
+		db:                               tx,
+		tx:                               tx,
+		addClickStmt:                     q.addClickStmt,
+		addLinkStmt:                      q.addLinkStmt,
+		addLinkDestinationStmt:           q.addLinkDestinationStmt,
+		addLinkScheduleStmt:              q.addLinkScheduleStmt,
+		addLinkTargetStmt:                q.addLinkTargetStmt,
+		addLinkVariantStmt:               q.addLinkVariantStmt,
+		addReportStmt:                    q.addReportStmt,
+		addVariantClickStmt:              q.addVariantClickStmt,
+		countLinksStmt:                   q.countLinksStmt,
+		deleteCountryClicksStmt:          q.deleteCountryClicksStmt,
+		deleteDailyClicksStmt:            q.deleteDailyClicksStmt,
+		deleteExpiredIdempotencyKeysStmt: q.deleteExpiredIdempotencyKeysStmt,
+		deleteLanguageClicksStmt:         q.deleteLanguageClicksStmt,
+		deleteLinkByIDStmt:               q.deleteLinkByIDStmt,
+		deleteLinkDestinationsStmt:       q.deleteLinkDestinationsStmt,
+		deleteLinkSchedulesStmt:          q.deleteLinkSchedulesStmt,
+		deleteLinkTargetsStmt:            q.deleteLinkTargetsStmt,
+		deleteLinkVariantsStmt:           q.deleteLinkVariantsStmt,
+		deleteSourceClicksStmt:           q.deleteSourceClicksStmt,
+		findLinkByDestinationStmt:        q.findLinkByDestinationStmt,
+		getCountryClicksStmt:             q.getCountryClicksStmt,
+		getDailyClicksStmt:               q.getDailyClicksStmt,
+		getIdempotentResponseStmt:        q.getIdempotentResponseStmt,
+		getLanguageClicksStmt:            q.getLanguageClicksStmt,
+		getLinkStmt:                      q.getLinkStmt,
+		getLinkSchedulesStmt:             q.getLinkSchedulesStmt,
+		getLinkStatsStmt:                 q.getLinkStatsStmt,
+		getLinkTargetsStmt:               q.getLinkTargetsStmt,
+		getLinkVariantsStmt:              q.getLinkVariantsStmt,
+		getReportStmt:                    q.getReportStmt,
+		getSourceClicksStmt:              q.getSourceClicksStmt,
+		importLinkStmt:                   q.importLinkStmt,
+		listDailyClicksStmt:              q.listDailyClicksStmt,
+		listLinkDestinationsStmt:         q.listLinkDestinationsStmt,
+		listLinksStmt:                    q.listLinksStmt,
+		listReportsStmt:                  q.listReportsStmt,
+		replaceLinkStmt:                  q.replaceLinkStmt,
+		resolveReportsStmt:               q.resolveReportsStmt,
+		saveCountryClicksStmt:            q.saveCountryClicksStmt,
+		saveDailyClicksStmt:              q.saveDailyClicksStmt,
+		saveIdempotentResponseStmt:       q.saveIdempotentResponseStmt,
+		saveLanguageClicksStmt:           q.saveLanguageClicksStmt,
+		saveSourceClicksStmt:             q.saveSourceClicksStmt,
+		setDailyClicksStmt:               q.setDailyClicksStmt,
+		setLinkInterstitialStmt:          q.setLinkInterstitialStmt,
+		setLinkOriginalURLStmt:           q.setLinkOriginalURLStmt,
+		setLinkPasswordStmt:              q.setLinkPasswordStmt,
+		setLinkTakedownStmt:              q.setLinkTakedownStmt,
+		updateLinkSlugStmt:               q.updateLinkSlugStmt,
+		updateLinkURLStmt:                q.updateLinkURLStmt,
 	}
 }
@@ -0,0 +1,1255 @@
+// Code generated by sqlc. DO NOT EDIT.
+// versions:
+//   sqlc v1.30.0
+// source: links.sql
+
+package pg
+
+import (
+	"context"
+	"database/sql"
+	"time"
+
+	"github.com/lib/pq"
+)
+
+const addClick = `-- name: AddClick :execrows
+UPDATE links SET clicks = clicks + $1 WHERE slug = $2
+`
+
+type AddClickParams struct {
+	Clicks sql.NullInt64 `json:"clicks"`
+	Slug   string        `json:"slug"`
+}
+
+func (q *Queries) AddClick(ctx context.Context, arg AddClickParams) (int64, error) {
+	result, err := q.exec(ctx, q.addClickStmt, addClick, arg.Clicks, arg.Slug)
+	if err != nil {
+		return 0, err
+	}
+	return result.RowsAffected()
+}
+
+const addLink = `-- name: AddLink :one
+INSERT INTO links (slug, url, "user", created_at, clicks)
+VALUES ($1, $2, $3, now(), 0)
+RETURNING id, slug, url, "user", created_at, clicks, password_hash, interstitial, takedown_status, takedown_reason, original_url
+`
+
+type AddLinkParams struct {
+	Slug string         `json:"slug"`
+	Url  string         `json:"url"`
+	User sql.NullString `json:"user"`
+}
+
+func (q *Queries) AddLink(ctx context.Context, arg AddLinkParams) (Link, error) {
+	row := q.queryRow(ctx, q.addLinkStmt, addLink, arg.Slug, arg.Url, arg.User)
+	var i Link
+	err := row.Scan(
+		&i.ID,
+		&i.Slug,
+		&i.Url,
+		&i.User,
+		&i.CreatedAt,
+		&i.Clicks,
+		&i.PasswordHash,
+		&i.Interstitial,
+		&i.TakedownStatus,
+		&i.TakedownReason,
+		&i.OriginalUrl,
+	)
+	return i, err
+}
+
+const addLinkDestination = `-- name: AddLinkDestination :exec
+INSERT INTO link_destinations (slug, owner, url_key)
+VALUES ($1, $2, $3)
+`
+
+type AddLinkDestinationParams struct {
+	Slug   string `json:"slug"`
+	Owner  string `json:"owner"`
+	UrlKey string `json:"url_key"`
+}
+
+func (q *Queries) AddLinkDestination(ctx context.Context, arg AddLinkDestinationParams) error {
+	_, err := q.exec(ctx, q.addLinkDestinationStmt, addLinkDestination, arg.Slug, arg.Owner, arg.UrlKey)
+	return err
+}
+
+const addLinkSchedule = `-- name: AddLinkSchedule :exec
+INSERT INTO link_schedules (slug, starts_at, ends_at, url)
+VALUES ($1, $2, $3, $4)
+`
+
+type AddLinkScheduleParams struct {
+	Slug     string       `json:"slug"`
+	StartsAt sql.NullTime `json:"starts_at"`
+	EndsAt   sql.NullTime `json:"ends_at"`
+	Url      string       `json:"url"`
+}
+
+func (q *Queries) AddLinkSchedule(ctx context.Context, arg AddLinkScheduleParams) error {
+	_, err := q.exec(ctx, q.addLinkScheduleStmt, addLinkSchedule,
+		arg.Slug,
+		arg.StartsAt,
+		arg.EndsAt,
+		arg.Url,
+	)
+	return err
+}
+
+const addLinkTarget = `-- name: AddLinkTarget :exec
+INSERT INTO link_targets (slug, kind, value, url)
+VALUES ($1, $2, $3, $4)
+`
+
+type AddLinkTargetParams struct {
+	Slug  string `json:"slug"`
+	Kind  string `json:"kind"`
+	Value string `json:"value"`
+	Url   string `json:"url"`
+}
+
+func (q *Queries) AddLinkTarget(ctx context.Context, arg AddLinkTargetParams) error {
+	_, err := q.exec(ctx, q.addLinkTargetStmt, addLinkTarget,
+		arg.Slug,
+		arg.Kind,
+		arg.Value,
+		arg.Url,
+	)
+	return err
+}
+
+const addLinkVariant = `-- name: AddLinkVariant :exec
+INSERT INTO link_variants (slug, name, url, weight, clicks)
+VALUES ($1, $2, $3, $4, 0)
+`
+
+type AddLinkVariantParams struct {
+	Slug   string `json:"slug"`
+	Name   string `json:"name"`
+	Url    string `json:"url"`
+	Weight int64  `json:"weight"`
+}
+
+func (q *Queries) AddLinkVariant(ctx context.Context, arg AddLinkVariantParams) error {
+	_, err := q.exec(ctx, q.addLinkVariantStmt, addLinkVariant,
+		arg.Slug,
+		arg.Name,
+		arg.Url,
+		arg.Weight,
+	)
+	return err
+}
+
+const addReport = `-- name: AddReport :one
+INSERT INTO reports (slug, reason, details, contact, reporter_ip)
+VALUES ($1, $2, $3, $4, $5)
+RETURNING id, slug, reason, details, contact, reporter_ip, status, created_at, resolved_at
+`
+
+type AddReportParams struct {
+	Slug       string         `json:"slug"`
+	Reason     string         `json:"reason"`
+	Details    sql.NullString `json:"details"`
+	Contact    sql.NullString `json:"contact"`
+	ReporterIp sql.NullString `json:"reporter_ip"`
+}
+
+func (q *Queries) AddReport(ctx context.Context, arg AddReportParams) (Report, error) {
+	row := q.queryRow(ctx, q.addReportStmt, addReport,
+		arg.Slug,
+		arg.Reason,
+		arg.Details,
+		arg.Contact,
+		arg.ReporterIp,
+	)
+	var i Report
+	err := row.Scan(
+		&i.ID,
+		&i.Slug,
+		&i.Reason,
+		&i.Details,
+		&i.Contact,
+		&i.ReporterIp,
+		&i.Status,
+		&i.CreatedAt,
+		&i.ResolvedAt,
+	)
+	return i, err
+}
+
+const addVariantClick = `-- name: AddVariantClick :exec
+UPDATE link_variants SET clicks = clicks + $1 WHERE slug = $2 AND name = $3
+`
+
+type AddVariantClickParams struct {
+	Clicks sql.NullInt64 `json:"clicks"`
+	Slug   string        `json:"slug"`
+	Name   string        `json:"name"`
+}
+
+func (q *Queries) AddVariantClick(ctx context.Context, arg AddVariantClickParams) error {
+	_, err := q.exec(ctx, q.addVariantClickStmt, addVariantClick, arg.Clicks, arg.Slug, arg.Name)
+	return err
+}
+
+const countLinks = `-- name: CountLinks :one
+SELECT COUNT(*) FROM links
+`
+
+func (q *Queries) CountLinks(ctx context.Context) (int64, error) {
+	row := q.queryRow(ctx, q.countLinksStmt, countLinks)
+	var count int64
+	err := row.Scan(&count)
+	return count, err
+}
+
+const deleteCountryClicks = `-- name: DeleteCountryClicks :exec
+DELETE FROM country_clicks WHERE slug = $1
+`
+
+func (q *Queries) DeleteCountryClicks(ctx context.Context, slug string) error {
+	_, err := q.exec(ctx, q.deleteCountryClicksStmt, deleteCountryClicks, slug)
+	return err
+}
+
+const deleteDailyClicks = `-- name: DeleteDailyClicks :exec
+DELETE FROM daily_clicks WHERE slug = $1
+`
+
+func (q *Queries) DeleteDailyClicks(ctx context.Context, slug string) error {
+	_, err := q.exec(ctx, q.deleteDailyClicksStmt, deleteDailyClicks, slug)
+	return err
+}
+
+const deleteExpiredIdempotencyKeys = `-- name: DeleteExpiredIdempotencyKeys :exec
+DELETE FROM idempotency_keys WHERE expires_at <= $1
+`
+
+func (q *Queries) DeleteExpiredIdempotencyKeys(ctx context.Context, expiresAt int64) error {
+	_, err := q.exec(ctx, q.deleteExpiredIdempotencyKeysStmt, deleteExpiredIdempotencyKeys, expiresAt)
+	return err
+}
+
+const deleteLanguageClicks = `-- name: DeleteLanguageClicks :exec
+DELETE FROM language_clicks WHERE slug = $1
+`
+
+func (q *Queries) DeleteLanguageClicks(ctx context.Context, slug string) error {
+	_, err := q.exec(ctx, q.deleteLanguageClicksStmt, deleteLanguageClicks, slug)
+	return err
+}
+
+const deleteLinkByID = `-- name: DeleteLinkByID :exec
+DELETE FROM links WHERE id = $1
+`
+
+func (q *Queries) DeleteLinkByID(ctx context.Context, id int64) error {
+	_, err := q.exec(ctx, q.deleteLinkByIDStmt, deleteLinkByID, id)
+	return err
+}
+
+const deleteLinkDestinations = `-- name: DeleteLinkDestinations :exec
+DELETE FROM link_destinations WHERE slug = $1
+`
+
+func (q *Queries) DeleteLinkDestinations(ctx context.Context, slug string) error {
+	_, err := q.exec(ctx, q.deleteLinkDestinationsStmt, deleteLinkDestinations, slug)
+	return err
+}
+
+const deleteLinkSchedules = `-- name: DeleteLinkSchedules :exec
+DELETE FROM link_schedules WHERE slug = $1
+`
+
+func (q *Queries) DeleteLinkSchedules(ctx context.Context, slug string) error {
+	_, err := q.exec(ctx, q.deleteLinkSchedulesStmt, deleteLinkSchedules, slug)
+	return err
+}
+
+const deleteLinkTargets = `-- name: DeleteLinkTargets :exec
+DELETE FROM link_targets WHERE slug = $1
+`
+
+func (q *Queries) DeleteLinkTargets(ctx context.Context, slug string) error {
+	_, err := q.exec(ctx, q.deleteLinkTargetsStmt, deleteLinkTargets, slug)
+	return err
+}
+
+const deleteLinkVariants = `-- name: DeleteLinkVariants :exec
+DELETE FROM link_variants WHERE slug = $1
+`
+
+func (q *Queries) DeleteLinkVariants(ctx context.Context, slug string) error {
+	_, err := q.exec(ctx, q.deleteLinkVariantsStmt, deleteLinkVariants, slug)
+	return err
+}
+
+const deleteSourceClicks = `-- name: DeleteSourceClicks :exec
+DELETE FROM source_clicks WHERE slug = $1
+`
+
+func (q *Queries) DeleteSourceClicks(ctx context.Context, slug string) error {
+	_, err := q.exec(ctx, q.deleteSourceClicksStmt, deleteSourceClicks, slug)
+	return err
+}
+
+const findLinkByDestination = `-- name: FindLinkByDestination :one
+SELECT links.id, links.slug, links.url
+FROM link_destinations
+JOIN links ON links.slug = link_destinations.slug
+WHERE link_destinations.owner = $1
+  AND link_destinations.url_key = $2
+  AND links.url = link_destinations.url_key -- not since pointed elsewhere
+  AND links.takedown_status IS NULL
+ORDER BY links.id ASC
+LIMIT 1
+`
+
+type FindLinkByDestinationParams struct {
+	Owner  string `json:"owner"`
+	UrlKey string `json:"url_key"`
+}
+
+type FindLinkByDestinationRow struct {
+	ID   int64  `json:"id"`
+	Slug string `json:"slug"`
+	Url  string `json:"url"`
+}
+
+func (q *Queries) FindLinkByDestination(ctx context.Context, arg FindLinkByDestinationParams) (FindLinkByDestinationRow, error) {
+	row := q.queryRow(ctx, q.findLinkByDestinationStmt, findLinkByDestination, arg.Owner, arg.UrlKey)
+	var i FindLinkByDestinationRow
+	err := row.Scan(&i.ID, &i.Slug, &i.Url)
+	return i, err
+}
+
+const flushCountryClicks = `-- name: FlushCountryClicks :exec
+INSERT INTO country_clicks (slug, day, country, clicks)
+SELECT b.slug, (now() AT TIME ZONE 'UTC')::date, b.value, b.clicks
+FROM (SELECT unnest($1::TEXT[]) AS slug, unnest($2::TEXT[]) AS value, unnest($3::BIGINT[]) AS clicks) AS b
+ON CONFLICT(slug, day, country) DO UPDATE SET clicks = country_clicks.clicks + excluded.clicks
+`
+
+type FlushCountryClicksParams struct {
+	Slugs  []string `json:"slugs"`
+	Vals   []string `json:"vals"`
+	Clicks []int64  `json:"clicks"`
+}
+
+func (q *Queries) FlushCountryClicks(ctx context.Context, arg FlushCountryClicksParams) error {
+	_, err := q.exec(ctx, q.flushCountryClicksStmt, flushCountryClicks, pq.Array(arg.Slugs), pq.Array(arg.Vals), pq.Array(arg.Clicks))
+	return err
+}
+
+const flushDailyClicks = `-- name: FlushDailyClicks :exec
+INSERT INTO daily_clicks (slug, day, clicks)
+SELECT b.slug, (now() AT TIME ZONE 'UTC')::date, b.clicks
+FROM (SELECT unnest($1::TEXT[]) AS slug, unnest($2::BIGINT[]) AS clicks) AS b
+ON CONFLICT(slug, day) DO UPDATE SET clicks = daily_clicks.clicks + excluded.clicks
+`
+
+type FlushDailyClicksParams struct {
+	Slugs  []string `json:"slugs"`
+	Clicks []int64  `json:"clicks"`
+}
+
+func (q *Queries) FlushDailyClicks(ctx context.Context, arg FlushDailyClicksParams) error {
+	_, err := q.exec(ctx, q.flushDailyClicksStmt, flushDailyClicks, pq.Array(arg.Slugs), pq.Array(arg.Clicks))
+	return err
+}
+
+const flushLanguageClicks = `-- name: FlushLanguageClicks :exec
+INSERT INTO language_clicks (slug, day, language, clicks)
+SELECT b.slug, (now() AT TIME ZONE 'UTC')::date, b.value, b.clicks
+FROM (SELECT unnest($1::TEXT[]) AS slug, unnest($2::TEXT[]) AS value, unnest($3::BIGINT[]) AS clicks) AS b
+ON CONFLICT(slug, day, language) DO UPDATE SET clicks = language_clicks.clicks + excluded.clicks
+`
+
+type FlushLanguageClicksParams struct {
+	Slugs  []string `json:"slugs"`
+	Vals   []string `json:"vals"`
+	Clicks []int64  `json:"clicks"`
+}
+
+func (q *Queries) FlushLanguageClicks(ctx context.Context, arg FlushLanguageClicksParams) error {
+	_, err := q.exec(ctx, q.flushLanguageClicksStmt, flushLanguageClicks, pq.Array(arg.Slugs), pq.Array(arg.Vals), pq.Array(arg.Clicks))
+	return err
+}
+
+const flushLinkClicks = `-- name: FlushLinkClicks :many
+
+UPDATE links SET clicks = links.clicks + b.clicks
+FROM (SELECT unnest($1::TEXT[]) AS slug, unnest($2::BIGINT[]) AS clicks) AS b
+WHERE links.slug = b.slug
+RETURNING links.slug
+`
+
+type FlushLinkClicksParams struct {
+	Slugs  []string `json:"slugs"`
+	Clicks []int64  `json:"clicks"`
+}
+
+// The click worker's batches: one statement per table over parallel
+// arrays, zipped by unnest.
+func (q *Queries) FlushLinkClicks(ctx context.Context, arg FlushLinkClicksParams) ([]string, error) {
+	rows, err := q.query(ctx, q.flushLinkClicksStmt, flushLinkClicks, pq.Array(arg.Slugs), pq.Array(arg.Clicks))
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+	var items []string
+	for rows.Next() {
+		var slug string
+		if err := rows.Scan(&slug); err != nil {
+			return nil, err
+		}
+		items = append(items, slug)
+	}
+	if err := rows.Close(); err != nil {
+		return nil, err
+	}
+	if err := rows.Err(); err != nil {
+		return nil, err
+	}
+	return items, nil
+}
+
+const flushSourceClicks = `-- name: FlushSourceClicks :exec
+INSERT INTO source_clicks (slug, day, source, clicks)
+SELECT b.slug, (now() AT TIME ZONE 'UTC')::date, b.value, b.clicks
+FROM (SELECT unnest($1::TEXT[]) AS slug, unnest($2::TEXT[]) AS value, unnest($3::BIGINT[]) AS clicks) AS b
+ON CONFLICT(slug, day, source) DO UPDATE SET clicks = source_clicks.clicks + excluded.clicks
+`
+
+type FlushSourceClicksParams struct {
+	Slugs  []string `json:"slugs"`
+	Vals   []string `json:"vals"`
+	Clicks []int64  `json:"clicks"`
+}
+
+func (q *Queries) FlushSourceClicks(ctx context.Context, arg FlushSourceClicksParams) error {
+	_, err := q.exec(ctx, q.flushSourceClicksStmt, flushSourceClicks, pq.Array(arg.Slugs), pq.Array(arg.Vals), pq.Array(arg.Clicks))
+	return err
+}
+
+const flushVariantClicks = `-- name: FlushVariantClicks :exec
+UPDATE link_variants SET clicks = link_variants.clicks + b.clicks
+FROM (SELECT unnest($1::TEXT[]) AS slug, unnest($2::TEXT[]) AS name, unnest($3::BIGINT[]) AS clicks) AS b
+WHERE link_variants.slug = b.slug AND link_variants.name = b.name
+`
+
+type FlushVariantClicksParams struct {
+	Slugs  []string `json:"slugs"`
+	Vals   []string `json:"vals"`
+	Clicks []int64  `json:"clicks"`
+}
+
+func (q *Queries) FlushVariantClicks(ctx context.Context, arg FlushVariantClicksParams) error {
+	_, err := q.exec(ctx, q.flushVariantClicksStmt, flushVariantClicks, pq.Array(arg.Slugs), pq.Array(arg.Vals), pq.Array(arg.Clicks))
+	return err
+}
+
+const getCountryClicks = `-- name: GetCountryClicks :many
+SELECT country, SUM(clicks)::BIGINT AS clicks
+FROM country_clicks
+WHERE slug = $1
+  AND day >= (now() AT TIME ZONE 'UTC')::date - 6
+GROUP BY country
+ORDER BY clicks DESC
+`
+
+type GetCountryClicksRow struct {
+	Country string `json:"country"`
+	Clicks  int64  `json:"clicks"`
+}
+
+func (q *Queries) GetCountryClicks(ctx context.Context, slug string) ([]GetCountryClicksRow, error) {
+	rows, err := q.query(ctx, q.getCountryClicksStmt, getCountryClicks, slug)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+	var items []GetCountryClicksRow
+	for rows.Next() {
+		var i GetCountryClicksRow
+		if err := rows.Scan(&i.Country, &i.Clicks); err != nil {
+			return nil, err
+		}
+		items = append(items, i)
+	}
+	if err := rows.Close(); err != nil {
+		return nil, err
+	}
+	if err := rows.Err(); err != nil {
+		return nil, err
+	}
+	return items, nil
+}
+
+const getDailyClicks = `-- name: GetDailyClicks :many
+SELECT day, clicks
+FROM daily_clicks
+WHERE slug = $1
+  AND day >= (now() AT TIME ZONE 'UTC')::date - 6
+ORDER BY day ASC
+`
+
+type GetDailyClicksRow struct {
+	Day    time.Time     `json:"day"`
+	Clicks sql.NullInt64 `json:"clicks"`
+}
+
+func (q *Queries) GetDailyClicks(ctx context.Context, slug string) ([]GetDailyClicksRow, error) {
+	rows, err := q.query(ctx, q.getDailyClicksStmt, getDailyClicks, slug)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+	var items []GetDailyClicksRow
+	for rows.Next() {
+		var i GetDailyClicksRow
+		if err := rows.Scan(&i.Day, &i.Clicks); err != nil {
+			return nil, err
+		}
+		items = append(items, i)
+	}
+	if err := rows.Close(); err != nil {
+		return nil, err
+	}
+	if err := rows.Err(); err != nil {
+		return nil, err
+	}
+	return items, nil
+}
+
+const getIdempotentResponse = `-- name: GetIdempotentResponse :one
+SELECT request_hash, status, body
+FROM idempotency_keys
+WHERE owner = $1 AND key = $2 AND expires_at > $3
+`
+
+type GetIdempotentResponseParams struct {
+	Owner string `json:"owner"`
+	Key   string `json:"key"`
+	Now   int64  `json:"now"`
+}
+
+type GetIdempotentResponseRow struct {
+	RequestHash string `json:"request_hash"`
+	Status      int64  `json:"status"`
+	Body        string `json:"body"`
+}
+
+func (q *Queries) GetIdempotentResponse(ctx context.Context, arg GetIdempotentResponseParams) (GetIdempotentResponseRow, error) {
+	row := q.queryRow(ctx, q.getIdempotentResponseStmt, getIdempotentResponse, arg.Owner, arg.Key, arg.Now)
+	var i GetIdempotentResponseRow
+	err := row.Scan(&i.RequestHash, &i.Status, &i.Body)
+	return i, err
+}
+
+const getLanguageClicks = `-- name: GetLanguageClicks :many
+SELECT language, SUM(clicks)::BIGINT AS clicks
+FROM language_clicks
+WHERE slug = $1
+  AND day >= (now() AT TIME ZONE 'UTC')::date - 6
+GROUP BY language
+ORDER BY clicks DESC
+`
+
+type GetLanguageClicksRow struct {
+	Language string `json:"language"`
+	Clicks   int64  `json:"clicks"`
+}
+
+func (q *Queries) GetLanguageClicks(ctx context.Context, slug string) ([]GetLanguageClicksRow, error) {
+	rows, err := q.query(ctx, q.getLanguageClicksStmt, getLanguageClicks, slug)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+	var items []GetLanguageClicksRow
+	for rows.Next() {
+		var i GetLanguageClicksRow
+		if err := rows.Scan(&i.Language, &i.Clicks); err != nil {
+			return nil, err
+		}
+		items = append(items, i)
+	}
+	if err := rows.Close(); err != nil {
+		return nil, err
+	}
+	if err := rows.Err(); err != nil {
+		return nil, err
+	}
+	return items, nil
+}
+
+const getLink = `-- name: GetLink :one
+SELECT id, slug, url, "user", created_at, clicks, password_hash, interstitial, takedown_status, takedown_reason, original_url
+FROM links
+WHERE slug = $1
+`
+
+func (q *Queries) GetLink(ctx context.Context, slug string) (Link, error) {
+	row := q.queryRow(ctx, q.getLinkStmt, getLink, slug)
+	var i Link
+	err := row.Scan(
+		&i.ID,
+		&i.Slug,
+		&i.Url,
+		&i.User,
+		&i.CreatedAt,
+		&i.Clicks,
+		&i.PasswordHash,
+		&i.Interstitial,
+		&i.TakedownStatus,
+		&i.TakedownReason,
+		&i.OriginalUrl,
+	)
+	return i, err
+}
+
+const getLinkSchedules = `-- name: GetLinkSchedules :many
+SELECT id, slug, starts_at, ends_at, url
+FROM link_schedules
+WHERE slug = $1
+ORDER BY starts_at ASC NULLS FIRST
+`
+
+func (q *Queries) GetLinkSchedules(ctx context.Context, slug string) ([]LinkSchedule, error) {
+	rows, err := q.query(ctx, q.getLinkSchedulesStmt, getLinkSchedules, slug)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+	var items []LinkSchedule
+	for rows.Next() {
+		var i LinkSchedule
+		if err := rows.Scan(
+			&i.ID,
+			&i.Slug,
+			&i.StartsAt,
+			&i.EndsAt,
+			&i.Url,
+		); err != nil {
+			return nil, err
+		}
+		items = append(items, i)
+	}
+	if err := rows.Close(); err != nil {
+		return nil, err
+	}
+	if err := rows.Err(); err != nil {
+		return nil, err
+	}
+	return items, nil
+}
+
+const getLinkStats = `-- name: GetLinkStats :one
+SELECT id, slug, url, "user", created_at, clicks, password_hash, interstitial, takedown_status, takedown_reason, original_url
+FROM links
+WHERE slug = $1
+`
+
+func (q *Queries) GetLinkStats(ctx context.Context, slug string) (Link, error) {
+	row := q.queryRow(ctx, q.getLinkStatsStmt, getLinkStats, slug)
+	var i Link
+	err := row.Scan(
+		&i.ID,
+		&i.Slug,
+		&i.Url,
+		&i.User,
+		&i.CreatedAt,
+		&i.Clicks,
+		&i.PasswordHash,
+		&i.Interstitial,
+		&i.TakedownStatus,
+		&i.TakedownReason,
+		&i.OriginalUrl,
+	)
+	return i, err
+}
+
+const getLinkTargets = `-- name: GetLinkTargets :many
+SELECT id, slug, kind, value, url
+FROM link_targets
+WHERE slug = $1
+`
+
+func (q *Queries) GetLinkTargets(ctx context.Context, slug string) ([]LinkTarget, error) {
+	rows, err := q.query(ctx, q.getLinkTargetsStmt, getLinkTargets, slug)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+	var items []LinkTarget
+	for rows.Next() {
+		var i LinkTarget
+		if err := rows.Scan(
+			&i.ID,
+			&i.Slug,
+			&i.Kind,
+			&i.Value,
+			&i.Url,
+		); err != nil {
+			return nil, err
+		}
+		items = append(items, i)
+	}
+	if err := rows.Close(); err != nil {
+		return nil, err
+	}
+	if err := rows.Err(); err != nil {
+		return nil, err
+	}
+	return items, nil
+}
+
+const getLinkVariants = `-- name: GetLinkVariants :many
+SELECT id, slug, name, url, weight, clicks
+FROM link_variants
+WHERE slug = $1
+ORDER BY id ASC
+`
+
+func (q *Queries) GetLinkVariants(ctx context.Context, slug string) ([]LinkVariant, error) {
+	rows, err := q.query(ctx, q.getLinkVariantsStmt, getLinkVariants, slug)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+	var items []LinkVariant
+	for rows.Next() {
+		var i LinkVariant
+		if err := rows.Scan(
+			&i.ID,
+			&i.Slug,
+			&i.Name,
+			&i.Url,
+			&i.Weight,
+			&i.Clicks,
+		); err != nil {
+			return nil, err
+		}
+		items = append(items, i)
+	}
+	if err := rows.Close(); err != nil {
+		return nil, err
+	}
+	if err := rows.Err(); err != nil {
+		return nil, err
+	}
+	return items, nil
+}
+
+const getReport = `-- name: GetReport :one
+SELECT id, slug, reason, details, contact, reporter_ip, status, created_at, resolved_at
+FROM reports
+WHERE id = $1
+`
+
+func (q *Queries) GetReport(ctx context.Context, id int64) (Report, error) {
+	row := q.queryRow(ctx, q.getReportStmt, getReport, id)
+	var i Report
+	err := row.Scan(
+		&i.ID,
+		&i.Slug,
+		&i.Reason,
+		&i.Details,
+		&i.Contact,
+		&i.ReporterIp,
+		&i.Status,
+		&i.CreatedAt,
+		&i.ResolvedAt,
+	)
+	return i, err
+}
+
+const getSourceClicks = `-- name: GetSourceClicks :many
+SELECT source, SUM(clicks)::BIGINT AS clicks
+FROM source_clicks
+WHERE slug = $1
+  AND day >= (now() AT TIME ZONE 'UTC')::date - 6
+GROUP BY source
+ORDER BY clicks DESC
+`
+
+type GetSourceClicksRow struct {
+	Source string `json:"source"`
+	Clicks int64  `json:"clicks"`
+}
+
+func (q *Queries) GetSourceClicks(ctx context.Context, slug string) ([]GetSourceClicksRow, error) {
+	rows, err := q.query(ctx, q.getSourceClicksStmt, getSourceClicks, slug)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+	var items []GetSourceClicksRow
+	for rows.Next() {
+		var i GetSourceClicksRow
+		if err := rows.Scan(&i.Source, &i.Clicks); err != nil {
+			return nil, err
+		}
+		items = append(items, i)
+	}
+	if err := rows.Close(); err != nil {
+		return nil, err
+	}
+	if err := rows.Err(); err != nil {
+		return nil, err
+	}
+	return items, nil
+}
+
+const importLink = `-- name: ImportLink :exec
+INSERT INTO links (slug, url, "user", created_at, clicks, original_url, password_hash, interstitial, takedown_status, takedown_reason)
+VALUES ($1, $2, $3, $4, $5, $6, $7, $8,
+  $9, $10)
+`
+
+type ImportLinkParams struct {
+	Slug           string         `json:"slug"`
+	Url            string         `json:"url"`
+	User           sql.NullString `json:"user"`
+	CreatedAt      time.Time      `json:"created_at"`
+	Clicks         sql.NullInt64  `json:"clicks"`
+	OriginalUrl    sql.NullString `json:"original_url"`
+	PasswordHash   sql.NullString `json:"password_hash"`
+	Interstitial   bool           `json:"interstitial"`
+	TakedownStatus sql.NullInt64  `json:"takedown_status"`
+	TakedownReason sql.NullString `json:"takedown_reason"`
+}
+
+func (q *Queries) ImportLink(ctx context.Context, arg ImportLinkParams) error {
+	_, err := q.exec(ctx, q.importLinkStmt, importLink,
+		arg.Slug,
+		arg.Url,
+		arg.User,
+		arg.CreatedAt,
+		arg.Clicks,
+		arg.OriginalUrl,
+		arg.PasswordHash,
+		arg.Interstitial,
+		arg.TakedownStatus,
+		arg.TakedownReason,
+	)
+	return err
+}
+
+const listDailyClicks = `-- name: ListDailyClicks :many
+SELECT day, clicks
+FROM daily_clicks
+WHERE slug = $1
+ORDER BY day ASC
+`
+
+type ListDailyClicksRow struct {
+	Day    time.Time     `json:"day"`
+	Clicks sql.NullInt64 `json:"clicks"`
+}
+
+func (q *Queries) ListDailyClicks(ctx context.Context, slug string) ([]ListDailyClicksRow, error) {
+	rows, err := q.query(ctx, q.listDailyClicksStmt, listDailyClicks, slug)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+	var items []ListDailyClicksRow
+	for rows.Next() {
+		var i ListDailyClicksRow
+		if err := rows.Scan(&i.Day, &i.Clicks); err != nil {
+			return nil, err
+		}
+		items = append(items, i)
+	}
+	if err := rows.Close(); err != nil {
+		return nil, err
+	}
+	if err := rows.Err(); err != nil {
+		return nil, err
+	}
+	return items, nil
+}
+
+const listLinkDestinations = `-- name: ListLinkDestinations :many
+SELECT owner, url_key
+FROM link_destinations
+WHERE slug = $1
+ORDER BY owner ASC
+`
+
+type ListLinkDestinationsRow struct {
+	Owner  string `json:"owner"`
+	UrlKey string `json:"url_key"`
+}
+
+func (q *Queries) ListLinkDestinations(ctx context.Context, slug string) ([]ListLinkDestinationsRow, error) {
+	rows, err := q.query(ctx, q.listLinkDestinationsStmt, listLinkDestinations, slug)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+	var items []ListLinkDestinationsRow
+	for rows.Next() {
+		var i ListLinkDestinationsRow
+		if err := rows.Scan(&i.Owner, &i.UrlKey); err != nil {
+			return nil, err
+		}
+		items = append(items, i)
+	}
+	if err := rows.Close(); err != nil {
+		return nil, err
+	}
+	if err := rows.Err(); err != nil {
+		return nil, err
+	}
+	return items, nil
+}
+
+const listLinks = `-- name: ListLinks :many
+SELECT id, slug, url, "user", created_at, clicks, password_hash, interstitial, takedown_status, takedown_reason, original_url
+FROM links
+WHERE id > $1
+ORDER BY id ASC
+LIMIT $2::BIGINT
+`
+
+type ListLinksParams struct {
+	AfterID int64 `json:"after_id"`
+	Limit   int64 `json:"limit"`
+}
+
+func (q *Queries) ListLinks(ctx context.Context, arg ListLinksParams) ([]Link, error) {
+	rows, err := q.query(ctx, q.listLinksStmt, listLinks, arg.AfterID, arg.Limit)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+	var items []Link
+	for rows.Next() {
+		var i Link
+		if err := rows.Scan(
+			&i.ID,
+			&i.Slug,
+			&i.Url,
+			&i.User,
+			&i.CreatedAt,
+			&i.Clicks,
+			&i.PasswordHash,
+			&i.Interstitial,
+			&i.TakedownStatus,
+			&i.TakedownReason,
+			&i.OriginalUrl,
+		); err != nil {
+			return nil, err
+		}
+		items = append(items, i)
+	}
+	if err := rows.Close(); err != nil {
+		return nil, err
+	}
+	if err := rows.Err(); err != nil {
+		return nil, err
+	}
+	return items, nil
+}
+
+const listReports = `-- name: ListReports :many
+SELECT id, slug, reason, details, contact, reporter_ip, status, created_at, resolved_at
+FROM reports
+WHERE status = $1
+ORDER BY created_at ASC, id ASC
+LIMIT $2::BIGINT
+`
+
+type ListReportsParams struct {
+	Status string `json:"status"`
+	Limit  int64  `json:"limit"`
+}
+
+func (q *Queries) ListReports(ctx context.Context, arg ListReportsParams) ([]Report, error) {
+	rows, err := q.query(ctx, q.listReportsStmt, listReports, arg.Status, arg.Limit)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+	var items []Report
+	for rows.Next() {
+		var i Report
+		if err := rows.Scan(
+			&i.ID,
+			&i.Slug,
+			&i.Reason,
+			&i.Details,
+			&i.Contact,
+			&i.ReporterIp,
+			&i.Status,
+			&i.CreatedAt,
+			&i.ResolvedAt,
+		); err != nil {
+			return nil, err
+		}
+		items = append(items, i)
+	}
+	if err := rows.Close(); err != nil {
+		return nil, err
+	}
+	if err := rows.Err(); err != nil {
+		return nil, err
+	}
+	return items, nil
+}
+
+const replaceLink = `-- name: ReplaceLink :exec
+UPDATE links SET url = $1, "user" = $2, created_at = $3, clicks = $4,
+  original_url = $5, password_hash = $6, interstitial = $7,
+  takedown_status = $8, takedown_reason = $9
+WHERE slug = $10
+`
+
+type ReplaceLinkParams struct {
+	Url            string         `json:"url"`
+	User           sql.NullString `json:"user"`
+	CreatedAt      time.Time      `json:"created_at"`
+	Clicks         sql.NullInt64  `json:"clicks"`
+	OriginalUrl    sql.NullString `json:"original_url"`
+	PasswordHash   sql.NullString `json:"password_hash"`
+	Interstitial   bool           `json:"interstitial"`
+	TakedownStatus sql.NullInt64  `json:"takedown_status"`
+	TakedownReason sql.NullString `json:"takedown_reason"`
+	Slug           string         `json:"slug"`
+}
+
+func (q *Queries) ReplaceLink(ctx context.Context, arg ReplaceLinkParams) error {
+	_, err := q.exec(ctx, q.replaceLinkStmt, replaceLink,
+		arg.Url,
+		arg.User,
+		arg.CreatedAt,
+		arg.Clicks,
+		arg.OriginalUrl,
+		arg.PasswordHash,
+		arg.Interstitial,
+		arg.TakedownStatus,
+		arg.TakedownReason,
+		arg.Slug,
+	)
+	return err
+}
+
+const resolveReports = `-- name: ResolveReports :exec
+UPDATE reports SET status = $1, resolved_at = now()
+WHERE slug = $2 AND status = 'open'
+`
+
+type ResolveReportsParams struct {
+	Status string `json:"status"`
+	Slug   string `json:"slug"`
+}
+
+func (q *Queries) ResolveReports(ctx context.Context, arg ResolveReportsParams) error {
+	_, err := q.exec(ctx, q.resolveReportsStmt, resolveReports, arg.Status, arg.Slug)
+	return err
+}
+
+const saveCountryClicks = `-- name: SaveCountryClicks :exec
+INSERT INTO country_clicks (slug, day, country, clicks)
+VALUES ($1, (now() AT TIME ZONE 'UTC')::date, $2, $3)
+ON CONFLICT(slug, day, country) DO UPDATE SET clicks = country_clicks.clicks + excluded.clicks
+`
+
+type SaveCountryClicksParams struct {
+	Slug    string        `json:"slug"`
+	Country string        `json:"country"`
+	Clicks  sql.NullInt64 `json:"clicks"`
+}
+
+func (q *Queries) SaveCountryClicks(ctx context.Context, arg SaveCountryClicksParams) error {
+	_, err := q.exec(ctx, q.saveCountryClicksStmt, saveCountryClicks, arg.Slug, arg.Country, arg.Clicks)
+	return err
+}
+
+const saveDailyClicks = `-- name: SaveDailyClicks :exec
+INSERT INTO daily_clicks (slug, day, clicks)
+VALUES ($1, (now() AT TIME ZONE 'UTC')::date, $2)
+ON CONFLICT(slug, day) DO UPDATE SET clicks = daily_clicks.clicks + excluded.clicks
+`
+
+type SaveDailyClicksParams struct {
+	Slug   string        `json:"slug"`
+	Clicks sql.NullInt64 `json:"clicks"`
+}
+
+func (q *Queries) SaveDailyClicks(ctx context.Context, arg SaveDailyClicksParams) error {
+	_, err := q.exec(ctx, q.saveDailyClicksStmt, saveDailyClicks, arg.Slug, arg.Clicks)
+	return err
+}
+
+const saveIdempotentResponse = `-- name: SaveIdempotentResponse :execrows
+INSERT INTO idempotency_keys (owner, key, request_hash, status, body, expires_at)
+VALUES ($1, $2, $3, $4, $5, $6)
+ON CONFLICT (owner, key) DO NOTHING
+`
+
+type SaveIdempotentResponseParams struct {
+	Owner       string `json:"owner"`
+	Key         string `json:"key"`
+	RequestHash string `json:"request_hash"`
+	Status      int64  `json:"status"`
+	Body        string `json:"body"`
+	ExpiresAt   int64  `json:"expires_at"`
+}
+
+func (q *Queries) SaveIdempotentResponse(ctx context.Context, arg SaveIdempotentResponseParams) (int64, error) {
+	result, err := q.exec(ctx, q.saveIdempotentResponseStmt, saveIdempotentResponse,
+		arg.Owner,
+		arg.Key,
+		arg.RequestHash,
+		arg.Status,
+		arg.Body,
+		arg.ExpiresAt,
+	)
+	if err != nil {
+		return 0, err
+	}
+	return result.RowsAffected()
+}
+
+const saveLanguageClicks = `-- name: SaveLanguageClicks :exec
+INSERT INTO language_clicks (slug, day, language, clicks)
+VALUES ($1, (now() AT TIME ZONE 'UTC')::date, $2, $3)
+ON CONFLICT(slug, day, language) DO UPDATE SET clicks = language_clicks.clicks + excluded.clicks
+`
+
+type SaveLanguageClicksParams struct {
+	Slug     string        `json:"slug"`
+	Language string        `json:"language"`
+	Clicks   sql.NullInt64 `json:"clicks"`
+}
+
+func (q *Queries) SaveLanguageClicks(ctx context.Context, arg SaveLanguageClicksParams) error {
+	_, err := q.exec(ctx, q.saveLanguageClicksStmt, saveLanguageClicks, arg.Slug, arg.Language, arg.Clicks)
+	return err
+}
+
+const saveSourceClicks = `-- name: SaveSourceClicks :exec
+INSERT INTO source_clicks (slug, day, source, clicks)
+VALUES ($1, (now() AT TIME ZONE 'UTC')::date, $2, $3)
+ON CONFLICT(slug, day, source) DO UPDATE SET clicks = source_clicks.clicks + excluded.clicks
+`
+
+type SaveSourceClicksParams struct {
+	Slug   string        `json:"slug"`
+	Source string        `json:"source"`
+	Clicks sql.NullInt64 `json:"clicks"`
+}
+
+func (q *Queries) SaveSourceClicks(ctx context.Context, arg SaveSourceClicksParams) error {
+	_, err := q.exec(ctx, q.saveSourceClicksStmt, saveSourceClicks, arg.Slug, arg.Source, arg.Clicks)
+	return err
+}
+
+const setDailyClicks = `-- name: SetDailyClicks :exec
+INSERT INTO daily_clicks (slug, day, clicks)
+VALUES ($1, $2, $3)
+ON CONFLICT(slug, day) DO UPDATE SET clicks = excluded.clicks
+`
+
+type SetDailyClicksParams struct {
+	Slug   string        `json:"slug"`
+	Day    time.Time     `json:"day"`
+	Clicks sql.NullInt64 `json:"clicks"`
+}
+
+func (q *Queries) SetDailyClicks(ctx context.Context, arg SetDailyClicksParams) error {
+	_, err := q.exec(ctx, q.setDailyClicksStmt, setDailyClicks, arg.Slug, arg.Day, arg.Clicks)
+	return err
+}
+
+const setLinkInterstitial = `-- name: SetLinkInterstitial :exec
+UPDATE links SET interstitial = $1 WHERE slug = $2
+`
+
+type SetLinkInterstitialParams struct {
+	Interstitial bool   `json:"interstitial"`
+	Slug         string `json:"slug"`
+}
+
+func (q *Queries) SetLinkInterstitial(ctx context.Context, arg SetLinkInterstitialParams) error {
+	_, err := q.exec(ctx, q.setLinkInterstitialStmt, setLinkInterstitial, arg.Interstitial, arg.Slug)
+	return err
+}
+
+const setLinkOriginalURL = `-- name: SetLinkOriginalURL :exec
+UPDATE links SET original_url = $1 WHERE slug = $2
+`
+
+type SetLinkOriginalURLParams struct {
+	OriginalUrl sql.NullString `json:"original_url"`
+	Slug        string         `json:"slug"`
+}
+
+func (q *Queries) SetLinkOriginalURL(ctx context.Context, arg SetLinkOriginalURLParams) error {
+	_, err := q.exec(ctx, q.setLinkOriginalURLStmt, setLinkOriginalURL, arg.OriginalUrl, arg.Slug)
+	return err
+}
+
+const setLinkPassword = `-- name: SetLinkPassword :exec
+UPDATE links SET password_hash = $1 WHERE slug = $2
+`
+
+type SetLinkPasswordParams struct {
+	PasswordHash sql.NullString `json:"password_hash"`
+	Slug         string         `json:"slug"`
+}
+
+func (q *Queries) SetLinkPassword(ctx context.Context, arg SetLinkPasswordParams) error {
+	_, err := q.exec(ctx, q.setLinkPasswordStmt, setLinkPassword, arg.PasswordHash, arg.Slug)
+	return err
+}
+
+const setLinkTakedown = `-- name: SetLinkTakedown :exec
+UPDATE links SET takedown_status = $1, takedown_reason = $2 WHERE slug = $3
+`
+
+type SetLinkTakedownParams struct {
+	TakedownStatus sql.NullInt64  `json:"takedown_status"`
+	TakedownReason sql.NullString `json:"takedown_reason"`
+	Slug           string         `json:"slug"`
+}
+
+func (q *Queries) SetLinkTakedown(ctx context.Context, arg SetLinkTakedownParams) error {
+	_, err := q.exec(ctx, q.setLinkTakedownStmt, setLinkTakedown, arg.TakedownStatus, arg.TakedownReason, arg.Slug)
+	return err
+}
+
+const updateLinkSlug = `-- name: UpdateLinkSlug :exec
+UPDATE links SET slug = $1 WHERE id = $2
+`
+
+type UpdateLinkSlugParams struct {
+	Slug string `json:"slug"`
+	ID   int64  `json:"id"`
+}
+
+func (q *Queries) UpdateLinkSlug(ctx context.Context, arg UpdateLinkSlugParams) error {
+	_, err := q.exec(ctx, q.updateLinkSlugStmt, updateLinkSlug, arg.Slug, arg.ID)
+	return err
+}
+
+const updateLinkURL = `-- name: UpdateLinkURL :exec
+UPDATE links SET url = $1 WHERE slug = $2
+`
+
+type UpdateLinkURLParams struct {
+	Url  string `json:"url"`
+	Slug string `json:"slug"`
+}
+
+func (q *Queries) UpdateLinkURL(ctx context.Context, arg UpdateLinkURLParams) error {
+	_, err := q.exec(ctx, q.updateLinkURLStmt, updateLinkURL, arg.Url, arg.Slug)
+	return err
+}
@@ -0,0 +1,107 @@
+// Code generated by sqlc. DO NOT EDIT.
+// versions:
+//   sqlc v1.30.0
+
+package pg
+
+import (
+	"database/sql"
+	"time"
+)
+
+type CountryClick struct {
+	ID      int64         `json:"id"`
+	Slug    string        `json:"slug"`
+	Day     time.Time     `json:"day"`
+	Country string        `json:"country"`
+	Clicks  sql.NullInt64 `json:"clicks"`
+}
+
+type DailyClick struct {
+	ID     int64         `json:"id"`
+	Slug   string        `json:"slug"`
+	Day    time.Time     `json:"day"`
+	Clicks sql.NullInt64 `json:"clicks"`
+}
+
+type IdempotencyKey struct {
+	Owner       string `json:"owner"`
+	Key         string `json:"key"`
+	RequestHash string `json:"request_hash"`
+	Status      int64  `json:"status"`
+	Body        string `json:"body"`
+	ExpiresAt   int64  `json:"expires_at"`
+}
+
+type LanguageClick struct {
+	ID       int64         `json:"id"`
+	Slug     string        `json:"slug"`
+	Day      time.Time     `json:"day"`
+	Language string        `json:"language"`
+	Clicks   sql.NullInt64 `json:"clicks"`
+}
+
+type Link struct {
+	ID             int64          `json:"id"`
+	Slug           string         `json:"slug"`
+	Url            string         `json:"url"`
+	User           sql.NullString `json:"user"`
+	CreatedAt      time.Time      `json:"created_at"`
+	Clicks         sql.NullInt64  `json:"clicks"`
+	PasswordHash   sql.NullString `json:"password_hash"`
+	Interstitial   bool           `json:"interstitial"`
+	TakedownStatus sql.NullInt64  `json:"takedown_status"`
+	TakedownReason sql.NullString `json:"takedown_reason"`
+	OriginalUrl    sql.NullString `json:"original_url"`
+}
+
+type LinkDestination struct {
+	Slug   string `json:"slug"`
+	Owner  string `json:"owner"`
+	UrlKey string `json:"url_key"`
+}
+
+type LinkSchedule struct {
+	ID       int64        `json:"id"`
+	Slug     string       `json:"slug"`
+	StartsAt sql.NullTime `json:"starts_at"`
+	EndsAt   sql.NullTime `json:"ends_at"`
+	Url      string       `json:"url"`
+}
+
+type LinkTarget struct {
+	ID    int64  `json:"id"`
+	Slug  string `json:"slug"`
+	Kind  string `json:"kind"`
+	Value string `json:"value"`
+	Url   string `json:"url"`
+}
+
+type LinkVariant struct {
+	ID     int64         `json:"id"`
+	Slug   string        `json:"slug"`
+	Name   string        `json:"name"`
+	Url    string        `json:"url"`
+	Weight int64         `json:"weight"`
+	Clicks sql.NullInt64 `json:"clicks"`
+}
+
+type Report struct {
+	ID         int64          `json:"id"`
+	Slug       string         `json:"slug"`
+	Reason     string         `json:"reason"`
+	Details    sql.NullString `json:"details"`
+	Contact    sql.NullString `json:"contact"`
+	ReporterIp sql.NullString `json:"reporter_ip"`
+	Status     string         `json:"status"`
+	CreatedAt  time.Time      `json:"created_at"`
+	ResolvedAt sql.NullTime   `json:"resolved_at"`
+}
+
+type SourceClick struct {
+	ID     int64         `json:"id"`
+	Slug   string        `json:"slug"`
+	Day    time.Time     `json:"day"`
+	Source string        `json:"source"`
+	Clicks sql.NullInt64 `json:"clicks"`
+}
@@ -0,0 +1,270 @@
+-- name: AddLink :one
+INSERT INTO links (slug, url, "user", created_at, clicks)
+VALUES ($1, $2, $3, now(), 0)
+RETURNING id, slug, url, "user", created_at, clicks, password_hash, interstitial, takedown_status, takedown_reason, original_url;
+
+-- name: GetLink :one
+SELECT id, slug, url, "user", created_at, clicks, password_hash, interstitial, takedown_status, takedown_reason, original_url
+FROM links
+WHERE slug = $1;
+
+-- name: AddClick :execrows
+UPDATE links SET clicks = clicks + $1 WHERE slug = $2;
+
+-- name: SaveDailyClicks :exec
+INSERT INTO daily_clicks (slug, day, clicks)
+VALUES ($1, (now() AT TIME ZONE 'UTC')::date, $2)
+ON CONFLICT(slug, day) DO UPDATE SET clicks = daily_clicks.clicks + excluded.clicks;
+
+-- name: GetLinkStats :one
+SELECT id, slug, url, "user", created_at, clicks, password_hash, interstitial, takedown_status, takedown_reason, original_url
+FROM links
+WHERE slug = @slug;
+
+-- name: GetDailyClicks :many
+SELECT day, clicks
+FROM daily_clicks
+WHERE slug = @slug
+  AND day >= (now() AT TIME ZONE 'UTC')::date - 6
+ORDER BY day ASC;
+
+-- name: AddLinkTarget :exec
+INSERT INTO link_targets (slug, kind, value, url)
+VALUES ($1, $2, $3, $4);
+
+-- name: GetLinkTargets :many
+SELECT id, slug, kind, value, url
+FROM link_targets
+WHERE slug = $1;
+
+-- name: SaveCountryClicks :exec
+INSERT INTO country_clicks (slug, day, country, clicks)
+VALUES ($1, (now() AT TIME ZONE 'UTC')::date, $2, $3)
+ON CONFLICT(slug, day, country) DO UPDATE SET clicks = country_clicks.clicks + excluded.clicks;
+
+-- name: GetCountryClicks :many
+SELECT country, SUM(clicks)::BIGINT AS clicks
+FROM country_clicks
+WHERE slug = @slug
+  AND day >= (now() AT TIME ZONE 'UTC')::date - 6
+GROUP BY country
+ORDER BY clicks DESC;
+
+-- name: AddLinkVariant :exec
+INSERT INTO link_variants (slug, name, url, weight, clicks)
+VALUES ($1, $2, $3, $4, 0);
+
+-- name: GetLinkVariants :many
+SELECT id, slug, name, url, weight, clicks
+FROM link_variants
+WHERE slug = $1
+ORDER BY id ASC;
+
+-- name: AddVariantClick :exec
+UPDATE link_variants SET clicks = clicks + $1 WHERE slug = $2 AND name = $3;
+
+-- name: SaveLanguageClicks :exec
+INSERT INTO language_clicks (slug, day, language, clicks)
+VALUES ($1, (now() AT TIME ZONE 'UTC')::date, $2, $3)
+ON CONFLICT(slug, day, language) DO UPDATE SET clicks = language_clicks.clicks + excluded.clicks;
+
+-- name: GetLanguageClicks :many
+SELECT language, SUM(clicks)::BIGINT AS clicks
+FROM language_clicks
+WHERE slug = @slug
+  AND day >= (now() AT TIME ZONE 'UTC')::date - 6
+GROUP BY language
+ORDER BY clicks DESC;
+
+-- name: AddLinkSchedule :exec
+INSERT INTO link_schedules (slug, starts_at, ends_at, url)
+VALUES ($1, $2, $3, $4);
+
+-- name: GetLinkSchedules :many
+SELECT id, slug, starts_at, ends_at, url
+FROM link_schedules
+WHERE slug = $1
+ORDER BY starts_at ASC NULLS FIRST;
+
+-- name: SetLinkPassword :exec
+UPDATE links SET password_hash = $1 WHERE slug = $2;
+
+-- name: SetLinkInterstitial :exec
+UPDATE links SET interstitial = $1 WHERE slug = $2;
+
+-- name: SetLinkOriginalURL :exec
+UPDATE links SET original_url = $1 WHERE slug = $2;
+
+-- name: SaveSourceClicks :exec
+INSERT INTO source_clicks (slug, day, source, clicks)
+VALUES ($1, (now() AT TIME ZONE 'UTC')::date, $2, $3)
+ON CONFLICT(slug, day, source) DO UPDATE SET clicks = source_clicks.clicks + excluded.clicks;
+
+-- name: GetSourceClicks :many
+SELECT source, SUM(clicks)::BIGINT AS clicks
+FROM source_clicks
+WHERE slug = @slug
+  AND day >= (now() AT TIME ZONE 'UTC')::date - 6
+GROUP BY source
+ORDER BY clicks DESC;
+
+-- name: UpdateLinkURL :exec
+UPDATE links SET url = $1 WHERE slug = $2;
+
+-- name: SetLinkTakedown :exec
+UPDATE links SET takedown_status = $1, takedown_reason = $2 WHERE slug = $3;
+
+-- name: AddReport :one
+INSERT INTO reports (slug, reason, details, contact, reporter_ip)
+VALUES ($1, $2, $3, $4, $5)
+RETURNING id, slug, reason, details, contact, reporter_ip, status, created_at, resolved_at;
+
+-- name: ListReports :many
+SELECT id, slug, reason, details, contact, reporter_ip, status, created_at, resolved_at
+FROM reports
+WHERE status = @status
+ORDER BY created_at ASC, id ASC
+LIMIT sqlc.arg('limit')::BIGINT;
+
+-- name: GetReport :one
+SELECT id, slug, reason, details, contact, reporter_ip, status, created_at, resolved_at
+FROM reports
+WHERE id = $1;
+
+-- name: ResolveReports :exec
+UPDATE reports SET status = $1, resolved_at = now()
+WHERE slug = $2 AND status = 'open';
+
+-- name: UpdateLinkSlug :exec
+UPDATE links SET slug = @slug WHERE id = @id;
+
+-- name: CountLinks :one
+SELECT COUNT(*) FROM links;
+
+-- name: DeleteLinkByID :exec
+DELETE FROM links WHERE id = $1;
+
+-- name: ListLinks :many
+SELECT id, slug, url, "user", created_at, clicks, password_hash, interstitial, takedown_status, takedown_reason, original_url
+FROM links
+WHERE id > @after_id
+ORDER BY id ASC
+LIMIT sqlc.arg('limit')::BIGINT;
+
+-- name: ListDailyClicks :many
+SELECT day, clicks
+FROM daily_clicks
+WHERE slug = $1
+ORDER BY day ASC;
+
+-- name: ImportLink :exec
+INSERT INTO links (slug, url, "user", created_at, clicks, original_url, password_hash, interstitial, takedown_status, takedown_reason)
+VALUES (@slug, @url, sqlc.narg('user'), @created_at, @clicks, sqlc.narg('original_url'), sqlc.narg('password_hash'), @interstitial,
+  sqlc.narg('takedown_status'), sqlc.narg('takedown_reason'));
+
+-- name: ReplaceLink :exec
+UPDATE links SET url = @url, "user" = sqlc.narg('user'), created_at = @created_at, clicks = @clicks,
+  original_url = sqlc.narg('original_url'), password_hash = sqlc.narg('password_hash'), interstitial = @interstitial,
+  takedown_status = sqlc.narg('takedown_status'), takedown_reason = sqlc.narg('takedown_reason')
+WHERE slug = @slug;
+
+-- name: DeleteDailyClicks :exec
+DELETE FROM daily_clicks WHERE slug = $1;
+
+-- name: DeleteLinkTargets :exec
+DELETE FROM link_targets WHERE slug = $1;
+
+-- name: DeleteLinkVariants :exec
+DELETE FROM link_variants WHERE slug = $1;
+
+-- name: DeleteLinkSchedules :exec
+DELETE FROM link_schedules WHERE slug = $1;
+
+-- name: DeleteCountryClicks :exec
+DELETE FROM country_clicks WHERE slug = $1;
+
+-- name: DeleteLanguageClicks :exec
+DELETE FROM language_clicks WHERE slug = $1;
+
+-- name: DeleteSourceClicks :exec
+DELETE FROM source_clicks WHERE slug = $1;
+
+-- name: DeleteLinkDestinations :exec
+DELETE FROM link_destinations WHERE slug = $1;
+
+-- name: SetDailyClicks :exec
+INSERT INTO daily_clicks (slug, day, clicks)
+VALUES (@slug, @day, @clicks)
+ON CONFLICT(slug, day) DO UPDATE SET clicks = excluded.clicks;
+
+-- The click worker's batches: one statement per table over parallel
+-- arrays, zipped by unnest.
+
+-- name: FlushLinkClicks :many
+UPDATE links SET clicks = links.clicks + b.clicks
+FROM (SELECT unnest(@slugs::TEXT[]) AS slug, unnest(@clicks::BIGINT[]) AS clicks) AS b
+WHERE links.slug = b.slug
+RETURNING links.slug;
+
+-- name: FlushDailyClicks :exec
+INSERT INTO daily_clicks (slug, day, clicks)
+SELECT b.slug, (now() AT TIME ZONE 'UTC')::date, b.clicks
+FROM (SELECT unnest(@slugs::TEXT[]) AS slug, unnest(@clicks::BIGINT[]) AS clicks) AS b
+ON CONFLICT(slug, day) DO UPDATE SET clicks = daily_clicks.clicks + excluded.clicks;
+
+-- name: FlushCountryClicks :exec
+INSERT INTO country_clicks (slug, day, country, clicks)
+SELECT b.slug, (now() AT TIME ZONE 'UTC')::date, b.value, b.clicks
+FROM (SELECT unnest(@slugs::TEXT[]) AS slug, unnest(@vals::TEXT[]) AS value, unnest(@clicks::BIGINT[]) AS clicks) AS b
+ON CONFLICT(slug, day, country) DO UPDATE SET clicks = country_clicks.clicks + excluded.clicks;
+
+-- name: FlushLanguageClicks :exec
+INSERT INTO language_clicks (slug, day, language, clicks)
+SELECT b.slug, (now() AT TIME ZONE 'UTC')::date, b.value, b.clicks
+FROM (SELECT unnest(@slugs::TEXT[]) AS slug, unnest(@vals::TEXT[]) AS value, unnest(@clicks::BIGINT[]) AS clicks) AS b
+ON CONFLICT(slug, day, language) DO UPDATE SET clicks = language_clicks.clicks + excluded.clicks;
+
+-- name: FlushSourceClicks :exec
+INSERT INTO source_clicks (slug, day, source, clicks)
+SELECT b.slug, (now() AT TIME ZONE 'UTC')::date, b.value, b.clicks
+FROM (SELECT unnest(@slugs::TEXT[]) AS slug, unnest(@vals::TEXT[]) AS value, unnest(@clicks::BIGINT[]) AS clicks) AS b
+ON CONFLICT(slug, day, source) DO UPDATE SET clicks = source_clicks.clicks + excluded.clicks;
+
+-- name: FlushVariantClicks :exec
+UPDATE link_variants SET clicks = link_variants.clicks + b.clicks
+FROM (SELECT unnest(@slugs::TEXT[]) AS slug, unnest(@vals::TEXT[]) AS name, unnest(@clicks::BIGINT[]) AS clicks) AS b
+WHERE link_variants.slug = b.slug AND link_variants.name = b.name;
+
+-- name: GetIdempotentResponse :one
+SELECT request_hash, status, body
+FROM idempotency_keys
+WHERE owner = @owner AND key = @key AND expires_at > @now;
+
+-- name: SaveIdempotentResponse :execrows
+INSERT INTO idempotency_keys (owner, key, request_hash, status, body, expires_at)
+VALUES (@owner, @key, @request_hash, @status, @body, @expires_at)
+ON CONFLICT (owner, key) DO NOTHING;
+
+-- name: DeleteExpiredIdempotencyKeys :exec
+DELETE FROM idempotency_keys WHERE expires_at <= $1;
+
+-- name: AddLinkDestination :exec
+INSERT INTO link_destinations (slug, owner, url_key)
+VALUES ($1, $2, $3);
+
+-- name: ListLinkDestinations :many
+SELECT owner, url_key
+FROM link_destinations
+WHERE slug = $1
+ORDER BY owner ASC;
+
+-- name: FindLinkByDestination :one
+SELECT links.id, links.slug, links.url
+FROM link_destinations
+JOIN links ON links.slug = link_destinations.slug
+WHERE link_destinations.owner = @owner
+  AND link_destinations.url_key = @url_key
+  AND links.url = link_destinations.url_key -- not since pointed elsewhere
+  AND links.takedown_status IS NULL
+ORDER BY links.id ASC
+LIMIT 1;
@@ -1,14 +1,14 @@
 -- name: AddLink :one
 INSERT INTO links (slug, url, user, created_at, clicks)
 VALUES (:slug, :url, :user, datetime('now'), 0)
-RETURNING id, slug, url, user, created_at, clicks;
+RETURNING id, slug, url, user, created_at, clicks, password_hash, interstitial, takedown_status, takedown_reason, original_url;
 
 -- name: GetLink :one
-SELECT id, slug, url, user, created_at, clicks
+SELECT id, slug, url, user, created_at, clicks, password_hash, interstitial, takedown_status, takedown_reason, original_url
 FROM links
 WHERE slug = ?;
 
--- name: AddClick :exec
+-- name: AddClick :execrows
 UPDATE links SET clicks = clicks + ? WHERE slug = ?;
 
 -- name: SaveDailyClicks :exec
@@ -17,7 +17,7 @@ VALUES (?, date('now'), ?)
 ON CONFLICT(slug, day) DO UPDATE SET clicks = clicks + excluded.clicks;
 
 -- name: GetLinkStats :one
-SELECT id, slug, url, user, created_at, clicks
+SELECT id, slug, url, user, created_at, clicks, password_hash, interstitial, takedown_status, takedown_reason, original_url
 FROM links
 WHERE slug = :slug;
 
@@ -26,4 +26,206 @@ SELECT day, clicks
 FROM daily_clicks
 WHERE slug = :slug
   AND day >= date('now','-6 days')
-ORDER BY day ASC;
\ No newline at end of file
+ORDER BY day ASC;
+
+-- name: AddLinkTarget :exec
+INSERT INTO link_targets (slug, kind, value, url)
+VALUES (?, ?, ?, ?);
+
+-- name: GetLinkTargets :many
+SELECT id, slug, kind, value, url
+FROM link_targets
+WHERE slug = ?;
+
+-- name: SaveCountryClicks :exec
+INSERT INTO country_clicks (slug, day, country, clicks)
+VALUES (?, date('now'), ?, ?)
+ON CONFLICT(slug, day, country) DO UPDATE SET clicks = clicks + excluded.clicks;
+
+-- name: GetCountryClicks :many
+SELECT country, CAST(SUM(clicks) AS INTEGER) AS clicks
+FROM country_clicks
+WHERE slug = :slug
+  AND day >= date('now','-6 days')
+GROUP BY country
+ORDER BY clicks DESC;
+
+-- name: AddLinkVariant :exec
+INSERT INTO link_variants (slug, name, url, weight, clicks)
+VALUES (?, ?, ?, ?, 0);
+
+-- name: GetLinkVariants :many
+SELECT id, slug, name, url, weight, clicks
+FROM link_variants
+WHERE slug = ?
+ORDER BY id ASC;
+
+-- name: AddVariantClick :exec
+UPDATE link_variants SET clicks = clicks + ? WHERE slug = ? AND name = ?;
+
+-- name: SaveLanguageClicks :exec
+INSERT INTO language_clicks (slug, day, language, clicks)
+VALUES (?, date('now'), ?, ?)
+ON CONFLICT(slug, day, language) DO UPDATE SET clicks = clicks + excluded.clicks;
+
+-- name: GetLanguageClicks :many
+SELECT language, CAST(SUM(clicks) AS INTEGER) AS clicks
+FROM language_clicks
+WHERE slug = :slug
+  AND day >= date('now','-6 days')
+GROUP BY language
+ORDER BY clicks DESC;
+
+-- name: AddLinkSchedule :exec
+INSERT INTO link_schedules (slug, starts_at, ends_at, url)
+VALUES (?, ?, ?, ?);
+
+-- name: GetLinkSchedules :many
+SELECT id, slug, starts_at, ends_at, url
+FROM link_schedules
+WHERE slug = ?
+ORDER BY starts_at ASC;
+
+-- name: SetLinkPassword :exec
+UPDATE links SET password_hash = ? WHERE slug = ?;
+
+-- name: SetLinkInterstitial :exec
+UPDATE links SET interstitial = ? WHERE slug = ?;
+
+-- name: SetLinkOriginalURL :exec
+UPDATE links SET original_url = ? WHERE slug = ?;
+
+-- name: SaveSourceClicks :exec
+INSERT INTO source_clicks (slug, day, source, clicks)
+VALUES (?, date('now'), ?, ?)
+ON CONFLICT(slug, day, source) DO UPDATE SET clicks = clicks + excluded.clicks;
+
+-- name: GetSourceClicks :many
+SELECT source, CAST(SUM(clicks) AS INTEGER) AS clicks
+FROM source_clicks
+WHERE slug = :slug
+  AND day >= date('now','-6 days')
+GROUP BY source
+ORDER BY clicks DESC;
+
+-- name: UpdateLinkURL :exec
+UPDATE links SET url = ? WHERE slug = ?;
+
+-- name: SetLinkTakedown :exec
+UPDATE links SET takedown_status = ?, takedown_reason = ? WHERE slug = ?;
+
+-- name: AddReport :one
+INSERT INTO reports (slug, reason, details, contact, reporter_ip)
+VALUES (?, ?, ?, ?, ?)
+RETURNING id, slug, reason, details, contact, reporter_ip, status, created_at, resolved_at;
+
+-- name: ListReports :many
+SELECT id, slug, reason, details, contact, reporter_ip, status, created_at, resolved_at
+FROM reports
+WHERE status = ?
+ORDER BY created_at ASC, id ASC
+LIMIT ?;
+
+-- name: GetReport :one
+SELECT id, slug, reason, details, contact, reporter_ip, status, created_at, resolved_at
+FROM reports
+WHERE id = ?;
+
+-- name: ResolveReports :exec
+UPDATE reports SET status = ?, resolved_at = datetime('now')
+WHERE slug = ? AND status = 'open';
+
+-- name: UpdateLinkSlug :exec
+UPDATE links SET slug = :slug WHERE id = :id;
+
+-- name: CountLinks :one
+SELECT COUNT(*) FROM links;
+
+-- name: DeleteLinkByID :exec
+DELETE FROM links WHERE id = ?;
+
+-- name: ListLinks :many
+SELECT id, slug, url, user, created_at, clicks, password_hash, interstitial, takedown_status, takedown_reason, original_url
+FROM links
+WHERE id > :after_id
+ORDER BY id ASC
+LIMIT :limit;
+
+-- name: ListDailyClicks :many
+SELECT day, clicks
+FROM daily_clicks
+WHERE slug = ?
+ORDER BY day ASC;
+
+-- name: ImportLink :exec
+INSERT INTO links (slug, url, user, created_at, clicks, original_url, password_hash, interstitial, takedown_status, takedown_reason)
+VALUES (:slug, :url, :user, datetime(:created_at), :clicks, :original_url, :password_hash, :interstitial, :takedown_status, :takedown_reason);
+
+-- name: ReplaceLink :exec
+UPDATE links SET url = :url, user = :user, created_at = datetime(:created_at), clicks = :clicks,
+  original_url = :original_url, password_hash = :password_hash, interstitial = :interstitial,
+  takedown_status = :takedown_status, takedown_reason = :takedown_reason
+WHERE slug = :slug;
+
+-- name: DeleteDailyClicks :exec
+DELETE FROM daily_clicks WHERE slug = ?;
+
+-- name: DeleteLinkTargets :exec
+DELETE FROM link_targets WHERE slug = ?;
+
+-- name: DeleteLinkVariants :exec
+DELETE FROM link_variants WHERE slug = ?;
+
+-- name: DeleteLinkSchedules :exec
+DELETE FROM link_schedules WHERE slug = ?;
+
+-- name: DeleteCountryClicks :exec
+DELETE FROM country_clicks WHERE slug = ?;
+
+-- name: DeleteLanguageClicks :exec
+DELETE FROM language_clicks WHERE slug = ?;
+
+-- name: DeleteSourceClicks :exec
+DELETE FROM source_clicks WHERE slug = ?;
+
+-- name: DeleteLinkDestinations :exec
+DELETE FROM link_destinations WHERE slug = ?;
+
+-- name: SetDailyClicks :exec
+INSERT INTO daily_clicks (slug, day, clicks)
+VALUES (:slug, date(:day), :clicks)
+ON CONFLICT(slug, day) DO UPDATE SET clicks = excluded.clicks;
+
+-- name: GetIdempotentResponse :one
+SELECT request_hash, status, body
+FROM idempotency_keys
+WHERE owner = :owner AND key = :key AND expires_at > :now;
+
+-- name: SaveIdempotentResponse :execrows
+INSERT INTO idempotency_keys (owner, key, request_hash, status, body, expires_at)
+VALUES (:owner, :key, :request_hash, :status, :body, :expires_at)
+ON CONFLICT(owner, key) DO NOTHING;
+
+-- name: DeleteExpiredIdempotencyKeys :exec
+DELETE FROM idempotency_keys WHERE expires_at <= ?;
+
+-- name: AddLinkDestination :exec
+INSERT INTO link_destinations (slug, owner, url_key)
+VALUES (?, ?, ?);
+
+-- name: ListLinkDestinations :many
+SELECT owner, url_key
+FROM link_destinations
+WHERE slug = ?
+ORDER BY owner ASC;
+
+-- name: FindLinkByDestination :one
+SELECT links.id, links.slug, links.url
+FROM link_destinations
+JOIN links ON links.slug = link_destinations.slug
+WHERE link_destinations.owner = :owner
+  AND link_destinations.url_key = :url_key
+  AND links.url = link_destinations.url_key -- not since pointed elsewhere
+  AND links.takedown_status IS NULL
+ORDER BY links.id ASC
+LIMIT 1;
@@ -0,0 +1,168 @@
+package link
+
+import (
+	"context"
+	"database/sql"
+	"net/http"
+	"strconv"
+
+	"github.com/labstack/echo/v4"
+	"go.uber.org/zap"
+
+	"shotr/db"
+	h "shotr/helpers"
+	"shotr/store"
+)
+
+type takedownRequest struct {
+	// Status is the code served instead of the redirect: 410 Gone for abuse,
+	// 451 Unavailable For Legal Reasons for legal requests.
+	Status int    `json:"status" validate:"required,oneof=410 451"`
+	Reason string `json:"reason" validate:"max=200"`
+}
+
+// GET /api/v1/admin/reports?status=open&limit=50
+func (l *Link) ListReports(c echo.Context) error {
+	status := queryDefault(c, "status", reportOpen)
+	if status != reportOpen && status != reportActioned && status != reportDismissed {
+		return h.JSONError(c, http.StatusBadRequest, "status must be open, actioned or dismissed")
+	}
+	limit, err := intParam(c, "limit", 50, 1, 500)
+	if err != nil {
+		return h.JSONError(c, http.StatusBadRequest, "limit must be between 1 and 500")
+	}
+
+	rows, err := l.Store.ListReports(c.Request().Context(), db.ListReportsParams{Status: status, Limit: int64(limit)})
+	if err != nil {
+		l.Log.Error("failed to list reports", zap.Error(err))
+		return h.JSONError(c, http.StatusInternalServerError, "db error")
+	}
+
+	reports := make([]map[string]any, 0, len(rows))
+	for _, r := range rows {
+		reports = append(reports, map[string]any{
+			"id":          r.ID,
+			"slug":        r.Slug,
+			"reason":      r.Reason,
+			"details":     r.Details.String,
+			"contact":     r.Contact.String,
+			"reporter_ip": r.ReporterIp.String,
+			"status":      r.Status,
+			"created_at":  r.CreatedAt,
+		})
+	}
+	return h.JSONSuccess(c, http.StatusOK, map[string]any{"reports": reports}, "")
+}
+
+// POST /api/v1/admin/reports/:id/resolve
+// Resolving a report resolves every open report for the same slug.
+func (l *Link) ResolveReport(c echo.Context) error {
+	id, err := strconv.ParseInt(c.Param("id"), 10, 64)
+	if err != nil {
+		return h.JSONError(c, http.StatusBadRequest, "invalid report id")
+	}
+
+	var req struct {
+		Action string `json:"action" validate:"required,oneof=takedown dismiss"`
+		Status int    `json:"status" validate:"required_if=Action takedown,omitempty,oneof=410 451"`
+		Reason string `json:"reason" validate:"max=200"`
+	}
+	if err := h.BindAndValidate(c, &req); err != nil {
+		return h.JSONError(c, http.StatusBadRequest, err.Error())
+	}
+
+	ctx := c.Request().Context()
+	report, err := l.Store.GetReport(ctx, id)
+	if err == sql.ErrNoRows {
+		return h.JSONError(c, http.StatusNotFound, "not found")
+	}
+	if err != nil {
+		l.Log.Error("failed to fetch report", zap.Error(err))
+		return h.JSONError(c, http.StatusInternalServerError, "db error")
+	}
+
+	if req.Action == "dismiss" {
+		if err := l.Store.ResolveReports(ctx, db.ResolveReportsParams{Status: reportDismissed, Slug: report.Slug}); err != nil {
+			l.Log.Error("failed to dismiss reports", zap.Error(err))
+			return h.JSONError(c, http.StatusInternalServerError, "db error")
+		}
+		return h.JSONSuccess(c, http.StatusOK, nil, "reports dismissed")
+	}
+
+	if err := l.takedown(ctx, report.Slug, takedownRequest{Status: req.Status, Reason: req.Reason}); err != nil {
+		l.Log.Error("failed to take down link", zap.String("slug", report.Slug), zap.Error(err))
+		return h.JSONError(c, http.StatusInternalServerError, "db error")
+	}
+	return h.JSONSuccess(c, http.StatusOK, nil, "link taken down")
+}
+
+// POST /api/v1/admin/links/:slug/takedown
+func (l *Link) Takedown(c echo.Context) error {
+	slug := c.Param("slug")
+	var req takedownRequest
+	if err := h.BindAndValidate(c, &req); err != nil {
+		return h.JSONError(c, http.StatusBadRequest, err.Error())
+	}
+
+	ctx := c.Request().Context()
+	_, slug, err := findSlug(ctx, slug, l.Store.GetLink)
+	if err == sql.ErrNoRows {
+		return h.JSONError(c, http.StatusNotFound, "not found")
+	} else if err != nil {
+		l.Log.Error("db lookup failed", zap.Error(err))
+		return h.JSONError(c, http.StatusInternalServerError, "db error")
+	}
+
+	if err := l.takedown(ctx, slug, req); err != nil {
+		l.Log.Error("failed to take down link", zap.String("slug", slug), zap.Error(err))
+		return h.JSONError(c, http.StatusInternalServerError, "db error")
+	}
+	return h.JSONSuccess(c, http.StatusOK, nil, "link taken down")
+}
+
+// DELETE /api/v1/admin/links/:slug/takedown
+func (l *Link) Restore(c echo.Context) error {
+	slug := c.Param("slug")
+	ctx := c.Request().Context()
+	_, slug, err := findSlug(ctx, slug, l.Store.GetLink)
+	if err == sql.ErrNoRows {
+		return h.JSONError(c, http.StatusNotFound, "not found")
+	} else if err != nil {
+		l.Log.Error("db lookup failed", zap.Error(err))
+		return h.JSONError(c, http.StatusInternalServerError, "db error")
+	}
+	if err := l.Store.SetLinkTakedown(ctx, db.SetLinkTakedownParams{Slug: slug}); err != nil {
+		l.Log.Error("failed to restore link", zap.String("slug", slug), zap.Error(err))
+		return h.JSONError(c, http.StatusInternalServerError, "db error")
+	}
+	l.purge(slug)
+	l.Log.Info("link restored", zap.String("slug", slug))
+	return h.JSONSuccess(c, http.StatusOK, nil, "link restored")
+}
+
+// takedown disables slug, closes its open reports and drops it from the
+// cache so the next request sees the new state.
+func (l *Link) takedown(ctx context.Context, slug string, req takedownRequest) error {
+	err := l.Store.WithTx(ctx, func(q store.Store) error {
+		if err := q.SetLinkTakedown(ctx, db.SetLinkTakedownParams{
+			TakedownStatus: sql.NullInt64{Int64: int64(req.Status), Valid: true},
+			TakedownReason: sql.NullString{String: req.Reason, Valid: req.Reason != ""},
+			Slug:           slug,
+		}); err != nil {
+			return err
+		}
+		return q.ResolveReports(ctx, db.ResolveReportsParams{Status: reportActioned, Slug: slug})
+	})
+	if err != nil {
+		return err
+	}
+	l.purge(slug)
+	l.Log.Info("link taken down", zap.String("slug", slug), zap.Int("status", req.Status))
+	return nil
+}
+
+func (l *Link) purge(slug string) {
+	if l.Cache != nil {
+		l.Cache.Remove(slug)
+	}
+}
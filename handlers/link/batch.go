@@ -0,0 +1,246 @@
+package link
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"mime"
+	"net/http"
+
+	"github.com/labstack/echo/v4"
+	"go.uber.org/zap"
+
+	"shotr/db"
+	h "shotr/helpers"
+	"shotr/store"
+)
+
+// batchChunk is how many links share one transaction. Each link gets its
+// own savepoint inside it, so one bad link doesn't undo its neighbours,
+// while the writer lock is still given up between chunks.
+const batchChunk = 100
+
+// batchResult is the outcome for the link at Index in the request.
+type batchResult struct {
+	Index    int    `json:"index"`
+	Status   int    `json:"status"`
+	Slug     string `json:"slug,omitempty"`
+	ShortURL string `json:"short_url,omitempty"`
+	ID       int64  `json:"id,omitempty"`
+	Reused   bool   `json:"reused,omitempty"`
+	Error    string `json:"error,omitempty"`
+	Code     string `json:"code,omitempty"`
+}
+
+func (r *batchResult) fail(e *createError) {
+	*r = batchResult{Index: r.Index, Status: e.Status, Error: e.Message, Code: e.Code}
+}
+
+// POST /api/v1/links/batch
+//
+// The body is a JSON array of create requests, answered with one result per
+// link once they are all done, or with Content-Type application/x-ndjson one
+// request per line, answered with one result per line as each chunk
+// commits. Failed links don't stop the rest; the response is 200 either way
+// and each result carries its own status.
+func (l *Link) Batch(c echo.Context) error {
+	ct, _, _ := mime.ParseMediaType(c.Request().Header.Get(echo.HeaderContentType))
+	if ct == "application/x-ndjson" {
+		return l.batchStream(c)
+	}
+
+	items, err := l.readBatch(c.Request().Body)
+	if err != nil {
+		return h.JSONError(c, http.StatusBadRequest, err.Error())
+	}
+	if len(items) == 0 {
+		return h.JSONError(c, http.StatusBadRequest, "no links to create")
+	}
+
+	results := make([]batchResult, 0, len(items))
+	for start := 0; start < len(items); start += batchChunk {
+		end := min(start+batchChunk, len(items))
+		results = append(results, l.createChunk(c, start, items[start:end])...)
+	}
+	created, reused := 0, 0
+	for _, r := range results {
+		switch {
+		case r.Status == http.StatusCreated:
+			created++
+		case r.Reused:
+			reused++
+		}
+	}
+	return h.JSONSuccess(c, http.StatusOK, map[string]any{
+		"results": results,
+		"created": created,
+		"reused":  reused,
+		"failed":  len(results) - created - reused,
+	}, "")
+}
+
+// readBatch reads the JSON array form of Batch an element at a time, so a
+// body with more than BatchMax links is refused once the one past the
+// limit is read rather than after all of it is.
+func (l *Link) readBatch(body io.Reader) ([]json.RawMessage, error) {
+	dec := json.NewDecoder(body)
+	if tok, err := dec.Token(); err != nil || tok != json.Delim('[') {
+		return nil, errors.New("invalid json")
+	}
+	var items []json.RawMessage
+	for dec.More() {
+		if len(items) == l.BatchMax {
+			return nil, fmt.Errorf("at most %d links per batch", l.BatchMax)
+		}
+		var raw json.RawMessage
+		if err := dec.Decode(&raw); err != nil {
+			return nil, errors.New("invalid json")
+		}
+		items = append(items, raw)
+	}
+	if _, err := dec.Token(); err != nil {
+		return nil, errors.New("invalid json")
+	}
+	return items, nil
+}
+
+// batchStream handles the NDJSON form of Batch. It holds at most one chunk
+// in memory, so it has no item limit beyond the caller's quota.
+func (l *Link) batchStream(c echo.Context) error {
+	res := c.Response()
+	res.Header().Set(echo.HeaderContentType, "application/x-ndjson")
+	res.WriteHeader(http.StatusOK)
+	enc := json.NewEncoder(res)
+
+	index := 0
+	chunk := make([]json.RawMessage, 0, batchChunk)
+	flush := func() error {
+		for _, r := range l.createChunk(c, index, chunk) {
+			if err := enc.Encode(r); err != nil {
+				return err
+			}
+		}
+		res.Flush()
+		index += len(chunk)
+		chunk = chunk[:0]
+		return nil
+	}
+
+	dec := json.NewDecoder(c.Request().Body)
+	for {
+		var raw json.RawMessage
+		err := dec.Decode(&raw)
+		if err == io.EOF {
+			break
+		}
+		if err != nil {
+			// there's no finding the next line after malformed JSON, so
+			// finish what was read and stop there
+			if err := flush(); err != nil {
+				return err
+			}
+			return enc.Encode(batchResult{Index: index, Status: http.StatusBadRequest, Error: "invalid json"})
+		}
+		chunk = append(chunk, raw)
+		if len(chunk) == batchChunk {
+			if err := flush(); err != nil {
+				return err
+			}
+		}
+	}
+	return flush()
+}
+
+// createChunk creates the links in raws, which start at offset in the
+// request, in one transaction and returns a result for each.
+func (l *Link) createChunk(c echo.Context, offset int, raws []json.RawMessage) []batchResult {
+	ctx := c.Request().Context()
+	owner := callerName(c)
+	results := make([]batchResult, len(raws))
+	pending := make([]*newLink, len(raws))
+	for i, raw := range raws {
+		results[i].Index = offset + i
+		var req createRequest
+		if err := json.Unmarshal(raw, &req); err != nil {
+			results[i].fail(badRequest("invalid json"))
+			continue
+		}
+		if err := h.Validate(&req); err != nil {
+			results[i].fail(badRequest(err.Error()))
+			continue
+		}
+		p, cerr := l.prepare(ctx, owner, req)
+		if cerr != nil {
+			results[i].fail(cerr)
+			continue
+		}
+		pending[i] = p
+	}
+
+	err := l.Store.WithTx(ctx, func(tx store.Store) error {
+		for i, p := range pending {
+			if p == nil {
+				continue
+			}
+			// looked up inside the transaction so that repeats within
+			// the batch find the first one
+			if p.req.Reuse && p.urlKey != "" {
+				found, err := l.findExisting(ctx, tx, p)
+				if err != nil {
+					l.Log.Error("failed to look up existing link", zap.Error(err))
+					results[i].fail(&createError{Status: http.StatusInternalServerError, Message: "db error"})
+					continue
+				}
+				if found != nil {
+					results[i] = batchResult{
+						Index:    results[i].Index,
+						Status:   http.StatusOK,
+						Slug:     found.Slug,
+						ShortURL: h.BuildShortURL(c, l.BaseHost, found.Slug),
+						ID:       found.ID,
+						Reused:   true,
+					}
+					continue
+				}
+			}
+			if !l.allowCreate(c) {
+				results[i].fail(errQuota)
+				continue
+			}
+			var link db.Link
+			err := tx.WithTx(ctx, func(q store.Store) error {
+				var err error
+				link, err = l.save(ctx, q, p)
+				return err
+			})
+			if err != nil {
+				l.refundCreate(c, 1)
+				results[i].fail(l.saveError(err))
+				continue
+			}
+			results[i] = batchResult{
+				Index:    results[i].Index,
+				Status:   http.StatusCreated,
+				Slug:     link.Slug,
+				ShortURL: h.BuildShortURL(c, l.BaseHost, link.Slug),
+				ID:       link.ID,
+			}
+		}
+		return nil
+	})
+	if err != nil {
+		l.Log.Error("failed to commit link batch", zap.Int("offset", offset), zap.Error(err))
+		lost := 0
+		for i := range results {
+			if results[i].Status == http.StatusCreated {
+				lost++
+			}
+			if results[i].Status == http.StatusCreated || results[i].Reused {
+				results[i].fail(&createError{Status: http.StatusInternalServerError, Message: "couldn't create short link"})
+			}
+		}
+		l.refundCreate(c, lost)
+	}
+	return results
+}
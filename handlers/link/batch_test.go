@@ -0,0 +1,238 @@
+package link
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"net/http"
+	"strings"
+	"testing"
+
+	"github.com/labstack/echo/v4"
+
+	"shotr/config"
+	"shotr/db"
+	"shotr/store"
+)
+
+// failURL is a destination faultStore refuses to write a target for, so a
+// link using it fails after its row is already inserted.
+const failURL = "https://fail.example/"
+
+// faultStore counts top-level transactions and fails target writes for
+// failURL, or every commit when failCommit is set.
+type faultStore struct {
+	store.Store
+	inTx       bool
+	txs        *int
+	failCommit bool
+}
+
+func (s *faultStore) WithTx(ctx context.Context, fn func(store.Store) error) error {
+	if !s.inTx {
+		*s.txs++
+	}
+	err := s.Store.WithTx(ctx, func(tx store.Store) error {
+		return fn(&faultStore{Store: tx, inTx: true, txs: s.txs})
+	})
+	if err == nil && !s.inTx && s.failCommit {
+		return errors.New("commit failed")
+	}
+	return err
+}
+
+func (s *faultStore) AddLinkTarget(ctx context.Context, arg db.AddLinkTargetParams) error {
+	if arg.Url == failURL {
+		return errors.New("target write failed")
+	}
+	return s.Store.AddLinkTarget(ctx, arg)
+}
+
+// withFaults puts a faultStore in front of the test server's store.
+func (s *testServer) withFaults() *faultStore {
+	fs := &faultStore{Store: s.st, txs: new(int)}
+	s.link.Store = fs
+	return fs
+}
+
+type batchResponse struct {
+	Results []batchResult `json:"results"`
+	Created int           `json:"created"`
+	Reused  int           `json:"reused"`
+	Failed  int           `json:"failed"`
+}
+
+func (s *testServer) batch(t *testing.T, items []map[string]any) batchResponse {
+	t.Helper()
+	rec := s.do(t, http.MethodPost, "/api/v1/links/batch", aliceKey, items)
+	if rec.Code != http.StatusOK {
+		t.Fatalf("batch: %d %s", rec.Code, rec.Body)
+	}
+	var out batchResponse
+	if err := json.Unmarshal(rec.Body.Bytes(), &out); err != nil {
+		t.Fatal(err)
+	}
+	return out
+}
+
+func statuses(results []batchResult) []int {
+	out := make([]int, len(results))
+	for i, r := range results {
+		out[i] = r.Status
+	}
+	return out
+}
+
+func TestBatchChunksOf100(t *testing.T) {
+	s := newTestServer(t)
+	fs := s.withFaults()
+	items := make([]map[string]any, 250)
+	for i := range items {
+		items[i] = map[string]any{"url": fmt.Sprintf("https://example.com/%d", i)}
+	}
+	out := s.batch(t, items)
+	if out.Created != 250 || len(out.Results) != 250 {
+		t.Fatalf("created %d of %d results, want 250", out.Created, len(out.Results))
+	}
+	for i, r := range out.Results {
+		if r.Index != i {
+			t.Fatalf("result %d has index %d", i, r.Index)
+		}
+	}
+	if *fs.txs != 3 {
+		t.Errorf("%d transactions for 250 links, want 3 chunks", *fs.txs)
+	}
+}
+
+func TestBatchStreamChunks(t *testing.T) {
+	s := newTestServer(t)
+	fs := s.withFaults()
+	var body strings.Builder
+	for i := 0; i < 150; i++ {
+		fmt.Fprintf(&body, "{\"url\":\"https://example.com/%d\"}\n", i)
+	}
+	body.WriteString("{not json\n")
+
+	rec := s.send(t, http.MethodPost, "/api/v1/links/batch", aliceKey, body.String(), http.Header{
+		echo.HeaderContentType: {"application/x-ndjson"},
+	})
+	lines := strings.Split(strings.TrimSpace(rec.Body.String()), "\n")
+	if len(lines) != 151 {
+		t.Fatalf("%d result lines, want 150 and one for the bad line", len(lines))
+	}
+	var last batchResult
+	if err := json.Unmarshal([]byte(lines[150]), &last); err != nil || last.Status != http.StatusBadRequest || last.Index != 150 {
+		t.Errorf("last line = %s, want a 400 at index 150", lines[150])
+	}
+	if *fs.txs != 2 {
+		t.Errorf("%d transactions for 150 links, want 2 chunks", *fs.txs)
+	}
+}
+
+// A link that fails part way through its save is rolled back to its own
+// savepoint; its neighbours in the chunk still commit.
+func TestBatchRollsBackOnlyTheFailedLink(t *testing.T) {
+	s := newTestServer(t)
+	s.withFaults()
+	out := s.batch(t, []map[string]any{
+		{"url": "https://example.com/0"},
+		{"url": "https://example.com/broken", "geo": map[string]string{"DE": failURL}},
+		{"url": "https://example.com/2"},
+	})
+	if got := statuses(out.Results); got[0] != 201 || got[1] != 500 || got[2] != 201 {
+		t.Fatalf("statuses = %v, want [201 500 201]", got)
+	}
+	n, err := s.st.CountLinks(context.Background())
+	if err != nil {
+		t.Fatal(err)
+	}
+	if n != 2 {
+		t.Errorf("%d links stored, want the 2 that succeeded", n)
+	}
+	links, _ := s.st.ListLinks(context.Background(), db.ListLinksParams{Limit: 10})
+	for _, l := range links {
+		if l.Url == "https://example.com/broken" {
+			t.Errorf("the failed link's row survived: %+v", l)
+		}
+	}
+}
+
+func TestBatchMixedResults(t *testing.T) {
+	s := newTestServer(t)
+	existing := s.create(t, aliceKey, map[string]any{"url": "https://example.com/reused"})
+	out := s.batch(t, []map[string]any{
+		{"url": "https://example.com/new"},
+		{"url": "not a url"},
+		{"url": "http://10.0.0.1/"},
+		{"url": "https://example.com/reused", "reuse": true},
+		{"url": "https://example.com/", "slug": "mine"},
+		{"url": "https://example.com/", "slug": "mine"},
+	})
+	want := []int{201, 400, 400, 200, 201, 409}
+	if got := statuses(out.Results); fmt.Sprint(got) != fmt.Sprint(want) {
+		t.Fatalf("statuses = %v, want %v", got, want)
+	}
+	if out.Created != 2 || out.Reused != 1 || out.Failed != 3 {
+		t.Errorf("counts = %d created, %d reused, %d failed; want 2, 1, 3", out.Created, out.Reused, out.Failed)
+	}
+	if r := out.Results[3]; !r.Reused || r.Slug != existing {
+		t.Errorf("reuse result = %+v, want %s", r, existing)
+	}
+	if out.Results[2].Code == "" {
+		t.Errorf("policy failure has no code: %+v", out.Results[2])
+	}
+}
+
+func TestBatchRefundsQuota(t *testing.T) {
+	s := newTestServer(t, func(cfg *config.Config) { cfg.LinkQuota = 5 })
+	s.withFaults()
+	items := make([]map[string]any, 7)
+	for i := range items {
+		items[i] = map[string]any{"url": fmt.Sprintf("https://example.com/%d", i)}
+	}
+	items[2]["geo"] = map[string]string{"DE": failURL}
+
+	// the failed link's quota comes back, so five of the rest fit
+	out := s.batch(t, items)
+	if got, want := fmt.Sprint(statuses(out.Results)), "[201 201 500 201 201 201 429]"; got != want {
+		t.Fatalf("statuses = %v, want %v", got, want)
+	}
+	if rec := s.do(t, http.MethodPost, "/api/v1/links", aliceKey, map[string]any{"url": "https://example.com/more"}); rec.Code != http.StatusTooManyRequests {
+		t.Errorf("create after the quota is spent: %d, want 429", rec.Code)
+	}
+}
+
+func TestBatchRefundsQuotaWhenCommitFails(t *testing.T) {
+	s := newTestServer(t, func(cfg *config.Config) { cfg.LinkQuota = 3 })
+	fs := s.withFaults()
+	fs.failCommit = true
+	out := s.batch(t, []map[string]any{{"url": "https://example.com/0"}, {"url": "https://example.com/1"}, {"url": "https://example.com/2"}})
+	if got := fmt.Sprint(statuses(out.Results)); got != "[500 500 500]" {
+		t.Fatalf("statuses = %v, want all 500", got)
+	}
+
+	fs.failCommit = false
+	out = s.batch(t, []map[string]any{{"url": "https://example.com/0"}, {"url": "https://example.com/1"}, {"url": "https://example.com/2"}})
+	if out.Created != 3 {
+		t.Errorf("created %d after a failed commit, want the whole quota of 3 back", out.Created)
+	}
+}
+
+func TestBatchLimit(t *testing.T) {
+	s := newTestServer(t, func(cfg *config.Config) { cfg.BatchMaxItems = 3 })
+	// the fourth element is refused before the malformed rest is read
+	body := `[{"url":"https://example.com/"},{"url":"https://example.com/"},{"url":"https://example.com/"},{"url":"https://example.com/"},{"url": `
+	rec := s.do(t, http.MethodPost, "/api/v1/links/batch", aliceKey, body)
+	if rec.Code != http.StatusBadRequest || !strings.Contains(rec.Body.String(), "at most 3") {
+		t.Errorf("over the limit: %d %s, want 400 at most 3", rec.Code, rec.Body)
+	}
+	for _, body := range []string{`{"url":"https://example.com/"}`, `[`, `[]`} {
+		if rec := s.do(t, http.MethodPost, "/api/v1/links/batch", aliceKey, body); rec.Code != http.StatusBadRequest {
+			t.Errorf("body %s: %d, want 400", body, rec.Code)
+		}
+	}
+	if n, _ := s.st.CountLinks(context.Background()); n != 0 {
+		t.Errorf("%d links created by refused batches", n)
+	}
+}
@@ -2,25 +2,126 @@ package link
 
 import (
 	"context"
+	"database/sql"
+	"time"
+
+	"shotr/db"
+	h "shotr/helpers"
 )
 
-func (l *Link) resolveURL(ctx context.Context, slug string) (string, bool, error) {
+// destination is what resolveURL caches per slug: the fallback URL, any
+// targeting rules keyed by kind and then by the matched value, and any
+// weighted split variants. For scheduled links URL is the current window's
+// URL and Expires is when that window ends, after which the entry is stale.
+type destination struct {
+	Slug     string
+	URL      string
+	Targets  map[string]map[string]string
+	Variants []db.LinkVariant
+	Expires  time.Time
+
+	PasswordHash string // bcrypt hash; empty when the link isn't protected
+	Interstitial bool   // show the preview page instead of redirecting
+
+	TakedownStatus int // 410 or 451 once taken down, otherwise 0
+	TakedownReason string
+
+	// cachedUntil is when the cache stops trusting this entry. Purges only
+	// reach this process's cache, so changes made elsewhere, e.g. by
+	// another instance or shotr import, show up once it passes.
+	cachedUntil time.Time
+}
+
+func (d *destination) expired(now time.Time) bool {
+	if !d.cachedUntil.IsZero() && !now.Before(d.cachedUntil) {
+		return true
+	}
+	return !d.Expires.IsZero() && !now.Before(d.Expires)
+}
+
+// findSlug looks slug up with get and, like resolveURL, retries with its
+// nocase form when there's no such row. It returns the slug that matched.
+func findSlug[T any](ctx context.Context, slug string, get func(context.Context, string) (T, error)) (T, string, error) {
+	row, err := get(ctx, slug)
+	if err == sql.ErrNoRows {
+		if folded, ok := h.FoldSlug(slug); ok {
+			row, err = get(ctx, folded)
+			return row, folded, err
+		}
+	}
+	return row, slug, err
+}
+
+func (l *Link) resolveURL(ctx context.Context, slug string) (*destination, bool, error) {
 	if l.Cache != nil {
 		if v, ok := l.Cache.Get(slug); ok {
-			if s, ok := v.(string); ok {
-				return s, true, nil
+			if d, ok := v.(*destination); ok && !d.expired(time.Now()) {
+				return d, true, nil
 			}
 			l.Cache.Remove(slug)
 		}
 	}
 
-	linkRow, err := l.Q.GetLink(ctx, slug)
+	linkRow, err := l.Store.GetLink(ctx, slug)
+	if err == sql.ErrNoRows {
+		// nocase slugs are stored lower case but may be typed in any case
+		if folded, ok := h.FoldSlug(slug); ok {
+			return l.resolveURL(ctx, folded)
+		}
+	}
+	if err != nil {
+		return nil, false, err
+	}
+
+	targets, err := l.Store.GetLinkTargets(ctx, slug)
 	if err != nil {
-		return "", false, err
+		return nil, false, err
 	}
 
+	variants, err := l.Store.GetLinkVariants(ctx, slug)
+	if err != nil {
+		return nil, false, err
+	}
+
+	schedules, err := l.Store.GetLinkSchedules(ctx, slug)
+	if err != nil {
+		return nil, false, err
+	}
+
+	d := newDestination(linkRow, targets, variants)
+	url, ok, next := activeWindow(schedules, time.Now())
+	if ok {
+		d.URL = url
+	}
+	d.Expires = next
 	if l.Cache != nil {
-		l.Cache.Add(slug, linkRow.Url)
+		if l.CacheTTL > 0 {
+			d.cachedUntil = time.Now().Add(l.CacheTTL)
+		}
+		l.Cache.Add(slug, d)
+	}
+	return d, false, nil
+}
+
+func newDestination(linkRow db.Link, targets []db.LinkTarget, variants []db.LinkVariant) *destination {
+	d := &destination{
+		Slug:         linkRow.Slug,
+		URL:          linkRow.Url,
+		Variants:     variants,
+		PasswordHash: linkRow.PasswordHash.String,
+		Interstitial: linkRow.Interstitial,
+
+		TakedownStatus: int(linkRow.TakedownStatus.Int64),
+		TakedownReason: linkRow.TakedownReason.String,
+	}
+	for _, t := range targets {
+		if d.Targets == nil {
+			d.Targets = make(map[string]map[string]string)
+		}
+		if d.Targets[t.Kind] == nil {
+			d.Targets[t.Kind] = make(map[string]string)
+		}
+		d.Targets[t.Kind][t.Value] = t.Url
 	}
-	return linkRow.Url, false, nil
-}
\ No newline at end of file
+	return d
+}
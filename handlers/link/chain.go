@@ -0,0 +1,154 @@
+package link
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+	"net/http"
+	"net/url"
+	"strings"
+
+	"github.com/labstack/echo/v4"
+	"go.uber.org/zap"
+
+	h "shotr/helpers"
+	"shotr/store"
+)
+
+// checkDestinations applies the URL policy and chain checks to every URL a
+// link would redirect to. self is the slug being updated, or "" on create.
+func (l *Link) checkDestinations(ctx context.Context, self string, dests []string) error {
+	return l.CheckDestinations(ctx, l.Store, self, dests)
+}
+
+// CheckDestinations is checkDestinations with chains looked up in q, so
+// shotr import can vet a link against the links imported before it in the
+// same transaction.
+func (l *Link) CheckDestinations(ctx context.Context, q store.Store, self string, dests []string) error {
+	for _, d := range dests {
+		if _, own := l.ownSlug(d); own {
+			continue // our own host; checkChain decides
+		}
+		if err := l.Policy.Check(d); err != nil {
+			return err
+		}
+	}
+	return l.checkChain(ctx, q, self, dests)
+}
+
+// destinationError writes the response for a checkDestinations failure.
+func (l *Link) destinationError(c echo.Context, err error) error {
+	return l.destinationFailure(err).write(c)
+}
+
+// destinationFailure is what the client is told about a checkDestinations
+// failure.
+func (l *Link) destinationFailure(err error) *createError {
+	var pe *h.PolicyError
+	if errors.As(err, &pe) {
+		return &createError{Status: http.StatusBadRequest, Code: pe.Code, Message: pe.Reason}
+	}
+	l.Log.Error("failed to check destination", zap.Error(err))
+	return &createError{Status: http.StatusInternalServerError, Message: "db error"}
+}
+
+// ownSlug returns the slug when raw is one of our own short URLs.
+func (l *Link) ownSlug(raw string) (string, bool) {
+	base, err := url.Parse(l.BaseHost)
+	if err != nil || base.Host == "" {
+		return "", false
+	}
+	u, err := url.Parse(raw)
+	if err != nil || !strings.EqualFold(u.Hostname(), base.Hostname()) {
+		return "", false
+	}
+	p := strings.TrimPrefix(u.Path, strings.TrimSuffix(base.Path, "/"))
+	slug := strings.TrimSuffix(strings.Trim(p, "/"), previewSuffix)
+	if slug == "" || strings.Contains(slug, "/") {
+		return "", false
+	}
+	return slug, true
+}
+
+// linkDestinations loads every URL the stored link could redirect to,
+// along with the slug as it is stored: a nocase slug may be typed in any
+// case.
+func linkDestinations(ctx context.Context, q store.Store, slug string) (string, []string, error) {
+	linkRow, slug, err := findSlug(ctx, slug, q.GetLink)
+	if err != nil {
+		return slug, nil, err
+	}
+	out := []string{linkRow.Url}
+
+	targets, err := q.GetLinkTargets(ctx, slug)
+	if err != nil {
+		return slug, nil, err
+	}
+	for _, t := range targets {
+		out = append(out, t.Url)
+	}
+	variants, err := q.GetLinkVariants(ctx, slug)
+	if err != nil {
+		return slug, nil, err
+	}
+	for _, v := range variants {
+		out = append(out, v.Url)
+	}
+	schedules, err := q.GetLinkSchedules(ctx, slug)
+	if err != nil {
+		return slug, nil, err
+	}
+	for _, s := range schedules {
+		out = append(out, s.Url)
+	}
+	return slug, out, nil
+}
+
+// checkChain follows destinations that are our own short links and refuses
+// ones that lead back to self (the slug being updated; empty on create),
+// pass through more than MaxChainDepth of our links, or point at a slug
+// that doesn't exist. External shorteners are refused by the URL policy.
+func (l *Link) checkChain(ctx context.Context, q store.Store, self string, dests []string) error {
+	errLoop := &h.PolicyError{Code: h.PolicyRedirectLoop, Reason: "url would create a redirect loop"}
+	var walk func(raw string, depth int, seen map[string]bool) error
+	walk = func(raw string, depth int, seen map[string]bool) error {
+		slug, ok := l.ownSlug(raw)
+		if !ok {
+			return nil
+		}
+		if slug == self || seen[slug] {
+			return errLoop
+		}
+		if depth > l.MaxChainDepth {
+			return &h.PolicyError{Code: h.PolicyChainTooDeep, Reason: "url passes through too many short links"}
+		}
+
+		slug, next, err := linkDestinations(ctx, q, slug)
+		if err == sql.ErrNoRows {
+			return &h.PolicyError{Code: h.PolicyDanglingLink, Reason: "url points to a short link that doesn't exist"}
+		}
+		if err != nil {
+			return err
+		}
+		// a nocase slug typed in another case only matches once looked up
+		if slug == self || seen[slug] {
+			return errLoop
+		}
+
+		seen[slug] = true
+		defer delete(seen, slug)
+		for _, n := range next {
+			if err := walk(n, depth+1, seen); err != nil {
+				return err
+			}
+		}
+		return nil
+	}
+
+	for _, d := range dests {
+		if err := walk(d, 1, map[string]bool{}); err != nil {
+			return err
+		}
+	}
+	return nil
+}
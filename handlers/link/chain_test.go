@@ -0,0 +1,31 @@
+package link
+
+import (
+	"net/http"
+	"strings"
+	"testing"
+
+	"shotr/config"
+	h "shotr/helpers"
+)
+
+func TestChainFollowsNocaseSlugInAnyCase(t *testing.T) {
+	s := newTestServer(t, func(cfg *config.Config) { cfg.MaxChainDepth = 2 })
+	nocase := s.create(t, aliceKey, map[string]any{"url": "https://example.com/", "slug_strategy": h.SlugNocase})
+	typed := "http://sho.rt/" + strings.ToUpper(nocase)
+
+	via := s.create(t, aliceKey, map[string]any{"url": typed})
+	if rec := s.do(t, http.MethodGet, "/"+via, "", nil); rec.Header().Get("Location") != typed {
+		t.Errorf("redirects to %q, want %q", rec.Header().Get("Location"), typed)
+	}
+
+	for name, url := range map[string]string{
+		"to itself":       typed,
+		"through another": "http://sho.rt/" + via,
+	} {
+		rec := s.do(t, http.MethodPatch, "/api/v1/links/"+nocase, aliceKey, map[string]any{"url": url})
+		if rec.Code != http.StatusBadRequest || decode(t, rec)["code"] != h.PolicyRedirectLoop {
+			t.Errorf("%s: %d %s, want a redirect loop", name, rec.Code, rec.Body)
+		}
+	}
+}
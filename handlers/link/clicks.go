@@ -11,37 +11,77 @@ import (
 	"shotr/workers"
 )
 
-func (l *Link) enqueueClick(ctx context.Context, slug string) {
-	ev := workers.ClickEvent{Slug: slug, Time: time.Now()}
-
+func (l *Link) enqueueClick(ctx context.Context, ev workers.ClickEvent) {
 	if l.Worker != nil {
 		if l.Worker.Enqueue(ev) {
 			return
 		}
 		// fallback: worker full
-		l.writeClickFallback(ctx, slug, "worker full")
+		l.writeClickFallback(ctx, ev, "worker full")
 		return
 	}
 
 	// no worker at all
-	l.writeClickFallback(ctx, slug, "no worker configured")
+	l.writeClickFallback(ctx, ev, "no worker configured")
 }
 
-func (l *Link) writeClickFallback(parentctx context.Context, slug, reason string) {
+func (l *Link) writeClickFallback(parentctx context.Context, ev workers.ClickEvent, reason string) {
+	slug := ev.Slug
 	ctx, cancel := context.WithTimeout(parentctx, 2*time.Second)
 	defer cancel()
 
-	if err := l.Q.AddClick(ctx, db.AddClickParams{
+	n, err := l.Store.AddClick(ctx, db.AddClickParams{
 		Clicks: sql.NullInt64{Int64: 1, Valid: true},
 		Slug:   slug,
-	}); err != nil {
+	})
+	if err != nil {
 		l.Log.Debug("fallback AddClick failed", zap.String("slug", slug), zap.String("reason", reason), zap.Error(err))
+	} else if n == 0 {
+		// the link is gone; don't leave stats behind for it
+		l.Log.Debug("fallback click for missing link dropped", zap.String("slug", slug), zap.String("reason", reason))
+		return
 	}
-	if err := l.Q.SaveDailyClicks(ctx, db.SaveDailyClicksParams{
+	if err := l.Store.SaveDailyClicks(ctx, db.SaveDailyClicksParams{
 		Slug:   slug,
 		Clicks: sql.NullInt64{Int64: 1, Valid: true},
 	}); err != nil {
 		l.Log.Debug("fallback SaveDailyClicks failed", zap.String("slug", slug), zap.String("reason", reason), zap.Error(err))
 	}
+	if ev.Country != "" {
+		if err := l.Store.SaveCountryClicks(ctx, db.SaveCountryClicksParams{
+			Slug:    slug,
+			Country: ev.Country,
+			Clicks:  sql.NullInt64{Int64: 1, Valid: true},
+		}); err != nil {
+			l.Log.Debug("fallback SaveCountryClicks failed", zap.String("slug", slug), zap.String("reason", reason), zap.Error(err))
+		}
+	}
+	if ev.Language != "" {
+		if err := l.Store.SaveLanguageClicks(ctx, db.SaveLanguageClicksParams{
+			Slug:     slug,
+			Language: ev.Language,
+			Clicks:   sql.NullInt64{Int64: 1, Valid: true},
+		}); err != nil {
+			l.Log.Debug("fallback SaveLanguageClicks failed", zap.String("slug", slug), zap.String("reason", reason), zap.Error(err))
+		}
+	}
+	if ev.Source != "" {
+		if err := l.Store.SaveSourceClicks(ctx, db.SaveSourceClicksParams{
+			Slug:   slug,
+			Source: ev.Source,
+			Clicks: sql.NullInt64{Int64: 1, Valid: true},
+		}); err != nil {
+			l.Log.Debug("fallback SaveSourceClicks failed", zap.String("slug", slug), zap.String("reason", reason), zap.Error(err))
+		}
+	}
+	if ev.Variant != "" {
+		if err := l.Store.AddVariantClick(ctx, db.AddVariantClickParams{
+			Clicks: sql.NullInt64{Int64: 1, Valid: true},
+			Slug:   slug,
+			Name:   ev.Variant,
+		}); err != nil {
+			l.Log.Debug("fallback AddVariantClick failed", zap.String("slug", slug), zap.String("reason", reason), zap.Error(err))
+		}
+	}
 	l.Log.Debug("click worker fallback sync increment", zap.String("slug", slug), zap.String("reason", reason))
 }
\ No newline at end of file
@@ -0,0 +1,147 @@
+package link
+
+import (
+	"context"
+	"crypto/sha256"
+	"database/sql"
+	"encoding/hex"
+	"encoding/json"
+	"errors"
+	"net/http"
+	"time"
+
+	"github.com/labstack/echo/v4"
+	"go.uber.org/zap"
+
+	"shotr/db"
+	h "shotr/helpers"
+	"shotr/store"
+)
+
+// idempotencyHeader makes retrying a create safe: a repeat of the same
+// request with the same key gets the first response back, not a new link.
+const idempotencyHeader = "Idempotency-Key"
+
+// errIdempotencyRace means another request with the same key saved its
+// response first; this one should replay it.
+var errIdempotencyRace = errors.New("idempotency key already has a response")
+
+// idempotentRequest is a create request that carried an Idempotency-Key.
+// Keys are per caller, and hash tells a retry from a different request
+// that reused the key. The password is left out of the hash, so a retry
+// that only changes it gets the first response.
+type idempotentRequest struct {
+	owner string
+	key   string
+	hash  string
+}
+
+// idempotencyKey reads the Idempotency-Key header, or returns nil if there
+// isn't one.
+func (l *Link) idempotencyKey(c echo.Context, owner string, req createRequest) (*idempotentRequest, *createError) {
+	key := c.Request().Header.Get(idempotencyHeader)
+	if key == "" {
+		return nil, nil
+	}
+	if len(key) > 255 {
+		return nil, badRequest("Idempotency-Key is too long")
+	}
+	// the hash is stored, and an unsalted hash of the password would let
+	// anyone holding the table test guesses at it offline
+	req.Password = ""
+	raw, err := json.Marshal(req)
+	if err != nil {
+		l.Log.Error("failed to hash create request", zap.Error(err))
+		return nil, &createError{Status: http.StatusInternalServerError, Message: "couldn't create short link"}
+	}
+	sum := sha256.Sum256(raw)
+	return &idempotentRequest{owner: owner, key: key, hash: hex.EncodeToString(sum[:])}, nil
+}
+
+// replay answers with the stored response to idem. It reports false, and
+// writes nothing, when there is none yet and the request should go ahead.
+func (l *Link) replay(c echo.Context, idem *idempotentRequest) (bool, error) {
+	row, err := l.Store.GetIdempotentResponse(c.Request().Context(), db.GetIdempotentResponseParams{
+		Owner: idem.owner,
+		Key:   idem.key,
+		Now:   time.Now().Unix(),
+	})
+	if err == sql.ErrNoRows {
+		return false, nil
+	}
+	if err != nil {
+		l.Log.Error("failed to look up idempotency key", zap.Error(err))
+		return true, h.JSONError(c, http.StatusInternalServerError, "db error")
+	}
+	if row.RequestHash != idem.hash {
+		return true, h.JSONError(c, http.StatusUnprocessableEntity, "Idempotency-Key was already used for a different request")
+	}
+
+	var body struct {
+		ShortURL string `json:"short_url"`
+	}
+	if json.Unmarshal([]byte(row.Body), &body) == nil && body.ShortURL != "" {
+		c.Response().Header().Set("Location", body.ShortURL)
+	}
+	c.Response().Header().Set("Idempotent-Replayed", "true")
+	return true, c.JSONBlob(int(row.Status), []byte(row.Body))
+}
+
+// saveResponse stores the response to idem through q for IdempotencyTTL.
+// It returns errIdempotencyRace if the key got a response in the meantime.
+func (l *Link) saveResponse(ctx context.Context, q store.Store, idem *idempotentRequest, status int, body map[string]any) error {
+	if idem == nil {
+		return nil
+	}
+	raw, err := json.Marshal(body)
+	if err != nil {
+		return err
+	}
+	now := time.Now()
+	// clear out expired keys first, so an expired one can be used again
+	if err := q.DeleteExpiredIdempotencyKeys(ctx, now.Unix()); err != nil {
+		return err
+	}
+	n, err := q.SaveIdempotentResponse(ctx, db.SaveIdempotentResponseParams{
+		Owner:       idem.owner,
+		Key:         idem.key,
+		RequestHash: idem.hash,
+		Status:      int64(status),
+		Body:        string(raw),
+		ExpiresAt:   now.Add(l.IdempotencyTTL).Unix(),
+	})
+	if err != nil {
+		return err
+	}
+	if n == 0 {
+		return errIdempotencyRace
+	}
+	return nil
+}
+
+// remember is saveResponse on its own, for responses that didn't create
+// anything. A failure only costs the retry a fresh answer, so it's logged.
+func (l *Link) remember(ctx context.Context, idem *idempotentRequest, status int, body map[string]any) {
+	if idem == nil {
+		return
+	}
+	err := l.Store.WithTx(ctx, func(q store.Store) error {
+		return l.saveResponse(ctx, q, idem, status, body)
+	})
+	if err != nil && err != errIdempotencyRace {
+		l.Log.Warn("failed to save idempotent response", zap.Error(err))
+	}
+}
+
+// findExisting looks through q for a plain link p's owner already made to
+// p's destination. It returns nil if there's none.
+func (l *Link) findExisting(ctx context.Context, q store.Store, p *newLink) (*db.FindLinkByDestinationRow, error) {
+	row, err := q.FindLinkByDestination(ctx, db.FindLinkByDestinationParams{Owner: p.owner, UrlKey: p.urlKey})
+	if err == sql.ErrNoRows {
+		return nil, nil
+	}
+	if err != nil {
+		return nil, err
+	}
+	return &row, nil
+}
@@ -0,0 +1,139 @@
+package link
+
+import (
+	"context"
+	"crypto/sha256"
+	"database/sql"
+	"encoding/hex"
+	"encoding/json"
+	"net/http"
+	"testing"
+	"time"
+
+	"shotr/db"
+	"shotr/store"
+)
+
+func withKey(key string) http.Header {
+	return http.Header{idempotencyHeader: {key}}
+}
+
+func TestIdempotentReplay(t *testing.T) {
+	s := newTestServer(t)
+	body := map[string]any{"url": "https://example.com/"}
+	first := s.send(t, http.MethodPost, "/api/v1/links", aliceKey, body, withKey("k1"))
+	if first.Code != http.StatusCreated {
+		t.Fatalf("first: %d %s", first.Code, first.Body)
+	}
+	again := s.send(t, http.MethodPost, "/api/v1/links", aliceKey, body, withKey("k1"))
+	if again.Code != http.StatusCreated || decode(t, again)["slug"] != decode(t, first)["slug"] {
+		t.Errorf("retry: %d %s, want the first response %s", again.Code, again.Body, first.Body)
+	}
+	if again.Header().Get("Idempotent-Replayed") != "true" || again.Header().Get("Location") == "" {
+		t.Errorf("retry headers = %v", again.Header())
+	}
+	if n, _ := s.st.CountLinks(context.Background()); n != 1 {
+		t.Errorf("%d links after a retry, want 1", n)
+	}
+
+	other := s.send(t, http.MethodPost, "/api/v1/links", aliceKey, map[string]any{"url": "https://example.com/other"}, withKey("k1"))
+	if other.Code != http.StatusUnprocessableEntity {
+		t.Errorf("same key, different request: %d, want 422", other.Code)
+	}
+	// keys are per caller
+	bob := s.send(t, http.MethodPost, "/api/v1/links", bobKey, body, withKey("k1"))
+	if bob.Code != http.StatusCreated || decode(t, bob)["slug"] == decode(t, first)["slug"] {
+		t.Errorf("another caller's key: %d %s, want a link of its own", bob.Code, bob.Body)
+	}
+}
+
+// The stored hash must not be a hash of the password.
+func TestIdempotencyHashLeavesOutPassword(t *testing.T) {
+	s := newTestServer(t)
+	rec := s.send(t, http.MethodPost, "/api/v1/links", aliceKey, map[string]any{"url": "https://example.com/", "password": "hunter22"}, withKey("k1"))
+	if rec.Code != http.StatusCreated {
+		t.Fatalf("create: %d %s", rec.Code, rec.Body)
+	}
+	row, err := s.st.GetIdempotentResponse(context.Background(), db.GetIdempotentResponseParams{Owner: "alice", Key: "k1", Now: time.Now().Unix()})
+	if err != nil {
+		t.Fatal(err)
+	}
+	hash := func(req createRequest) string {
+		raw, _ := json.Marshal(req)
+		sum := sha256.Sum256(raw)
+		return hex.EncodeToString(sum[:])
+	}
+	if row.RequestHash == hash(createRequest{URL: "https://example.com/", Password: "hunter22"}) {
+		t.Error("request hash covers the password")
+	}
+	if row.RequestHash != hash(createRequest{URL: "https://example.com/"}) {
+		t.Errorf("request hash %s isn't the request's without its password", row.RequestHash)
+	}
+}
+
+// racyStore acts as if another request saved a response for the key just
+// before this one, which then expired before it could be read back.
+type racyStore struct {
+	store.Store
+}
+
+func (s racyStore) WithTx(ctx context.Context, fn func(store.Store) error) error {
+	return s.Store.WithTx(ctx, func(tx store.Store) error { return fn(racyStore{tx}) })
+}
+
+func (racyStore) SaveIdempotentResponse(context.Context, db.SaveIdempotentResponseParams) (int64, error) {
+	return 0, nil
+}
+
+func (racyStore) GetIdempotentResponse(context.Context, db.GetIdempotentResponseParams) (db.GetIdempotentResponseRow, error) {
+	return db.GetIdempotentResponseRow{}, sql.ErrNoRows
+}
+
+func TestIdempotencyRaceWithNothingToReplay(t *testing.T) {
+	s := newTestServer(t)
+	s.link.Store = racyStore{s.st}
+	rec := s.send(t, http.MethodPost, "/api/v1/links", aliceKey, map[string]any{"url": "https://example.com/"}, withKey("k1"))
+	if rec.Code != http.StatusConflict {
+		t.Errorf("lost race: %d %s, want 409", rec.Code, rec.Body)
+	}
+	if n, _ := s.st.CountLinks(context.Background()); n != 0 {
+		t.Errorf("%d links kept from the request that lost the race", n)
+	}
+}
+
+func TestReuseDestination(t *testing.T) {
+	s := newTestServer(t)
+	reuse := map[string]any{"url": "https://example.com/page", "reuse": true}
+	first := s.create(t, aliceKey, reuse)
+
+	rec := s.do(t, http.MethodPost, "/api/v1/links", aliceKey, map[string]any{"url": "https://EXAMPLE.com/page", "reuse": true})
+	if rec.Code != http.StatusOK {
+		t.Fatalf("reuse: %d %s", rec.Code, rec.Body)
+	}
+	if body := decode(t, rec); body["slug"] != first || body["reused"] != true {
+		t.Errorf("reuse body = %v, want %s back", body, first)
+	}
+
+	for name, tc := range map[string]struct {
+		token string
+		body  map[string]any
+	}{
+		"without reuse":  {aliceKey, map[string]any{"url": "https://example.com/page"}},
+		"another caller": {bobKey, reuse},
+		"not plain":      {aliceKey, map[string]any{"url": "https://example.com/page", "reuse": true, "interstitial": true}},
+	} {
+		if slug := s.create(t, tc.token, tc.body); slug == first {
+			t.Errorf("%s: got %s back", name, first)
+		}
+	}
+
+	// once pointed elsewhere the link no longer stands for the destination
+	if rec := s.do(t, http.MethodPatch, "/api/v1/links/"+first, aliceKey, map[string]any{"url": "https://example.com/moved"}); rec.Code != http.StatusOK {
+		t.Fatalf("update: %d %s", rec.Code, rec.Body)
+	}
+	// another of alice's plain links to it may stand in, but not this one
+	rec = s.do(t, http.MethodPost, "/api/v1/links", aliceKey, reuse)
+	if slug := decode(t, rec)["slug"]; slug == first {
+		t.Errorf("reused %s after it was pointed elsewhere", first)
+	}
+}
@@ -1,39 +1,495 @@
 package link
 
 import (
+	"context"
+	"crypto/rand"
 	"database/sql"
 	"net/http"
+	"strings"
+	"sync/atomic"
+	"time"
 
 	lru "github.com/hashicorp/golang-lru"
 	"github.com/labstack/echo/v4"
 	"go.uber.org/zap"
+	"golang.org/x/crypto/bcrypt"
 
+	"shotr/config"
 	"shotr/db"
 	h "shotr/helpers"
+	"shotr/store"
 	"shotr/workers"
 )
 
 // Link handler contains dependencies for link endpoints.
 type Link struct {
-	Q        *db.Queries
+	Store    store.Store
 	Log      *zap.Logger
 	BaseHost string
 	Worker   *workers.ClickWorker
 	Cache    *lru.Cache
+	CacheTTL time.Duration
+	Geo      *h.GeoIP
+	Policy   *h.URLPolicy
+
+	MaxChainDepth int
+
+	UnlockSecret  []byte
+	unlockLimiter *h.RateLimiter
+
+	Keyring *h.Keyring
+	SignAll bool
+
+	Slugs       map[string]h.SlugStrategy
+	DefaultSlug string
+	Sizers      map[string]*h.SlugSizer // for the strategies that can grow
+	SlugFilter  *h.SlugFilter
+	linkCount   atomic.Int64
+
+	Quota          *h.RateLimiter // links per caller per hour; nil when unlimited
+	BatchMax       int
+	IdempotencyTTL time.Duration
+	TrackingParams []string // stripped from destinations; nil keeps them
 }
 
-func New(q *db.Queries, log *zap.Logger, baseHost string, cw *workers.ClickWorker, cache *lru.Cache) *Link {
-	return &Link{
-		Q:        q,
+func New(st store.Store, log *zap.Logger, cfg *config.Config, cw *workers.ClickWorker, cache *lru.Cache, geo *h.GeoIP, policy *h.URLPolicy, keyring *h.Keyring, filter *h.SlugFilter) *Link {
+	secret := []byte(cfg.UnlockSecret)
+	if len(secret) == 0 {
+		// unlock cookies then only survive until restart
+		secret = make([]byte, 32)
+		_, _ = rand.Read(secret)
+	}
+	l := &Link{
+		Store:    st,
 		Log:      log,
-		BaseHost: baseHost,
+		BaseHost: cfg.BaseHost,
 		Worker:   cw,
 		Cache:    cache,
+		CacheTTL: cfg.CacheTTL,
+		Geo:      geo,
+		Policy:   policy,
+
+		MaxChainDepth: cfg.MaxChainDepth,
+
+		UnlockSecret:  secret,
+		unlockLimiter: h.NewRateLimiter(5, time.Minute),
+
+		Keyring: keyring,
+		SignAll: cfg.SignAllSlugs,
+
+		Slugs:       h.SlugStrategies(cfg.SlugSequenceKey),
+		DefaultSlug: cfg.SlugStrategy,
+		SlugFilter:  filter,
+
+		BatchMax:       cfg.BatchMaxItems,
+		IdempotencyTTL: cfg.IdempotencyTTL,
+	}
+	if cfg.StripTracking {
+		l.TrackingParams = cfg.TrackingParams
+	}
+	if cfg.LinkQuota > 0 {
+		l.Quota = h.NewRateLimiter(cfg.LinkQuota, time.Hour)
+	}
+	l.initSizers()
+	return l
+}
+
+// createRequest is the body accepted by POST /api/v1/links.
+type createRequest struct {
+	URL      string            `json:"url" validate:"required,url"`
+	Device   map[string]string `json:"device" validate:"omitempty,dive,keys,oneof=ios android desktop,endkeys,required,url"`
+	Geo      map[string]string `json:"geo" validate:"omitempty,dive,keys,len=2,alpha,endkeys,required,url"`
+	Language map[string]string `json:"languages" validate:"omitempty,dive,keys,bcp47_language_tag,endkeys,required,url"`
+	Variants []variantRequest  `json:"variants" validate:"omitempty,min=2,max=10,dive"`
+	Schedule []scheduleWindow  `json:"schedule" validate:"omitempty,max=50,dive"`
+	Password string            `json:"password" validate:"omitempty,min=4,max=72"`
+	// Interstitial always shows the preview page instead of redirecting straight away.
+	Interstitial bool `json:"interstitial"`
+	// Signed gives the link an unguessable signed slug.
+	Signed bool `json:"signed"`
+	// SlugStrategy overrides the deployment's SLUG_STRATEGY for this link.
+	SlugStrategy string `json:"slug_strategy" validate:"omitempty,oneof=nanoid sequential words nocase"`
+	// Slug asks for a vanity slug instead of a generated one.
+	Slug string `json:"slug" validate:"omitempty,min=3,max=64"`
+	// Reuse returns the caller's existing link to the same destination, if
+	// there is one, instead of creating another. Only plain links are
+	// reused.
+	Reuse bool `json:"reuse"`
+}
+
+// plain reports whether r asks for nothing but a redirect to its URL. Only
+// plain links can stand in for one another.
+func (r *createRequest) plain() bool {
+	return len(r.Device) == 0 && len(r.Geo) == 0 && len(r.Language) == 0 &&
+		len(r.Variants) == 0 && len(r.Schedule) == 0 && r.Password == "" &&
+		!r.Interstitial && !r.Signed && r.SlugStrategy == "" && r.Slug == ""
+}
+
+type variantRequest struct {
+	Name   string `json:"name" validate:"required,max=32"`
+	URL    string `json:"url" validate:"required,url"`
+	Weight int64  `json:"weight" validate:"required,min=1,max=10000"`
+}
+
+// destinations lists every URL a link created from r could redirect to.
+func (r *createRequest) destinations() []string {
+	out := []string{r.URL}
+	for _, rules := range []map[string]string{r.Device, r.Geo, r.Language} {
+		for _, u := range rules {
+			out = append(out, u)
+		}
+	}
+	for _, v := range r.Variants {
+		out = append(out, v.URL)
+	}
+	for _, w := range r.Schedule {
+		out = append(out, w.URL)
 	}
+	return out
+}
+
+// canonicalize replaces every destination of r with its canonical
+// spelling. The rule maps and slices are copied, not changed in place, so
+// callers holding the request see it as it was submitted.
+func (r *createRequest) canonicalize(canonical func(string) (string, error)) error {
+	var err error
+	if r.URL, err = canonical(r.URL); err != nil {
+		return err
+	}
+	for _, rules := range []*map[string]string{&r.Device, &r.Geo, &r.Language} {
+		if len(*rules) == 0 {
+			continue
+		}
+		out := make(map[string]string, len(*rules))
+		for k, u := range *rules {
+			if out[k], err = canonical(u); err != nil {
+				return err
+			}
+		}
+		*rules = out
+	}
+	r.Variants = append([]variantRequest(nil), r.Variants...)
+	for i := range r.Variants {
+		if r.Variants[i].URL, err = canonical(r.Variants[i].URL); err != nil {
+			return err
+		}
+	}
+	r.Schedule = append([]scheduleWindow(nil), r.Schedule...)
+	for i := range r.Schedule {
+		if r.Schedule[i].URL, err = canonical(r.Schedule[i].URL); err != nil {
+			return err
+		}
+	}
+	return nil
 }
 
 // POST /api/v1/links
 func (l *Link) Create(c echo.Context) error {
+	var req createRequest
+	if err := h.BindAndValidate(c, &req); err != nil {
+		return h.JSONError(c, http.StatusBadRequest, err.Error())
+	}
+	ctx := c.Request().Context()
+	owner := callerName(c)
+
+	idem, cerr := l.idempotencyKey(c, owner, req)
+	if cerr != nil {
+		return cerr.write(c)
+	}
+	if idem != nil {
+		if done, err := l.replay(c, idem); done {
+			return err
+		}
+	}
+	p, cerr := l.prepare(ctx, owner, req)
+	if cerr != nil {
+		return cerr.write(c)
+	}
+	if req.Reuse && p.urlKey != "" {
+		found, err := l.findExisting(ctx, l.Store, p)
+		if err != nil {
+			l.Log.Error("failed to look up existing link", zap.Error(err))
+			return h.JSONError(c, http.StatusInternalServerError, "db error")
+		}
+		if found != nil {
+			body := l.linkBody(c, found.ID, found.Slug)
+			body["reused"] = true
+			l.remember(ctx, idem, http.StatusOK, body)
+			c.Response().Header().Set("Location", body["short_url"].(string))
+			return h.JSONSuccess(c, http.StatusOK, body, "")
+		}
+	}
+	// only what is actually inserted counts against the quota; replays and
+	// reused links are free
+	if !l.allowCreate(c) {
+		return errQuota.write(c)
+	}
+
+	var body map[string]any
+	err := l.Store.WithTx(ctx, func(q store.Store) error {
+		link, err := l.save(ctx, q, p)
+		if err != nil {
+			return err
+		}
+		body = l.linkBody(c, link.ID, link.Slug)
+		// saved with the link, so a retry either finds both or neither
+		return l.saveResponse(ctx, q, idem, http.StatusCreated, body)
+	})
+	if err != nil {
+		l.refundCreate(c, 1)
+	}
+	if err == errIdempotencyRace {
+		if done, err := l.replay(c, idem); done {
+			return err
+		}
+	}
+	if err != nil {
+		return l.saveError(err).write(c)
+	}
+
+	c.Response().Header().Set("Location", body["short_url"].(string))
+	return h.JSONSuccess(c, http.StatusCreated, body, "")
+}
+
+// linkBody is the response to a successful create.
+func (l *Link) linkBody(c echo.Context, id int64, slug string) map[string]any {
+	return map[string]any{
+		"slug":      slug,
+		"short_url": h.BuildShortURL(c, l.BaseHost, slug),
+		"id":        id,
+	}
+}
+
+// createError is why a link wasn't created, as the client is told.
+type createError struct {
+	Status  int
+	Code    string // machine-readable, for policy failures
+	Message string
+}
+
+func (e *createError) Error() string { return e.Message }
+
+func (e *createError) write(c echo.Context) error {
+	if e.Code != "" {
+		return h.JSONErrorCode(c, e.Status, e.Code, e.Message)
+	}
+	return h.JSONError(c, e.Status, e.Message)
+}
+
+func badRequest(msg string) *createError {
+	return &createError{Status: http.StatusBadRequest, Message: msg}
+}
+
+var errQuota = &createError{Status: http.StatusTooManyRequests, Message: "link quota exceeded"}
+
+// newLink is a create request that passed validation, ready to save.
+type newLink struct {
+	req          createRequest
+	signed       bool
+	passwordHash []byte
+	owner        string // see callerName
+	urlKey       string // normalized destination, for plain links only
+	originalURL  string // req.URL as submitted, if canonicalURL changed it
+}
+
+// prepare checks what struct tags can't: destinations against the URL
+// policy and chains, variants, schedule and slug options. It canonicalizes
+// every destination and hashes the password, so save does no slow work
+// inside a transaction.
+func (l *Link) prepare(ctx context.Context, owner string, req createRequest) (*newLink, *createError) {
+	submitted := req.URL
+	if err := req.canonicalize(l.canonicalURL); err != nil {
+		return nil, badRequest("invalid url")
+	}
+	var original string
+	if req.URL != submitted {
+		original = submitted
+	}
+	if err := l.checkDestinations(ctx, "", req.destinations()); err != nil {
+		return nil, l.destinationFailure(err)
+	}
+	seen := make(map[string]bool, len(req.Variants))
+	for _, v := range req.Variants {
+		if seen[v.Name] {
+			return nil, badRequest("duplicate variant name")
+		}
+		seen[v.Name] = true
+	}
+	if err := validateSchedule(req.Schedule); err != nil {
+		return nil, badRequest(err.Error())
+	}
+	p := &newLink{req: req, signed: req.Signed || l.SignAll, owner: owner, originalURL: original}
+	if req.plain() {
+		p.urlKey = req.URL
+	}
+	if req.Slug != "" {
+		if req.Signed || req.SlugStrategy != "" {
+			return nil, badRequest("a vanity slug can't be signed or use a slug strategy")
+		}
+		if err := l.checkVanity(req.Slug); err != nil {
+			return nil, badRequest(err.Error())
+		}
+		p.signed = false
+	}
+	if p.signed && l.Keyring == nil {
+		return nil, badRequest("signed links are not enabled")
+	}
+	if req.Password != "" {
+		var err error
+		if p.passwordHash, err = bcrypt.GenerateFromPassword([]byte(req.Password), bcrypt.DefaultCost); err != nil {
+			l.Log.Error("failed to hash link password", zap.Error(err))
+			return nil, &createError{Status: http.StatusInternalServerError, Message: "couldn't create short link"}
+		}
+	}
+	return p, nil
+}
+
+// canonicalURL is the spelling of a destination that gets stored.
+func (l *Link) canonicalURL(raw string) (string, error) {
+	return h.CanonicalURL(raw, l.TrackingParams)
+}
+
+// save inserts the link and its rules through q, which should be a
+// transaction so a failure leaves nothing behind.
+func (l *Link) save(ctx context.Context, q store.Store, p *newLink) (db.Link, error) {
+	req := p.req
+	var (
+		link db.Link
+		err  error
+	)
+	if req.Slug != "" {
+		link, err = l.insertVanity(ctx, q, db.AddLinkParams{Slug: req.Slug, Url: req.URL, User: ownerColumn(p.owner)})
+	} else {
+		link, err = l.insertLink(ctx, q, db.AddLinkParams{Url: req.URL, User: ownerColumn(p.owner)}, req.SlugStrategy, p.signed)
+	}
+	if err != nil {
+		return link, err
+	}
+	if p.originalURL != "" {
+		if err := q.SetLinkOriginalURL(ctx, db.SetLinkOriginalURLParams{
+			OriginalUrl: sql.NullString{String: p.originalURL, Valid: true},
+			Slug:        link.Slug,
+		}); err != nil {
+			return link, err
+		}
+	}
+	if p.passwordHash != nil {
+		if err := q.SetLinkPassword(ctx, db.SetLinkPasswordParams{
+			PasswordHash: sql.NullString{String: string(p.passwordHash), Valid: true},
+			Slug:         link.Slug,
+		}); err != nil {
+			return link, err
+		}
+	}
+	if req.Interstitial {
+		if err := q.SetLinkInterstitial(ctx, db.SetLinkInterstitialParams{
+			Interstitial: true,
+			Slug:         link.Slug,
+		}); err != nil {
+			return link, err
+		}
+	}
+	if err := addTargets(ctx, q, link.Slug, targetDevice, req.Device); err != nil {
+		return link, err
+	}
+	if err := addTargets(ctx, q, link.Slug, targetCountry, req.Geo); err != nil {
+		return link, err
+	}
+	if err := addTargets(ctx, q, link.Slug, targetLanguage, req.Language); err != nil {
+		return link, err
+	}
+	if err := addSchedule(ctx, q, link.Slug, req.Schedule); err != nil {
+		return link, err
+	}
+	for _, v := range req.Variants {
+		if err := q.AddLinkVariant(ctx, db.AddLinkVariantParams{
+			Slug:   link.Slug,
+			Name:   v.Name,
+			Url:    v.URL,
+			Weight: v.Weight,
+		}); err != nil {
+			return link, err
+		}
+	}
+	if p.urlKey != "" {
+		if err := q.AddLinkDestination(ctx, db.AddLinkDestinationParams{
+			Slug:   link.Slug,
+			Owner:  p.owner,
+			UrlKey: p.urlKey,
+		}); err != nil {
+			return link, err
+		}
+	}
+	return link, nil
+}
+
+// saveError is what the client is told when save fails.
+func (l *Link) saveError(err error) *createError {
+	if err == errSlugTaken {
+		return &createError{Status: http.StatusConflict, Message: "slug is already taken"}
+	}
+	if err == errIdempotencyRace {
+		// another request with the key won but its response can't be
+		// replayed, e.g. it expired in between
+		return &createError{Status: http.StatusConflict, Message: "a request with this Idempotency-Key conflicted; retry it"}
+	}
+	l.Log.Error("failed to create short link", zap.Error(err))
+	return &createError{Status: http.StatusInternalServerError, Message: "couldn't create short link"}
+}
+
+// allowCreate spends one of the caller's link quota, if there is one. The
+// caller is its API key when keys are configured, otherwise its IP.
+func (l *Link) allowCreate(c echo.Context) bool {
+	if l.Quota == nil {
+		return true
+	}
+	return l.Quota.Allow(quotaKey(c))
+}
+
+// refundCreate gives back n of the caller's link quota spent on links that
+// were never stored.
+func (l *Link) refundCreate(c echo.Context, n int) {
+	if l.Quota != nil {
+		l.Quota.Refund(quotaKey(c), n)
+	}
+}
+
+func quotaKey(c echo.Context) string {
+	if name := callerName(c); name != "" {
+		return "key:" + name
+	}
+	return "ip:" + h.ClientIP(c)
+}
+
+// callerName is the name of the caller's API key, or "" when API keys
+// aren't in use and all callers share one namespace.
+func callerName(c echo.Context) string {
+	name, _ := c.Get(h.APIKeyName).(string)
+	return name
+}
+
+// ownerColumn is owner as stored in links.user.
+func ownerColumn(owner string) sql.NullString {
+	return sql.NullString{String: owner, Valid: owner != ""}
+}
+
+// mayEdit reports whether the caller may change link: the admin may change
+// any link, an API key only the links it created.
+func mayEdit(c echo.Context, link db.Link) bool {
+	if admin, _ := c.Get(h.AdminName).(bool); admin {
+		return true
+	}
+	owner := callerName(c)
+	return owner != "" && link.User.Valid && link.User.String == owner
+}
+
+// PATCH /api/v1/links/:slug
+func (l *Link) Update(c echo.Context) error {
+	slug := c.Param("slug")
+	if slug == "" {
+		return h.JSONError(c, http.StatusBadRequest, "missing slug")
+	}
+
 	var req struct {
 		URL string `json:"url" validate:"required,url"`
 	}
@@ -42,19 +498,42 @@ func (l *Link) Create(c echo.Context) error {
 	}
 
 	ctx := c.Request().Context()
-	link, err := h.TryInsertWithRetry(ctx, l.Q, req.URL, 5, l.Log)
+	link, err := l.Store.GetLink(ctx, slug)
+	if err == sql.ErrNoRows {
+		return h.JSONError(c, http.StatusNotFound, "not found")
+	} else if err != nil {
+		l.Log.Error("db lookup failed", zap.Error(err))
+		return h.JSONError(c, http.StatusInternalServerError, "db error")
+	}
+	if !mayEdit(c, link) {
+		return h.JSONError(c, http.StatusForbidden, "not your link")
+	}
+	canonical, err := l.canonicalURL(req.URL)
 	if err != nil {
-		l.Log.Error("failed to create short link", zap.Error(err))
-		return h.JSONError(c, http.StatusInternalServerError, "couldn't create short link")
+		return h.JSONError(c, http.StatusBadRequest, "invalid url")
+	}
+	if err := l.checkDestinations(ctx, slug, []string{canonical}); err != nil {
+		return l.destinationError(c, err)
 	}
 
-	short := h.BuildShortURL(c, l.BaseHost, link.Slug)
-	c.Response().Header().Set("Location", short)
+	err = l.Store.WithTx(ctx, func(q store.Store) error {
+		if err := q.UpdateLinkURL(ctx, db.UpdateLinkURLParams{Url: canonical, Slug: slug}); err != nil {
+			return err
+		}
+		return q.SetLinkOriginalURL(ctx, db.SetLinkOriginalURLParams{
+			OriginalUrl: sql.NullString{String: req.URL, Valid: canonical != req.URL},
+			Slug:        slug,
+		})
+	})
+	if err != nil {
+		l.Log.Error("failed to update link", zap.Error(err))
+		return h.JSONError(c, http.StatusInternalServerError, "couldn't update short link")
+	}
+	l.purge(slug)
 
-	return h.JSONSuccess(c, http.StatusCreated, map[string]any{
-		"slug":      link.Slug,
-		"short_url": short,
-		"id":        link.ID,
+	return h.JSONSuccess(c, http.StatusOK, map[string]any{
+		"slug": slug,
+		"url":  canonical,
 	}, "")
 }
 
@@ -64,9 +543,12 @@ func (l *Link) Redirect(c echo.Context) error {
 	if slug == "" {
 		return h.JSONError(c, http.StatusBadRequest, "missing slug")
 	}
+	if strings.HasSuffix(slug, previewSuffix) {
+		return l.Preview(c)
+	}
 
 	ctx := c.Request().Context()
-	url, _, err := l.resolveURL(ctx, slug)
+	dest, _, err := l.resolveURL(ctx, slug)
 	if err == sql.ErrNoRows {
 		return h.JSONError(c, http.StatusNotFound, "not found")
 	}
@@ -74,9 +556,36 @@ func (l *Link) Redirect(c echo.Context) error {
 		l.Log.Error("db lookup failed", zap.Error(err))
 		return h.JSONError(c, http.StatusInternalServerError, "db error")
 	}
+	slug = dest.Slug
+
+	if dest.TakedownStatus != 0 {
+		return renderTakedown(c, dest)
+	}
+	if dest.PasswordHash != "" && !l.unlocked(c, slug) {
+		return renderPage(c, http.StatusUnauthorized, "unlock.html", unlockPage{Slug: slug})
+	}
+
+	v := l.newVisitor(c)
+	ch := dest.pick(c, v)
+	l.enqueueClick(ctx, workers.ClickEvent{
+		Slug:     slug,
+		Time:     time.Now(),
+		Country:  v.Country,
+		Variant:  ch.Variant,
+		Language: ch.Language,
+		Source:   clickSource(c),
+	})
 
-	l.enqueueClick(ctx, slug)
-	return c.Redirect(http.StatusFound, url)
+	if dest.Interstitial {
+		page, err := l.newPreviewPage(ctx, c, dest, ch.URL)
+		if err != nil {
+			l.Log.Error("failed to fetch link stats", zap.Error(err))
+			return h.JSONError(c, http.StatusInternalServerError, "db error")
+		}
+		page.Interstitial = true
+		return renderPage(c, http.StatusOK, "preview.html", page)
+	}
+	return c.Redirect(http.StatusFound, ch.URL)
 }
 
 // GET /api/v1/links/:slug/stats
@@ -87,7 +596,7 @@ func (l *Link) Stats(c echo.Context) error {
 	}
 
 	ctx := c.Request().Context()
-	linkRow, err := l.Q.GetLinkStats(ctx, slug)
+	linkRow, slug, err := findSlug(ctx, slug, l.Store.GetLinkStats)
 	if err == sql.ErrNoRows {
 		return h.JSONError(c, http.StatusNotFound, "not found")
 	}
@@ -96,7 +605,7 @@ func (l *Link) Stats(c echo.Context) error {
 		return h.JSONError(c, http.StatusInternalServerError, "db error")
 	}
 
-	dailyRows, err := l.Q.GetDailyClicks(ctx, slug)
+	dailyRows, err := l.Store.GetDailyClicks(ctx, slug)
 	if err != nil {
 		l.Log.Error("failed to fetch daily clicks", zap.Error(err))
 		return h.JSONError(c, http.StatusInternalServerError, "db error")
@@ -114,15 +623,94 @@ func (l *Link) Stats(c echo.Context) error {
 		})
 	}
 
+	countryRows, err := l.Store.GetCountryClicks(ctx, slug)
+	if err != nil {
+		l.Log.Error("failed to fetch country clicks", zap.Error(err))
+		return h.JSONError(c, http.StatusInternalServerError, "db error")
+	}
+
+	countries := make([]map[string]any, 0, len(countryRows))
+	for _, r := range countryRows {
+		countries = append(countries, map[string]any{
+			"country": r.Country,
+			"clicks":  r.Clicks,
+		})
+	}
+
+	languageRows, err := l.Store.GetLanguageClicks(ctx, slug)
+	if err != nil {
+		l.Log.Error("failed to fetch language clicks", zap.Error(err))
+		return h.JSONError(c, http.StatusInternalServerError, "db error")
+	}
+
+	languages := make([]map[string]any, 0, len(languageRows))
+	for _, r := range languageRows {
+		languages = append(languages, map[string]any{
+			"language": r.Language,
+			"clicks":   r.Clicks,
+		})
+	}
+
+	sourceRows, err := l.Store.GetSourceClicks(ctx, slug)
+	if err != nil {
+		l.Log.Error("failed to fetch source clicks", zap.Error(err))
+		return h.JSONError(c, http.StatusInternalServerError, "db error")
+	}
+
+	sources := make([]map[string]any, 0, len(sourceRows))
+	for _, r := range sourceRows {
+		sources = append(sources, map[string]any{
+			"source": r.Source,
+			"clicks": r.Clicks,
+		})
+	}
+
+	variantRows, err := l.Store.GetLinkVariants(ctx, slug)
+	if err != nil {
+		l.Log.Error("failed to fetch link variants", zap.Error(err))
+		return h.JSONError(c, http.StatusInternalServerError, "db error")
+	}
+
+	variants := make([]map[string]any, 0, len(variantRows))
+	for _, r := range variantRows {
+		clicks := int64(0)
+		if r.Clicks.Valid {
+			clicks = r.Clicks.Int64
+		}
+		variants = append(variants, map[string]any{
+			"name":   r.Name,
+			"url":    r.Url,
+			"weight": r.Weight,
+			"clicks": clicks,
+		})
+	}
+
 	total := int64(0)
 	if linkRow.Clicks.Valid {
 		total = linkRow.Clicks.Int64
 	}
 
-	return h.JSONSuccess(c, http.StatusOK, map[string]any{
-		"slug":  linkRow.Slug,
-		"total": total,
-		"daily": daily,
-		"url":   linkRow.Url,
-	}, "")
-}
\ No newline at end of file
+	resp := map[string]any{
+		"slug":      linkRow.Slug,
+		"total":     total,
+		"daily":     daily,
+		"countries": countries,
+		"languages": languages,
+		"sources":   sources,
+		"variants":  variants,
+		"url":       linkRow.Url,
+	}
+	if linkRow.OriginalUrl.Valid {
+		resp["original_url"] = linkRow.OriginalUrl.String
+	}
+	// stats are public, so don't let them reveal where a protected link goes
+	if linkRow.PasswordHash.Valid {
+		resp["protected"] = true
+		delete(resp, "url")
+		delete(resp, "original_url")
+		for _, v := range variants {
+			delete(v, "url")
+		}
+	}
+	return h.JSONSuccess(c, http.StatusOK, resp, "")
+}
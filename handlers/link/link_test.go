@@ -0,0 +1,334 @@
+package link
+
+import (
+	"context"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/labstack/echo/v4"
+	"go.uber.org/zap"
+
+	"shotr/config"
+	h "shotr/helpers"
+	"shotr/store"
+)
+
+// API keys and the admin token the test server accepts.
+const (
+	aliceKey   = "alice-secret"
+	bobKey     = "bob-secret"
+	adminToken = "admin-secret"
+)
+
+// testServer is the link handler on an in-memory store, routed the way
+// server.go routes it. Clicks are written as they happen, without a worker.
+type testServer struct {
+	e    *echo.Echo
+	link *Link
+	st   store.Store
+}
+
+// newTestServer applies opts to a default config before building the
+// handler.
+func newTestServer(t *testing.T, opts ...func(*config.Config)) *testServer {
+	t.Helper()
+	cfg := &config.Config{
+		BaseHost:       "http://sho.rt",
+		MaxChainDepth:  1,
+		SlugStrategy:   h.SlugNanoid,
+		BatchMaxItems:  1000,
+		IdempotencyTTL: time.Hour,
+		CacheTTL:       time.Minute,
+	}
+	for _, opt := range opts {
+		opt(cfg)
+	}
+	policy, err := h.LoadURLPolicy("", "", []string{"bit.ly"})
+	if err != nil {
+		t.Fatal(err)
+	}
+	filter, err := h.LoadSlugFilter("")
+	if err != nil {
+		t.Fatal(err)
+	}
+	keyring, err := h.ParseKeyring(cfg.SlugSigningKeys)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	st := store.NewMemory()
+	l := New(st, zap.NewNop(), cfg, nil, nil, nil, policy, keyring, filter)
+	keys := map[string]string{aliceKey: "alice", bobKey: "bob"}
+
+	e := echo.New()
+	e.POST("/api/v1/links", l.Create, h.RequireAPIKey(keys))
+	e.POST("/api/v1/links/batch", l.Batch, h.RequireAPIKey(keys))
+	e.PATCH("/api/v1/links/:slug", l.Update, h.RequireKeyOrBearer(keys, adminToken))
+	e.GET("/:slug", l.Redirect, l.CheckSignature)
+	e.GET("/api/v1/links/:slug/stats", l.Stats, l.CheckSignature)
+	e.GET("/api/v1/links/:slug/qr", l.QR, l.CheckSignature)
+	e.POST("/:slug/report", l.Report, l.CheckSignature)
+	admin := e.Group("/api/v1/admin", h.RequireBearer(adminToken))
+	admin.POST("/links/:slug/takedown", l.Takedown)
+	admin.DELETE("/links/:slug/takedown", l.Restore)
+	return &testServer{e: e, link: l, st: st}
+}
+
+// do sends body, JSON-encoded unless it is already a string, with token
+// as the bearer token when it isn't empty.
+func (s *testServer) do(t *testing.T, method, path, token string, body any) *httptest.ResponseRecorder {
+	t.Helper()
+	return s.send(t, method, path, token, body, nil)
+}
+
+// send is do with extra request headers.
+func (s *testServer) send(t *testing.T, method, path, token string, body any, header http.Header) *httptest.ResponseRecorder {
+	t.Helper()
+	var raw string
+	switch b := body.(type) {
+	case nil:
+	case string:
+		raw = b
+	default:
+		enc, err := json.Marshal(b)
+		if err != nil {
+			t.Fatal(err)
+		}
+		raw = string(enc)
+	}
+	req := httptest.NewRequest(method, path, strings.NewReader(raw))
+	if raw != "" {
+		req.Header.Set(echo.HeaderContentType, echo.MIMEApplicationJSON)
+	}
+	if token != "" {
+		req.Header.Set(echo.HeaderAuthorization, "Bearer "+token)
+	}
+	for k, v := range header {
+		req.Header[k] = v
+	}
+	rec := httptest.NewRecorder()
+	s.e.ServeHTTP(rec, req)
+	return rec
+}
+
+// create makes a link as token and returns its slug.
+func (s *testServer) create(t *testing.T, token string, body map[string]any) string {
+	t.Helper()
+	rec := s.do(t, http.MethodPost, "/api/v1/links", token, body)
+	if rec.Code != http.StatusCreated {
+		t.Fatalf("create %v: %d %s", body, rec.Code, rec.Body)
+	}
+	return decode(t, rec)["slug"].(string)
+}
+
+func decode(t *testing.T, rec *httptest.ResponseRecorder) map[string]any {
+	t.Helper()
+	var out map[string]any
+	if err := json.Unmarshal(rec.Body.Bytes(), &out); err != nil {
+		t.Fatalf("decode %q: %v", rec.Body, err)
+	}
+	return out
+}
+
+func TestCreateAndRedirect(t *testing.T) {
+	s := newTestServer(t)
+	rec := s.do(t, http.MethodPost, "/api/v1/links", aliceKey, map[string]any{"url": "https://example.com/a"})
+	if rec.Code != http.StatusCreated {
+		t.Fatalf("create: %d %s", rec.Code, rec.Body)
+	}
+	body := decode(t, rec)
+	slug, _ := body["slug"].(string)
+	if slug == "" || body["short_url"] != "http://sho.rt/"+slug {
+		t.Fatalf("create body = %v", body)
+	}
+
+	rec = s.do(t, http.MethodGet, "/"+slug, "", nil)
+	if rec.Code != http.StatusFound || rec.Header().Get("Location") != "https://example.com/a" {
+		t.Errorf("redirect: %d to %q", rec.Code, rec.Header().Get("Location"))
+	}
+	if rec := s.do(t, http.MethodGet, "/nosuchlink", "", nil); rec.Code != http.StatusNotFound {
+		t.Errorf("unknown slug: %d, want 404", rec.Code)
+	}
+}
+
+func TestCreateRefusals(t *testing.T) {
+	s := newTestServer(t)
+	s.create(t, aliceKey, map[string]any{"url": "https://example.com/", "slug": "taken"})
+
+	cases := []struct {
+		name  string
+		token string
+		body  any
+		want  int
+		code  string
+	}{
+		{"no key", "", map[string]any{"url": "https://example.com/"}, http.StatusUnauthorized, ""},
+		{"not json", aliceKey, "{", http.StatusBadRequest, ""},
+		{"no url", aliceKey, map[string]any{}, http.StatusBadRequest, ""},
+		{"private address", aliceKey, map[string]any{"url": "http://127.0.0.1/"}, http.StatusBadRequest, h.PolicyPrivateAddress},
+		{"other shortener", aliceKey, map[string]any{"url": "https://bit.ly/x"}, http.StatusBadRequest, h.PolicyShortener},
+		{"dangling own link", aliceKey, map[string]any{"url": "http://sho.rt/nothing"}, http.StatusBadRequest, h.PolicyDanglingLink},
+		{"vanity taken", aliceKey, map[string]any{"url": "https://example.com/", "slug": "taken"}, http.StatusConflict, ""},
+	}
+	for _, tc := range cases {
+		t.Run(tc.name, func(t *testing.T) {
+			rec := s.do(t, http.MethodPost, "/api/v1/links", tc.token, tc.body)
+			if rec.Code != tc.want {
+				t.Fatalf("status = %d %s, want %d", rec.Code, rec.Body, tc.want)
+			}
+			if tc.code != "" && decode(t, rec)["code"] != tc.code {
+				t.Errorf("body = %s, want code %s", rec.Body, tc.code)
+			}
+		})
+	}
+}
+
+func TestCreateStoresCanonicalDestinations(t *testing.T) {
+	s := newTestServer(t, func(cfg *config.Config) {
+		cfg.StripTracking = true
+		cfg.TrackingParams = []string{"utm_*"}
+	})
+	messy := func(path string) string { return "HTTPS://Example.com:443/x/.." + path + "?utm_source=mail" }
+	clean := func(path string) string { return "https://example.com" + path }
+	slug := s.create(t, aliceKey, map[string]any{
+		"url":    messy("/main"),
+		"device": map[string]string{"ios": messy("/ios")},
+		"variants": []map[string]any{
+			{"name": "a", "url": messy("/a"), "weight": 1},
+			{"name": "b", "url": messy("/b"), "weight": 1},
+		},
+		"schedule": []map[string]any{{"start": time.Now().Add(time.Hour), "url": messy("/later")}},
+	})
+
+	ctx := context.Background()
+	link, err := s.st.GetLink(ctx, slug)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if link.Url != clean("/main") || link.OriginalUrl.String != messy("/main") {
+		t.Errorf("url = %q, submitted as %q", link.Url, link.OriginalUrl.String)
+	}
+	targets, err := s.st.GetLinkTargets(ctx, slug)
+	if err != nil || len(targets) != 1 || targets[0].Url != clean("/ios") {
+		t.Errorf("targets = %+v, %v", targets, err)
+	}
+	variants, err := s.st.GetLinkVariants(ctx, slug)
+	if err != nil || len(variants) != 2 {
+		t.Fatalf("variants = %+v, %v", variants, err)
+	}
+	for _, v := range variants {
+		if v.Url != clean("/"+v.Name) {
+			t.Errorf("variant %s url = %q", v.Name, v.Url)
+		}
+	}
+	schedules, err := s.st.GetLinkSchedules(ctx, slug)
+	if err != nil || len(schedules) != 1 || schedules[0].Url != clean("/later") {
+		t.Errorf("schedules = %+v, %v", schedules, err)
+	}
+
+	if rec := s.do(t, http.MethodPost, "/api/v1/links", aliceKey, map[string]any{
+		"url":    "https://example.com/",
+		"device": map[string]string{"ios": "https://exa mple.com/"},
+	}); rec.Code != http.StatusBadRequest {
+		t.Errorf("unparsable rule url: %d %s, want 400", rec.Code, rec.Body)
+	}
+}
+
+func TestUpdate(t *testing.T) {
+	s := newTestServer(t)
+	slug := s.create(t, aliceKey, map[string]any{"url": "https://example.com/old"})
+	path := "/api/v1/links/" + slug
+	move := func(url string) map[string]any { return map[string]any{"url": url} }
+
+	if rec := s.do(t, http.MethodPatch, path, "", move("https://example.com/new")); rec.Code != http.StatusUnauthorized {
+		t.Errorf("no token: %d, want 401", rec.Code)
+	}
+	if rec := s.do(t, http.MethodPatch, path, bobKey, move("https://example.com/new")); rec.Code != http.StatusForbidden {
+		t.Errorf("another key: %d, want 403", rec.Code)
+	}
+	if rec := s.do(t, http.MethodPatch, "/api/v1/links/nosuchlink", aliceKey, move("https://example.com/new")); rec.Code != http.StatusNotFound {
+		t.Errorf("unknown slug: %d, want 404", rec.Code)
+	}
+	if rec := s.do(t, http.MethodPatch, path, aliceKey, move("http://sho.rt/"+slug)); rec.Code != http.StatusBadRequest {
+		t.Errorf("pointing at itself: %d, want 400", rec.Code)
+	}
+
+	rec := s.do(t, http.MethodPatch, path, aliceKey, move("https://example.com/new"))
+	if rec.Code != http.StatusOK {
+		t.Fatalf("owner: %d %s", rec.Code, rec.Body)
+	}
+	if rec := s.do(t, http.MethodGet, "/"+slug, "", nil); rec.Header().Get("Location") != "https://example.com/new" {
+		t.Errorf("redirects to %q after the update", rec.Header().Get("Location"))
+	}
+	if rec := s.do(t, http.MethodPatch, path, adminToken, move("https://example.com/admin")); rec.Code != http.StatusOK {
+		t.Errorf("admin: %d %s", rec.Code, rec.Body)
+	}
+}
+
+func TestStats(t *testing.T) {
+	s := newTestServer(t)
+	slug := s.create(t, aliceKey, map[string]any{"url": "https://example.com/"})
+	for i := 0; i < 3; i++ {
+		s.do(t, http.MethodGet, "/"+slug, "", nil)
+	}
+	s.do(t, http.MethodGet, "/"+slug+"?src=qr", "", nil)
+
+	rec := s.do(t, http.MethodGet, "/api/v1/links/"+slug+"/stats", "", nil)
+	if rec.Code != http.StatusOK {
+		t.Fatalf("stats: %d %s", rec.Code, rec.Body)
+	}
+	var stats struct {
+		Slug  string `json:"slug"`
+		URL   string `json:"url"`
+		Total int64  `json:"total"`
+		Daily []struct {
+			Clicks int64 `json:"clicks"`
+		} `json:"daily"`
+		Sources []struct {
+			Source string `json:"source"`
+			Clicks int64  `json:"clicks"`
+		} `json:"sources"`
+	}
+	if err := json.Unmarshal(rec.Body.Bytes(), &stats); err != nil {
+		t.Fatal(err)
+	}
+	if stats.Slug != slug || stats.URL != "https://example.com/" || stats.Total != 4 {
+		t.Errorf("stats = %+v, want 4 clicks on %s", stats, slug)
+	}
+	if len(stats.Daily) != 1 || stats.Daily[0].Clicks != 4 {
+		t.Errorf("daily = %+v, want one day of 4", stats.Daily)
+	}
+	if len(stats.Sources) != 1 || stats.Sources[0].Source != "qr" || stats.Sources[0].Clicks != 1 {
+		t.Errorf("sources = %+v, want qr 1", stats.Sources)
+	}
+
+	if rec := s.do(t, http.MethodGet, "/api/v1/links/nosuchlink/stats", "", nil); rec.Code != http.StatusNotFound {
+		t.Errorf("unknown slug: %d, want 404", rec.Code)
+	}
+}
+
+func TestTakedownFindsNocaseSlugInAnyCase(t *testing.T) {
+	s := newTestServer(t)
+	slug := s.create(t, aliceKey, map[string]any{"url": "https://example.com/", "slug_strategy": h.SlugNocase})
+	typed := "/api/v1/admin/links/" + strings.ToUpper(slug) + "/takedown"
+
+	if rec := s.do(t, http.MethodPost, typed, adminToken, map[string]any{"status": 451}); rec.Code != http.StatusOK {
+		t.Fatalf("takedown: %d %s", rec.Code, rec.Body)
+	}
+	if rec := s.do(t, http.MethodGet, "/"+slug, "", nil); rec.Code != http.StatusUnavailableForLegalReasons {
+		t.Errorf("redirect after takedown: %d, want 451", rec.Code)
+	}
+	if rec := s.do(t, http.MethodDelete, typed, adminToken, nil); rec.Code != http.StatusOK {
+		t.Fatalf("restore: %d %s", rec.Code, rec.Body)
+	}
+	if rec := s.do(t, http.MethodGet, "/"+slug, "", nil); rec.Code != http.StatusFound {
+		t.Errorf("redirect after restore: %d, want 302", rec.Code)
+	}
+	if rec := s.do(t, http.MethodDelete, "/api/v1/admin/links/nosuchlink/takedown", adminToken, nil); rec.Code != http.StatusNotFound {
+		t.Errorf("unknown slug: %d, want 404", rec.Code)
+	}
+}
@@ -0,0 +1,42 @@
+package link
+
+import (
+	"bytes"
+	"embed"
+	"html/template"
+	"net/http"
+
+	"github.com/labstack/echo/v4"
+)
+
+//go:embed templates/*.html
+var templateFS embed.FS
+
+var pages = template.Must(template.ParseFS(templateFS, "templates/*.html"))
+
+// renderPage executes one of the embedded HTML templates. HEAD requests get
+// the status and headers only.
+func renderPage(c echo.Context, code int, name string, data any) error {
+	c.Response().Header().Set("Cache-Control", "no-store")
+	if c.Request().Method == http.MethodHead {
+		return c.NoContent(code)
+	}
+	var buf bytes.Buffer
+	if err := pages.ExecuteTemplate(&buf, name, data); err != nil {
+		return err
+	}
+	return c.HTMLBlob(code, buf.Bytes())
+}
+
+type takedownPage struct {
+	Status int
+	Reason string
+}
+
+// renderTakedown serves the warning page for a link that was taken down.
+func renderTakedown(c echo.Context, dest *destination) error {
+	return renderPage(c, dest.TakedownStatus, "takedown.html", takedownPage{
+		Status: dest.TakedownStatus,
+		Reason: dest.TakedownReason,
+	})
+}
@@ -0,0 +1,77 @@
+package link
+
+import (
+	"context"
+	"database/sql"
+	"net/http"
+	"strings"
+	"time"
+
+	"github.com/labstack/echo/v4"
+	"go.uber.org/zap"
+
+	h "shotr/helpers"
+)
+
+// previewSuffix appended to a slug asks for the preview page instead of a
+// redirect, e.g. /abc1234+
+const previewSuffix = "+"
+
+type previewPage struct {
+	ShortURL     string
+	URL          string
+	Owner        string
+	CreatedAt    time.Time
+	Clicks       int64
+	Protected    bool
+	Varies       bool
+	Interstitial bool
+}
+
+// newPreviewPage fills in the link metadata shown on both the preview and
+// the interstitial page. url is the destination to display.
+func (l *Link) newPreviewPage(ctx context.Context, c echo.Context, dest *destination, url string) (previewPage, error) {
+	linkRow, err := l.Store.GetLinkStats(ctx, dest.Slug)
+	if err != nil {
+		return previewPage{}, err
+	}
+	return previewPage{
+		ShortURL:  h.BuildShortURL(c, l.BaseHost, dest.Slug),
+		URL:       url,
+		Owner:     linkRow.User.String,
+		CreatedAt: linkRow.CreatedAt,
+		Clicks:    linkRow.Clicks.Int64,
+		Protected: dest.PasswordHash != "",
+		Varies:    len(dest.Targets) > 0 || len(dest.Variants) > 0 || !dest.Expires.IsZero(),
+	}, nil
+}
+
+// GET /:slug+  (dispatched from Redirect)
+// Preview shows where a link goes without following it or counting a click.
+func (l *Link) Preview(c echo.Context) error {
+	slug := strings.TrimSuffix(c.Param("slug"), previewSuffix)
+	if slug == "" {
+		return h.JSONError(c, http.StatusBadRequest, "missing slug")
+	}
+
+	ctx := c.Request().Context()
+	dest, _, err := l.resolveURL(ctx, slug)
+	if err == sql.ErrNoRows {
+		return h.JSONError(c, http.StatusNotFound, "not found")
+	}
+	if err != nil {
+		l.Log.Error("db lookup failed", zap.Error(err))
+		return h.JSONError(c, http.StatusInternalServerError, "db error")
+	}
+
+	if dest.TakedownStatus != 0 {
+		return renderTakedown(c, dest)
+	}
+
+	page, err := l.newPreviewPage(ctx, c, dest, dest.URL)
+	if err != nil {
+		l.Log.Error("failed to fetch link stats", zap.Error(err))
+		return h.JSONError(c, http.StatusInternalServerError, "db error")
+	}
+	return renderPage(c, http.StatusOK, "preview.html", page)
+}
@@ -0,0 +1,109 @@
+package link
+
+import (
+	"database/sql"
+	"net/http"
+	"strconv"
+
+	"github.com/labstack/echo/v4"
+	"go.uber.org/zap"
+
+	h "shotr/helpers"
+)
+
+// QR codes encode the short URL with ?src=qr so scans can be told apart
+// from ordinary clicks.
+const (
+	sourceParam = "src"
+	sourceQR    = "qr"
+)
+
+// clickSource returns the recorded source for a redirect request, or "" when
+// it didn't come through a known tracking variant of the short URL.
+func clickSource(c echo.Context) string {
+	if c.QueryParam(sourceParam) == sourceQR {
+		return sourceQR
+	}
+	return ""
+}
+
+// GET /api/v1/links/:slug/qr?format=png|svg&size=256&margin=4&level=M&fg=000000&bg=ffffff
+func (l *Link) QR(c echo.Context) error {
+	slug := c.Param("slug")
+	if slug == "" {
+		return h.JSONError(c, http.StatusBadRequest, "missing slug")
+	}
+
+	opt := h.QROptions{Size: 256, Margin: 4, Level: "M"}
+	var err error
+	if opt.Size, err = intParam(c, "size", opt.Size, 64, 2048); err != nil {
+		return h.JSONError(c, http.StatusBadRequest, "size must be between 64 and 2048")
+	}
+	if opt.Margin, err = intParam(c, "margin", opt.Margin, 0, 16); err != nil {
+		return h.JSONError(c, http.StatusBadRequest, "margin must be between 0 and 16")
+	}
+	if v := c.QueryParam("level"); v != "" {
+		opt.Level = v
+	}
+	if opt.Foreground, err = h.ParseHexColor(queryDefault(c, "fg", "000000")); err != nil {
+		return h.JSONError(c, http.StatusBadRequest, "fg must be a hex color like 000000")
+	}
+	if opt.Background, err = h.ParseHexColor(queryDefault(c, "bg", "ffffff")); err != nil {
+		return h.JSONError(c, http.StatusBadRequest, "bg must be a hex color like ffffff")
+	}
+
+	format := queryDefault(c, "format", "png")
+	if format != "png" && format != "svg" {
+		return h.JSONError(c, http.StatusBadRequest, "format must be png or svg")
+	}
+
+	ctx := c.Request().Context()
+	_, slug, err = findSlug(ctx, slug, l.Store.GetLink)
+	if err == sql.ErrNoRows {
+		return h.JSONError(c, http.StatusNotFound, "not found")
+	} else if err != nil {
+		l.Log.Error("db lookup failed", zap.Error(err))
+		return h.JSONError(c, http.StatusInternalServerError, "db error")
+	}
+
+	content := h.BuildShortURL(c, l.BaseHost, slug) + "?" + sourceParam + "=" + sourceQR
+
+	var body []byte
+	var contentType string
+	if format == "svg" {
+		body, err = h.QRSVG(content, opt)
+		contentType = "image/svg+xml"
+	} else {
+		body, err = h.QRPNG(content, opt)
+		contentType = "image/png"
+	}
+	if err != nil {
+		return h.JSONError(c, http.StatusBadRequest, err.Error())
+	}
+
+	c.Response().Header().Set("Cache-Control", "public, max-age=86400")
+	return c.Blob(http.StatusOK, contentType, body)
+}
+
+func queryDefault(c echo.Context, name, def string) string {
+	if v := c.QueryParam(name); v != "" {
+		return v
+	}
+	return def
+}
+
+// intParam parses an optional integer query parameter within [min, max].
+func intParam(c echo.Context, name string, def, min, max int) (int, error) {
+	v := c.QueryParam(name)
+	if v == "" {
+		return def, nil
+	}
+	n, err := strconv.Atoi(v)
+	if err != nil {
+		return 0, err
+	}
+	if n < min || n > max {
+		return 0, strconv.ErrRange
+	}
+	return n, nil
+}
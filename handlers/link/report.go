@@ -0,0 +1,60 @@
+package link
+
+import (
+	"database/sql"
+	"net/http"
+
+	"github.com/labstack/echo/v4"
+	"go.uber.org/zap"
+
+	"shotr/db"
+	h "shotr/helpers"
+)
+
+// Report statuses stored in reports.status.
+const (
+	reportOpen      = "open"
+	reportActioned  = "actioned"
+	reportDismissed = "dismissed"
+)
+
+// POST /:slug/report
+func (l *Link) Report(c echo.Context) error {
+	slug := c.Param("slug")
+	if slug == "" {
+		return h.JSONError(c, http.StatusBadRequest, "missing slug")
+	}
+
+	var req struct {
+		Reason  string `json:"reason" form:"reason" validate:"required,oneof=phishing malware spam other"`
+		Details string `json:"details" form:"details" validate:"max=2000"`
+		Contact string `json:"contact" form:"contact" validate:"omitempty,email,max=254"`
+	}
+	if err := h.BindAndValidate(c, &req); err != nil {
+		return h.JSONError(c, http.StatusBadRequest, err.Error())
+	}
+
+	ctx := c.Request().Context()
+	_, slug, err := findSlug(ctx, slug, l.Store.GetLink)
+	if err == sql.ErrNoRows {
+		return h.JSONError(c, http.StatusNotFound, "not found")
+	} else if err != nil {
+		l.Log.Error("db lookup failed", zap.Error(err))
+		return h.JSONError(c, http.StatusInternalServerError, "db error")
+	}
+
+	report, err := l.Store.AddReport(ctx, db.AddReportParams{
+		Slug:       slug,
+		Reason:     req.Reason,
+		Details:    sql.NullString{String: req.Details, Valid: req.Details != ""},
+		Contact:    sql.NullString{String: req.Contact, Valid: req.Contact != ""},
+		ReporterIp: sql.NullString{String: h.ClientIP(c), Valid: true},
+	})
+	if err != nil {
+		l.Log.Error("failed to save report", zap.Error(err))
+		return h.JSONError(c, http.StatusInternalServerError, "couldn't save report")
+	}
+	l.Log.Info("link reported", zap.String("slug", slug), zap.String("reason", req.Reason), zap.Int64("report_id", report.ID))
+
+	return h.JSONSuccess(c, http.StatusAccepted, map[string]any{"id": report.ID}, "report received")
+}
@@ -0,0 +1,79 @@
+package link
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+	"sort"
+	"time"
+
+	"shotr/db"
+	"shotr/store"
+)
+
+// scheduleWindow is one entry of a link's schedule as accepted by Create.
+// A nil Start or End leaves that side of the window open.
+type scheduleWindow struct {
+	Start *time.Time `json:"start"`
+	End   *time.Time `json:"end"`
+	URL   string     `json:"url" validate:"required,url"`
+}
+
+// validateSchedule rejects empty or inverted windows and windows that
+// overlap each other. It sorts windows by start as a side effect.
+func validateSchedule(windows []scheduleWindow) error {
+	for _, w := range windows {
+		if w.Start != nil && w.End != nil && !w.Start.Before(*w.End) {
+			return errors.New("schedule window must start before it ends")
+		}
+	}
+	sort.SliceStable(windows, func(i, j int) bool {
+		if windows[i].Start == nil {
+			return windows[j].Start != nil
+		}
+		return windows[j].Start != nil && windows[i].Start.Before(*windows[j].Start)
+	})
+	for i := 1; i < len(windows); i++ {
+		prev, cur := windows[i-1], windows[i]
+		// windows are half-open, so prev may end exactly where cur starts
+		if prev.End == nil || cur.Start == nil || prev.End.After(*cur.Start) {
+			return errors.New("schedule windows overlap")
+		}
+	}
+	return nil
+}
+
+func addSchedule(ctx context.Context, q store.Store, slug string, windows []scheduleWindow) error {
+	for _, w := range windows {
+		params := db.AddLinkScheduleParams{Slug: slug, Url: w.URL}
+		if w.Start != nil {
+			params.StartsAt = sql.NullTime{Time: w.Start.UTC(), Valid: true}
+		}
+		if w.End != nil {
+			params.EndsAt = sql.NullTime{Time: w.End.UTC(), Valid: true}
+		}
+		if err := q.AddLinkSchedule(ctx, params); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// activeWindow returns the URL of the window containing now (ok is false
+// when now falls in a gap) and the next instant at which that answer can
+// change, which is zero if it never will.
+func activeWindow(windows []db.LinkSchedule, now time.Time) (url string, ok bool, next time.Time) {
+	for _, w := range windows {
+		started := !w.StartsAt.Valid || !now.Before(w.StartsAt.Time)
+		ended := w.EndsAt.Valid && !now.Before(w.EndsAt.Time)
+		if started && !ended {
+			url, ok = w.Url, true
+		}
+		for _, b := range []sql.NullTime{w.StartsAt, w.EndsAt} {
+			if b.Valid && b.Time.After(now) && (next.IsZero() || b.Time.Before(next)) {
+				next = b.Time
+			}
+		}
+	}
+	return url, ok, next
+}
@@ -0,0 +1,24 @@
+package link
+
+import (
+	"net/http"
+	"strings"
+
+	"github.com/labstack/echo/v4"
+
+	h "shotr/helpers"
+)
+
+// CheckSignature rejects slugs shaped like signed slugs whose signature
+// doesn't verify, before the handler touches the database. Guessing a
+// signed slug then costs a 404 and no query. Unsigned slugs pass through,
+// and signed nocase slugs verify in any case.
+func (l *Link) CheckSignature(next echo.HandlerFunc) echo.HandlerFunc {
+	return func(c echo.Context) error {
+		slug := strings.TrimSuffix(c.Param("slug"), previewSuffix)
+		if h.IsSigned(slug) && !l.Keyring.Verify(slug) {
+			return h.JSONError(c, http.StatusNotFound, "not found")
+		}
+		return next(c)
+	}
+}
@@ -0,0 +1,171 @@
+package link
+
+import (
+	"context"
+	"errors"
+	"net/http"
+	"regexp"
+	"strings"
+
+	"github.com/labstack/echo/v4"
+	"go.uber.org/zap"
+
+	"shotr/db"
+	h "shotr/helpers"
+	"shotr/store"
+)
+
+// sequentialTries bounds how many ids a sequential create may burn looking
+// for one whose slug passes the filter.
+const sequentialTries = 10
+
+var (
+	errSlugTaken = errors.New("slug is already taken")
+
+	vanityPattern = regexp.MustCompile(`^[A-Za-z0-9]+(-[A-Za-z0-9]+)*$`)
+	// first path segments that belong to the app, not to links
+	reservedSlugs = map[string]bool{"api": true, "healthz": true, "admin": true}
+)
+
+// initSizers counts the existing links and sets up a SlugSizer for every
+// strategy whose slugs can grow.
+func (l *Link) initSizers() {
+	n, err := l.Store.CountLinks(context.Background())
+	if err != nil {
+		// occupancy catches up as links are created
+		l.Log.Warn("failed to count links", zap.Error(err))
+	}
+	l.linkCount.Store(n)
+
+	l.Sizers = make(map[string]*h.SlugSizer)
+	for name, s := range l.Slugs {
+		if sized, ok := s.(h.SizedSlugStrategy); ok {
+			l.Sizers[name] = h.NewSlugSizer(name, sized, &l.linkCount, l.Log)
+		}
+	}
+}
+
+// insertLink adds the links row with a slug from the named strategy, or the
+// deployment default when name is empty. Strategies that derive the slug
+// from the row id get it assigned after the insert, in the same transaction.
+func (l *Link) insertLink(ctx context.Context, q store.Store, arg db.AddLinkParams, name string, signed bool) (db.Link, error) {
+	if name == "" {
+		name = l.DefaultSlug
+	}
+	strategy := l.Slugs[name]
+	sizer := l.Sizers[name]
+	sign := l.Keyring.Sign
+	if name == h.SlugNocase {
+		sign = l.Keyring.SignNocase
+	}
+
+	gen := func(attempt int) (string, error) {
+		slug, err := l.SlugFilter.Generate(func() (string, error) {
+			if sizer != nil {
+				return sizer.Next(attempt)
+			}
+			return strategy.Generate()
+		})
+		if err != nil || !signed {
+			return slug, err
+		}
+		return sign(slug), nil
+	}
+	link, collisions, err := h.TryInsertWithRetry(ctx, q, arg, gen, 5, l.Log)
+	if err != nil {
+		return link, err
+	}
+	// counted before commit; a rolled back create only makes the sizer
+	// slightly more cautious
+	l.linkCount.Add(1)
+	if sizer != nil {
+		sizer.Record(collisions)
+	}
+
+	if ids, ok := strategy.(h.IDSlugStrategy); ok {
+		return l.assignIDSlug(ctx, q, link, ids, gen, signed)
+	}
+	return link, nil
+}
+
+// assignIDSlug replaces the placeholder slug with the one derived from the
+// row id. When that slug is refused, by the filter or because a vanity
+// link already has it, the row is deleted and inserted again;
+// AUTOINCREMENT never hands out a deleted id twice, so the next id gets its
+// chance.
+func (l *Link) assignIDSlug(ctx context.Context, q store.Store, link db.Link, ids h.IDSlugStrategy, gen func(int) (string, error), signed bool) (db.Link, error) {
+	for i := 0; ; i++ {
+		last := i+1 >= sequentialTries
+		if slug := ids.FromID(link.ID); last || l.SlugFilter.Allowed(slug) {
+			if signed {
+				slug = l.Keyring.Sign(slug)
+			}
+			// in a savepoint, so a taken slug leaves the transaction usable
+			err := q.WithTx(ctx, func(tx store.Store) error {
+				return tx.UpdateLinkSlug(ctx, db.UpdateLinkSlugParams{Slug: slug, ID: link.ID})
+			})
+			if err == nil {
+				link.Slug = slug
+				return link, nil
+			}
+			if last || !h.IsUniqueConstraint(err) {
+				return link, err
+			}
+			l.Log.Debug("sequential slug already taken", zap.String("slug", slug))
+		}
+
+		if err := q.DeleteLinkByID(ctx, link.ID); err != nil {
+			return link, err
+		}
+		var err error
+		if link, _, err = h.TryInsertWithRetry(ctx, q, db.AddLinkParams{Url: link.Url, User: link.User}, gen, 5, l.Log); err != nil {
+			return link, err
+		}
+	}
+}
+
+// checkVanity applies the format rules and the slug filter to a slug the
+// client picked.
+func (l *Link) checkVanity(slug string) error {
+	if !vanityPattern.MatchString(slug) {
+		return errors.New("slug may only contain letters, digits and single dashes")
+	}
+	if reservedSlugs[strings.ToLower(slug)] {
+		return errors.New("slug is reserved")
+	}
+	if l.SlugFilter.Offensive(slug) {
+		return errors.New("slug contains a blocked word")
+	}
+	if h.Confusing(slug) {
+		return errors.New("slug mixes characters that are easy to confuse, such as 0 and O")
+	}
+	return nil
+}
+
+// insertVanity adds the links row with the client-picked arg.Slug.
+func (l *Link) insertVanity(ctx context.Context, q store.Store, arg db.AddLinkParams) (db.Link, error) {
+	link, err := q.AddLink(ctx, arg)
+	if h.IsUniqueConstraint(err) {
+		return link, errSlugTaken
+	}
+	if err != nil {
+		return link, err
+	}
+	l.linkCount.Add(1)
+	return link, nil
+}
+
+// GET /api/v1/admin/slugs
+func (l *Link) SlugMetrics(c echo.Context) error {
+	metrics := make([]h.SlugMetrics, 0, len(l.Sizers))
+	for _, name := range []string{h.SlugNanoid, h.SlugWords, h.SlugNocase} {
+		if z := l.Sizers[name]; z != nil {
+			metrics = append(metrics, z.Metrics())
+		}
+	}
+	return h.JSONSuccess(c, http.StatusOK, map[string]any{
+		"default":    l.DefaultSlug,
+		"links":      l.linkCount.Load(),
+		"strategies": metrics,
+	}, "")
+}
@@ -0,0 +1,87 @@
+package link
+
+import (
+	"context"
+	"net/http"
+	"testing"
+
+	"shotr/config"
+	"shotr/db"
+	h "shotr/helpers"
+)
+
+func TestVanitySlugChecks(t *testing.T) {
+	s := newTestServer(t)
+	cases := []struct {
+		slug string
+		want int
+	}{
+		{"summer-sale", http.StatusCreated},
+		{"fuckyou", http.StatusBadRequest},
+		{"Sh1t-happens", http.StatusBadRequest},
+		{"O0-test", http.StatusBadRequest},
+		{"l1nk", http.StatusBadRequest},
+		{"double--dash", http.StatusBadRequest},
+		{"api", http.StatusBadRequest},
+	}
+	for _, tc := range cases {
+		t.Run(tc.slug, func(t *testing.T) {
+			rec := s.do(t, http.MethodPost, "/api/v1/links", aliceKey, map[string]any{"url": "https://example.com/", "slug": tc.slug})
+			if rec.Code != tc.want {
+				t.Errorf("status = %d %s, want %d", rec.Code, rec.Body, tc.want)
+			}
+		})
+	}
+}
+
+func TestSequentialSlugSkipsTakenSlug(t *testing.T) {
+	s := newTestServer(t, func(cfg *config.Config) { cfg.SlugStrategy = h.SlugSequential })
+	seq := s.link.Slugs[h.SlugSequential].(h.IDSlugStrategy)
+	ctx := context.Background()
+
+	if slug := s.create(t, aliceKey, map[string]any{"url": "https://example.com/1"}); slug != seq.FromID(1) {
+		t.Fatalf("first link got %q, want %q", slug, seq.FromID(1))
+	}
+	// a vanity link, row 2, already holds the slug row 3 would get
+	if !s.link.SlugFilter.Allowed(seq.FromID(3)) {
+		t.Fatalf("the filter refuses %q; pick ids it allows", seq.FromID(3))
+	}
+	if _, err := s.st.AddLink(ctx, db.AddLinkParams{Slug: seq.FromID(3), Url: "https://example.com/vanity"}); err != nil {
+		t.Fatal(err)
+	}
+
+	rec := s.do(t, http.MethodPost, "/api/v1/links", aliceKey, map[string]any{"url": "https://example.com/3"})
+	if rec.Code != http.StatusCreated {
+		t.Fatalf("create: %d %s", rec.Code, rec.Body)
+	}
+	body := decode(t, rec)
+	id := int64(body["id"].(float64))
+	if id <= 3 || body["slug"] != seq.FromID(id) {
+		t.Errorf("created %v, want a later row with its own sequential slug", body)
+	}
+	if link, err := s.st.GetLink(ctx, seq.FromID(3)); err != nil || link.Url != "https://example.com/vanity" {
+		t.Errorf("the vanity link became %+v, %v", link, err)
+	}
+	if n, _ := s.st.CountLinks(ctx); n != 3 {
+		t.Errorf("%d links, want 3 with no leftover placeholder", n)
+	}
+}
+
+func TestSlugMetrics(t *testing.T) {
+	s := newTestServer(t)
+	for i := 0; i < 3; i++ {
+		s.create(t, aliceKey, map[string]any{"url": "https://example.com/"})
+	}
+	for _, name := range []string{h.SlugNanoid, h.SlugWords, h.SlugNocase} {
+		m := s.link.Sizers[name].Metrics()
+		if m.Links != 3 {
+			t.Errorf("%s sizer counts %d links, want 3", name, m.Links)
+		}
+	}
+	if m := s.link.Sizers[h.SlugNanoid].Metrics(); m.Created != 3 || m.Length != 7 {
+		t.Errorf("nanoid sizer = %+v, want 3 created at length 7", m)
+	}
+	if _, ok := s.link.Sizers[h.SlugSequential]; ok {
+		t.Error("sequential slugs have a sizer")
+	}
+}
@@ -0,0 +1,99 @@
+package link
+
+import (
+	"context"
+	"strings"
+
+	"github.com/labstack/echo/v4"
+
+	"shotr/db"
+	h "shotr/helpers"
+	"shotr/store"
+)
+
+// Target kinds stored in link_targets.kind.
+const (
+	targetDevice   = "device"
+	targetCountry  = "country"
+	targetLanguage = "language"
+)
+
+// languageDefault is recorded when a link has language mappings but none of
+// them matched the visitor's Accept-Language.
+const languageDefault = "default"
+
+// visitor holds the request attributes targeting rules are matched against.
+type visitor struct {
+	Platform       string
+	Country        string
+	AcceptLanguage string
+}
+
+// choice is the outcome of pick: where to send the visitor and which
+// variant or language mapping (if any) produced that URL.
+type choice struct {
+	URL      string
+	Variant  string
+	Language string
+}
+
+func (l *Link) newVisitor(c echo.Context) visitor {
+	return visitor{
+		Platform:       h.DetectPlatform(c.Request().UserAgent()),
+		Country:        l.Geo.Country(c),
+		AcceptLanguage: c.Request().Header.Get("Accept-Language"),
+	}
+}
+
+// pick decides where this visitor should be sent. Rules are checked in
+// order device, country, language; split variants only apply when no
+// targeting rule matched, and the link's own URL is the final fallback.
+func (d *destination) pick(c echo.Context, v visitor) choice {
+	if rules, ok := d.Targets[targetDevice]; ok {
+		c.Response().Header().Add("Vary", "User-Agent")
+		if u, ok := rules[v.Platform]; ok {
+			return choice{URL: u}
+		}
+	}
+	if u, ok := d.Targets[targetCountry][v.Country]; ok {
+		return choice{URL: u}
+	}
+	if rules, ok := d.Targets[targetLanguage]; ok {
+		c.Response().Header().Add("Vary", "Accept-Language")
+		tags := make([]string, 0, len(rules))
+		for tag := range rules {
+			tags = append(tags, tag)
+		}
+		if tag, ok := h.NegotiateLanguage(v.AcceptLanguage, tags); ok {
+			return choice{URL: rules[tag], Language: tag}
+		}
+		return choice{URL: d.URL, Language: languageDefault}
+	}
+	if len(d.Variants) > 0 {
+		vr := chooseVariant(d.Variants, d.Slug, visitorID(c))
+		return choice{URL: vr.Url, Variant: vr.Name}
+	}
+	return choice{URL: d.URL}
+}
+
+// addTargets stores one rule per entry of rules (match value -> URL) under
+// the given kind.
+func addTargets(ctx context.Context, q store.Store, slug, kind string, rules map[string]string) error {
+	for value, url := range rules {
+		switch kind {
+		case targetCountry:
+			value = strings.ToUpper(value)
+		case targetLanguage:
+			value = strings.ToLower(value)
+		}
+		if err := q.AddLinkTarget(ctx, db.AddLinkTargetParams{
+			Slug:  slug,
+			Kind:  kind,
+			Value: value,
+			Url:   url,
+		}); err != nil {
+			return err
+		}
+	}
+	return nil
+}
@@ -0,0 +1,109 @@
+package link
+
+import (
+	"crypto/hmac"
+	"crypto/sha256"
+	"database/sql"
+	"encoding/base64"
+	"net/http"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/labstack/echo/v4"
+	"go.uber.org/zap"
+	"golang.org/x/crypto/bcrypt"
+
+	h "shotr/helpers"
+)
+
+const (
+	unlockCookiePrefix = "shotr_unlock_"
+	unlockTTL          = time.Hour
+)
+
+type unlockPage struct {
+	Slug  string
+	Error string
+}
+
+// unlockSignature authenticates "slug|expiry" with the server's unlock secret.
+func (l *Link) unlockSignature(slug string, exp int64) string {
+	mac := hmac.New(sha256.New, l.UnlockSecret)
+	mac.Write([]byte(slug + "|" + strconv.FormatInt(exp, 10)))
+	return base64.RawURLEncoding.EncodeToString(mac.Sum(nil))
+}
+
+// unlocked reports whether the request carries a valid, unexpired unlock
+// cookie for slug.
+func (l *Link) unlocked(c echo.Context, slug string) bool {
+	ck, err := c.Cookie(unlockCookiePrefix + slug)
+	if err != nil {
+		return false
+	}
+	expStr, sig, ok := strings.Cut(ck.Value, ".")
+	if !ok {
+		return false
+	}
+	exp, err := strconv.ParseInt(expStr, 10, 64)
+	if err != nil || time.Now().Unix() >= exp {
+		return false
+	}
+	return hmac.Equal([]byte(sig), []byte(l.unlockSignature(slug, exp)))
+}
+
+func (l *Link) setUnlockCookie(c echo.Context, slug string) {
+	exp := time.Now().Add(unlockTTL)
+	c.SetCookie(&http.Cookie{
+		Name:     unlockCookiePrefix + slug,
+		Value:    strconv.FormatInt(exp.Unix(), 10) + "." + l.unlockSignature(slug, exp.Unix()),
+		Path:     "/" + slug,
+		Expires:  exp,
+		HttpOnly: true,
+		Secure:   c.Scheme() == "https",
+		SameSite: http.SameSiteLaxMode,
+	})
+}
+
+// POST /:slug
+func (l *Link) Unlock(c echo.Context) error {
+	slug := c.Param("slug")
+	if slug == "" {
+		return h.JSONError(c, http.StatusBadRequest, "missing slug")
+	}
+
+	ctx := c.Request().Context()
+	dest, _, err := l.resolveURL(ctx, slug)
+	if err == sql.ErrNoRows {
+		return h.JSONError(c, http.StatusNotFound, "not found")
+	}
+	if err != nil {
+		l.Log.Error("db lookup failed", zap.Error(err))
+		return h.JSONError(c, http.StatusInternalServerError, "db error")
+	}
+	slug = dest.Slug
+	if dest.TakedownStatus != 0 {
+		return renderTakedown(c, dest)
+	}
+	if dest.PasswordHash == "" {
+		return c.Redirect(http.StatusSeeOther, "/"+slug)
+	}
+
+	if !l.unlockLimiter.Allow(slug + "|" + h.ClientIP(c)) {
+		c.Response().Header().Set("Retry-After", "60")
+		return renderPage(c, http.StatusTooManyRequests, "unlock.html", unlockPage{
+			Slug:  slug,
+			Error: "Too many attempts. Try again in a minute.",
+		})
+	}
+
+	if err := bcrypt.CompareHashAndPassword([]byte(dest.PasswordHash), []byte(c.FormValue("password"))); err != nil {
+		return renderPage(c, http.StatusUnauthorized, "unlock.html", unlockPage{
+			Slug:  slug,
+			Error: "Incorrect password.",
+		})
+	}
+
+	l.setUnlockCookie(c, slug)
+	return c.Redirect(http.StatusSeeOther, "/"+slug)
+}
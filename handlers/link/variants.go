@@ -0,0 +1,58 @@
+package link
+
+import (
+	"crypto/rand"
+	"encoding/hex"
+	"hash/fnv"
+	"net/http"
+	"time"
+
+	"github.com/labstack/echo/v4"
+
+	"shotr/db"
+)
+
+// visitorCookie carries a random per-browser id so split assignments stick.
+const visitorCookie = "shotr_vid"
+
+// visitorID returns the caller's visitor id, issuing a new cookie if they
+// don't have one yet.
+func visitorID(c echo.Context) string {
+	if ck, err := c.Cookie(visitorCookie); err == nil && ck.Value != "" {
+		return ck.Value
+	}
+	b := make([]byte, 16)
+	_, _ = rand.Read(b)
+	id := hex.EncodeToString(b)
+	c.SetCookie(&http.Cookie{
+		Name:     visitorCookie,
+		Value:    id,
+		Path:     "/",
+		Expires:  time.Now().AddDate(1, 0, 0),
+		HttpOnly: true,
+		SameSite: http.SameSiteLaxMode,
+	})
+	return id
+}
+
+// chooseVariant maps a visitor onto one of the variants in proportion to
+// their weights. The same visitor always lands on the same variant for a
+// given slug as long as the variant set doesn't change.
+func chooseVariant(variants []db.LinkVariant, slug, vid string) db.LinkVariant {
+	var total uint64
+	for _, v := range variants {
+		total += uint64(v.Weight)
+	}
+	hsh := fnv.New64a()
+	hsh.Write([]byte(slug))
+	hsh.Write([]byte{0})
+	hsh.Write([]byte(vid))
+	n := hsh.Sum64() % total
+	for _, v := range variants {
+		if n < uint64(v.Weight) {
+			return v
+		}
+		n -= uint64(v.Weight)
+	}
+	return variants[len(variants)-1]
+}
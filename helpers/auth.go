@@ -0,0 +1,99 @@
+package helpers
+
+import (
+	"crypto/subtle"
+	"errors"
+	"net/http"
+	"strings"
+
+	"github.com/labstack/echo/v4"
+)
+
+// RequireBearer rejects requests that don't carry "Authorization: Bearer <token>".
+func RequireBearer(token string) echo.MiddlewareFunc {
+	return func(next echo.HandlerFunc) echo.HandlerFunc {
+		return func(c echo.Context) error {
+			got, ok := strings.CutPrefix(c.Request().Header.Get("Authorization"), "Bearer ")
+			if !ok || subtle.ConstantTimeCompare([]byte(got), []byte(token)) != 1 {
+				return JSONError(c, http.StatusUnauthorized, "unauthorized")
+			}
+			return next(c)
+		}
+	}
+}
+
+// APIKeyName is the echo context key RequireAPIKey stores the caller's key
+// name under.
+const APIKeyName = "api_key"
+
+// ParseAPIKeys parses "name:secret,..." into a secret -> name map. Secrets
+// must be at least 16 characters.
+func ParseAPIKeys(spec string) (map[string]string, error) {
+	if strings.TrimSpace(spec) == "" {
+		return nil, nil
+	}
+	keys := make(map[string]string)
+	for _, part := range strings.Split(spec, ",") {
+		name, secret, ok := strings.Cut(strings.TrimSpace(part), ":")
+		if !ok || name == "" {
+			return nil, errors.New("API_KEYS entries must look like name:secret")
+		}
+		if len(secret) < 16 {
+			return nil, errors.New("API_KEYS secrets must be at least 16 characters")
+		}
+		if _, dup := keys[secret]; dup {
+			return nil, errors.New("API_KEYS has a repeated secret")
+		}
+		keys[secret] = name
+	}
+	return keys, nil
+}
+
+// AdminName is the echo context key RequireKeyOrBearer sets to true when the
+// caller used the admin token.
+const AdminName = "admin"
+
+// RequireKeyOrBearer accepts either one of keys, recorded as RequireAPIKey
+// does, or the admin token, recorded under AdminName. An empty adminToken
+// never matches.
+func RequireKeyOrBearer(keys map[string]string, adminToken string) echo.MiddlewareFunc {
+	return func(next echo.HandlerFunc) echo.HandlerFunc {
+		return func(c echo.Context) error {
+			got, ok := strings.CutPrefix(c.Request().Header.Get("Authorization"), "Bearer ")
+			if !ok {
+				return JSONError(c, http.StatusUnauthorized, "unauthorized")
+			}
+			if adminToken != "" && subtle.ConstantTimeCompare([]byte(got), []byte(adminToken)) == 1 {
+				c.Set(AdminName, true)
+				return next(c)
+			}
+			for secret, name := range keys {
+				if subtle.ConstantTimeCompare([]byte(got), []byte(secret)) == 1 {
+					c.Set(APIKeyName, name)
+					return next(c)
+				}
+			}
+			return JSONError(c, http.StatusUnauthorized, "unauthorized")
+		}
+	}
+}
+
+// RequireAPIKey rejects requests whose bearer token isn't one of keys and
+// records the key's name in the context for quotas.
+func RequireAPIKey(keys map[string]string) echo.MiddlewareFunc {
+	return func(next echo.HandlerFunc) echo.HandlerFunc {
+		return func(c echo.Context) error {
+			got, ok := strings.CutPrefix(c.Request().Header.Get("Authorization"), "Bearer ")
+			if !ok {
+				return JSONError(c, http.StatusUnauthorized, "unauthorized")
+			}
+			for secret, name := range keys {
+				if subtle.ConstantTimeCompare([]byte(got), []byte(secret)) == 1 {
+					c.Set(APIKeyName, name)
+					return next(c)
+				}
+			}
+			return JSONError(c, http.StatusUnauthorized, "unauthorized")
+		}
+	}
+}
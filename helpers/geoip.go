@@ -0,0 +1,53 @@
+package helpers
+
+import (
+	"net"
+
+	"github.com/labstack/echo/v4"
+	"github.com/oschwald/maxminddb-golang"
+)
+
+// GeoIP looks up client countries in a MaxMind-format (.mmdb) database.
+// A nil *GeoIP is valid and resolves every address to "".
+type GeoIP struct {
+	r *maxminddb.Reader
+}
+
+type geoRecord struct {
+	Country struct {
+		ISOCode string `maxminddb:"iso_code"`
+	} `maxminddb:"country"`
+}
+
+// OpenGeoIP memory-maps the database at path.
+func OpenGeoIP(path string) (*GeoIP, error) {
+	r, err := maxminddb.Open(path)
+	if err != nil {
+		return nil, err
+	}
+	return &GeoIP{r: r}, nil
+}
+
+// Country returns the ISO 3166-1 alpha-2 code for the requesting client, or
+// "" when it can't be determined.
+func (g *GeoIP) Country(c echo.Context) string {
+	if g == nil {
+		return ""
+	}
+	ip := net.ParseIP(ClientIP(c))
+	if ip == nil {
+		return ""
+	}
+	var rec geoRecord
+	if err := g.r.Lookup(ip, &rec); err != nil {
+		return ""
+	}
+	return rec.Country.ISOCode
+}
+
+func (g *GeoIP) Close() error {
+	if g == nil {
+		return nil
+	}
+	return g.r.Close()
+}
@@ -0,0 +1,73 @@
+package helpers
+
+import (
+	"net"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/labstack/echo/v4"
+)
+
+// testGeoDB is MaxMind's test database, cut down to a few networks:
+// 2.125.160.0/24 DE, 81.2.69.0/24 GB, 89.160.20.0/24 SE,
+// 216.160.83.0/24 US and 2001:218::/32 JP.
+const testGeoDB = "../testdata/GeoIP2-Country-Test.mmdb"
+
+func TestGeoIPCountry(t *testing.T) {
+	geo, err := OpenGeoIP(testGeoDB)
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer geo.Close()
+
+	_, proxies, _ := net.ParseCIDR("10.0.0.0/8")
+	direct := echo.ExtractIPDirect()
+	behindProxy := echo.ExtractIPFromXFFHeader(
+		echo.TrustLoopback(false), echo.TrustLinkLocal(false), echo.TrustPrivateNet(false),
+		echo.TrustIPRange(proxies),
+	)
+
+	cases := []struct {
+		name       string
+		extractor  echo.IPExtractor
+		remoteAddr string
+		xff        string
+		want       string
+	}{
+		{"ipv4", direct, "81.2.69.160:4321", "", "GB"},
+		{"ipv6", direct, "[2001:218::1]:4321", "", "JP"},
+		{"not in the database", direct, "127.0.0.1:4321", "", ""},
+		{"forwarded header ignored without proxies", direct, "89.160.20.112:4321", "81.2.69.160", "SE"},
+		{"trusted proxy", behindProxy, "10.1.2.3:4321", "89.160.20.112", "SE"},
+		{"trusted proxy chain", behindProxy, "10.1.2.3:4321", "81.2.69.160, 10.9.9.9", "GB"},
+		{"untrusted peer can't spoof", behindProxy, "216.160.83.56:4321", "81.2.69.160", "US"},
+	}
+	for _, tc := range cases {
+		t.Run(tc.name, func(t *testing.T) {
+			e := echo.New()
+			e.IPExtractor = tc.extractor
+			req := httptest.NewRequest(http.MethodGet, "/abc", nil)
+			req.RemoteAddr = tc.remoteAddr
+			if tc.xff != "" {
+				req.Header.Set(echo.HeaderXForwardedFor, tc.xff)
+			}
+			c := e.NewContext(req, httptest.NewRecorder())
+			if got := geo.Country(c); got != tc.want {
+				t.Errorf("Country = %q, want %q", got, tc.want)
+			}
+		})
+	}
+}
+
+func TestNilGeoIP(t *testing.T) {
+	var geo *GeoIP
+	req := httptest.NewRequest(http.MethodGet, "/abc", nil)
+	req.RemoteAddr = "81.2.69.160:4321"
+	if got := geo.Country(echo.New().NewContext(req, httptest.NewRecorder())); got != "" {
+		t.Errorf("nil GeoIP Country = %q, want \"\"", got)
+	}
+	if err := geo.Close(); err != nil {
+		t.Errorf("nil GeoIP Close = %v", err)
+	}
+}
@@ -48,6 +48,10 @@ func JSONError(c echo.Context, code int, v any) error {
 	return c.JSON(code, map[string]string{"error": msg})
 }
 
+// JSONErrorCode is JSONError with a machine-readable code next to the message.
+func JSONErrorCode(c echo.Context, code int, errCode, msg string) error {
+	return c.JSON(code, map[string]string{"error": msg, "code": errCode})
+}
 
 func BindAndValidate(c echo.Context, v any) error {
 	if err := c.Bind(v); err != nil {
@@ -60,6 +64,15 @@ func BindAndValidate(c echo.Context, v any) error {
 	return nil
 }
 
+// Validate checks v's validate tags, with the same generic error as
+// BindAndValidate, for bodies that aren't bound in one go.
+func Validate(v any) error {
+	if err := validate.Struct(v); err != nil {
+		return fmt.Errorf("missing or invalid fields")
+	}
+	return nil
+}
+
 func BuildShortURL(c echo.Context, baseHost, slug string) string {
 	if baseHost != "" {
 		return fmt.Sprintf("%s/%s", trimSuffix(baseHost, "/"), slug)
@@ -0,0 +1,86 @@
+package helpers
+
+import (
+	"sort"
+	"strconv"
+	"strings"
+)
+
+type langRange struct {
+	tag string
+	q   float64
+}
+
+// parseAcceptLanguage returns the language ranges in an Accept-Language
+// header ordered by descending q-value, keeping header order for ties.
+// Ranges with q=0 or a malformed q are dropped.
+func parseAcceptLanguage(header string) []langRange {
+	var out []langRange
+	for _, part := range strings.Split(header, ",") {
+		fields := strings.Split(part, ";")
+		tag := strings.ToLower(strings.TrimSpace(fields[0]))
+		if tag == "" {
+			continue
+		}
+		q := 1.0
+		for _, p := range fields[1:] {
+			p = strings.TrimSpace(p)
+			if v, ok := strings.CutPrefix(p, "q="); ok {
+				f, err := strconv.ParseFloat(v, 64)
+				if err != nil || f < 0 || f > 1 {
+					q = 0
+				} else {
+					q = f
+				}
+			}
+		}
+		if q == 0 {
+			continue
+		}
+		out = append(out, langRange{tag: tag, q: q})
+	}
+	sort.SliceStable(out, func(i, j int) bool { return out[i].q > out[j].q })
+	return out
+}
+
+// NegotiateLanguage picks the best of the available language tags for an
+// Accept-Language header. For each requested range, in preference order, it
+// tries an exact match, then any available tag the range is a prefix of
+// ("de" accepts "de-at"), then progressively shorter prefixes of the range
+// itself ("de-ch" falls back to "de"). A "*" range matches nothing so the
+// caller's default applies. Tags are compared case-insensitively.
+func NegotiateLanguage(header string, available []string) (string, bool) {
+	if header == "" || len(available) == 0 {
+		return "", false
+	}
+	avail := make([]string, len(available))
+	for i, a := range available {
+		avail[i] = strings.ToLower(a)
+	}
+	sort.Strings(avail)
+
+	for _, r := range parseAcceptLanguage(header) {
+		if r.tag == "*" {
+			continue
+		}
+		for _, a := range avail {
+			if a == r.tag {
+				return a, true
+			}
+		}
+		for _, a := range avail {
+			if strings.HasPrefix(a, r.tag+"-") {
+				return a, true
+			}
+		}
+		for tag := r.tag; strings.Contains(tag, "-"); {
+			tag = tag[:strings.LastIndex(tag, "-")]
+			for _, a := range avail {
+				if a == tag {
+					return a, true
+				}
+			}
+		}
+	}
+	return "", false
+}
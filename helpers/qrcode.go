@@ -0,0 +1,126 @@
+package helpers
+
+import (
+	"bytes"
+	"encoding/hex"
+	"fmt"
+	"image"
+	"image/color"
+	"image/png"
+	"strings"
+
+	qrcode "github.com/skip2/go-qrcode"
+)
+
+// QROptions controls how a QR code is drawn. Size is the image width and
+// height in pixels (SVG uses it for width/height, the viewBox is in
+// modules), Margin is the quiet zone in modules and Level is one of
+// "L", "M", "Q" or "H".
+type QROptions struct {
+	Size       int
+	Margin     int
+	Level      string
+	Foreground color.RGBA
+	Background color.RGBA
+}
+
+var qrLevels = map[string]qrcode.RecoveryLevel{
+	"L": qrcode.Low,
+	"M": qrcode.Medium,
+	"Q": qrcode.High,
+	"H": qrcode.Highest,
+}
+
+// ParseHexColor parses "rrggbb" (with or without a leading '#').
+func ParseHexColor(s string) (color.RGBA, error) {
+	b, err := hex.DecodeString(strings.TrimPrefix(s, "#"))
+	if err != nil || len(b) != 3 {
+		return color.RGBA{}, fmt.Errorf("invalid color %q", s)
+	}
+	return color.RGBA{R: b[0], G: b[1], B: b[2], A: 0xff}, nil
+}
+
+// qrModules encodes content and returns its modules, including a quiet zone
+// of opt.Margin modules on every side.
+func qrModules(content string, opt QROptions) ([][]bool, error) {
+	level, ok := qrLevels[strings.ToUpper(opt.Level)]
+	if !ok {
+		return nil, fmt.Errorf("invalid error correction level %q", opt.Level)
+	}
+	q, err := qrcode.New(content, level)
+	if err != nil {
+		return nil, err
+	}
+	q.DisableBorder = true
+	bits := q.Bitmap()
+
+	n := len(bits) + 2*opt.Margin
+	out := make([][]bool, n)
+	for y := range out {
+		out[y] = make([]bool, n)
+	}
+	for y, row := range bits {
+		copy(out[y+opt.Margin][opt.Margin:], row)
+	}
+	return out, nil
+}
+
+// QRPNG renders content as a PNG QR code. The image is never smaller than
+// one pixel per module; leftover pixels are split evenly around the code.
+func QRPNG(content string, opt QROptions) ([]byte, error) {
+	mods, err := qrModules(content, opt)
+	if err != nil {
+		return nil, err
+	}
+	n := len(mods)
+	size := max(opt.Size, n)
+	scale := size / n
+	offset := (size - n*scale) / 2
+
+	img := image.NewPaletted(image.Rect(0, 0, size, size), color.Palette{opt.Background, opt.Foreground})
+	for y, row := range mods {
+		for x, dark := range row {
+			if !dark {
+				continue
+			}
+			for dy := 0; dy < scale; dy++ {
+				for dx := 0; dx < scale; dx++ {
+					img.SetColorIndex(offset+x*scale+dx, offset+y*scale+dy, 1)
+				}
+			}
+		}
+	}
+
+	var buf bytes.Buffer
+	if err := png.Encode(&buf, img); err != nil {
+		return nil, err
+	}
+	return buf.Bytes(), nil
+}
+
+// QRSVG renders content as an SVG QR code with one path for all dark modules.
+func QRSVG(content string, opt QROptions) ([]byte, error) {
+	mods, err := qrModules(content, opt)
+	if err != nil {
+		return nil, err
+	}
+	n := len(mods)
+
+	var buf bytes.Buffer
+	fmt.Fprintf(&buf, `<svg xmlns="http://www.w3.org/2000/svg" width="%d" height="%d" viewBox="0 0 %d %d" shape-rendering="crispEdges">`, opt.Size, opt.Size, n, n)
+	fmt.Fprintf(&buf, `<rect width="%d" height="%d" fill="%s"/>`, n, n, hexColor(opt.Background))
+	fmt.Fprintf(&buf, `<path fill="%s" d="`, hexColor(opt.Foreground))
+	for y, row := range mods {
+		for x, dark := range row {
+			if dark {
+				fmt.Fprintf(&buf, "M%d %dh1v1h-1z", x, y)
+			}
+		}
+	}
+	buf.WriteString(`"/></svg>`)
+	return buf.Bytes(), nil
+}
+
+func hexColor(c color.RGBA) string {
+	return fmt.Sprintf("#%02x%02x%02x", c.R, c.G, c.B)
+}
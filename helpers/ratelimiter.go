@@ -32,6 +32,10 @@ func NewRateLimiter(max int, per time.Duration) *RateLimiter {
 		burst: max,
 		cleanupAfter: 3 * time.Minute,
 	}
+	// forgetting a client refills its bucket, so keep it at least a period
+	if per > rl.cleanupAfter {
+		rl.cleanupAfter = per
+	}
 
 	rl.once.Do(func() {
 		go rl.cleanupLoop()
@@ -41,7 +45,7 @@ func NewRateLimiter(max int, per time.Duration) *RateLimiter {
 
 func (rl *RateLimiter) Middleware(next echo.HandlerFunc) echo.HandlerFunc {
 	return func(c echo.Context) error {
-	key := clientIP(c)
+	key := ClientIP(c)
 	info := rl.getOrCreate(key)
 
 	atomic.StoreInt64(&info.lastSeen, time.Now().UnixNano())
@@ -63,6 +67,24 @@ func (rl *RateLimiter) Middleware(next echo.HandlerFunc) echo.HandlerFunc {
 	}
 }
 
+// Allow reports whether one more event for key fits within the limit. It is
+// for throttling by something other than the client IP, e.g. per-slug.
+func (rl *RateLimiter) Allow(key string) bool {
+	info := rl.getOrCreate(key)
+	atomic.StoreInt64(&info.lastSeen, time.Now().UnixNano())
+	return info.limiter.Allow()
+}
+
+// Refund gives back n events key was allowed but didn't get to use. The
+// bucket still never holds more than the limit.
+func (rl *RateLimiter) Refund(key string, n int) {
+	if n <= 0 {
+		return
+	}
+	info := rl.getOrCreate(key)
+	info.limiter.AllowN(time.Now(), -n)
+}
+
 func (rl *RateLimiter) getOrCreate(key string) *clientInfo {
 	now := time.Now().UnixNano()
 	if v, ok := rl.clients.Load(key); ok {
@@ -95,7 +117,9 @@ func (rl *RateLimiter) cleanupLoop() {
 	}
 }
 
-func clientIP(c echo.Context) string {
+// ClientIP returns the caller's IP address without a port, as echo's
+// IPExtractor sees it: forwarding headers only count from trusted proxies.
+func ClientIP(c echo.Context) string {
 	if ip := c.RealIP(); ip != "" {
 		if host, _, err := net.SplitHostPort(ip); err == nil {
 			return host
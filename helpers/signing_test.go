@@ -0,0 +1,38 @@
+package helpers
+
+import (
+	"strings"
+	"testing"
+)
+
+func TestSignedNocaseSlugVerifiesInAnyCase(t *testing.T) {
+	k, err := ParseKeyring("A:0123456789abcdef0123,a:fedcba98765432100123")
+	if err != nil {
+		t.Fatal(err)
+	}
+	slug := k.SignNocase("k7mq2x")
+	if slug != strings.ToLower(slug) || strings.Trim(slug[len("k7mq2x_a"):], nocaseAlphabet) != "" {
+		t.Fatalf("SignNocase = %q, want it all in the nocase alphabet", slug)
+	}
+	for _, typed := range []string{slug, strings.ToUpper(slug), strings.ToUpper(slug[:4]) + slug[4:]} {
+		if !k.Verify(typed) {
+			t.Errorf("Verify(%q) = false", typed)
+		}
+		if folded, ok := FoldSlug(typed); typed != slug && (!ok || folded != slug) {
+			t.Errorf("FoldSlug(%q) = %q, %v, want %q", typed, folded, ok, slug)
+		}
+	}
+
+	base62 := k.Sign("k7mq2x")
+	if !k.Verify(base62) {
+		t.Errorf("Verify(%q) = false", base62)
+	}
+	if strings.ToUpper(base62) != base62 && k.Verify(strings.ToUpper(base62)) {
+		t.Errorf("Verify accepted %q typed in another case", base62)
+	}
+	tampered := []byte(slug)
+	tampered[len(tampered)-1] ^= 1
+	if k.Verify(string(tampered)) {
+		t.Errorf("Verify accepted tampered %q", tampered)
+	}
+}
@@ -1,10 +1,197 @@
 package helpers
 
-import gonanoid "github.com/matoous/go-nanoid/v2"
+import (
+	"crypto/rand"
+	"hash/fnv"
+	"math"
+	"math/big"
+	"strings"
+
+	gonanoid "github.com/matoous/go-nanoid/v2"
+)
 
 const alphabet = "0123456789abcdefghijklmnopqrstuvwxyzABCDEFGHIJKLMNOPQRSTUVWXYZ"
 const length = 7
 
+// nocaseAlphabet drops upper case and the look-alikes 0/o and 1/i/l so a
+// slug survives being read aloud or retyped.
+const nocaseAlphabet = "23456789abcdefghjkmnpqrstuvwxyz"
+
+// Slug strategy names, as used by SLUG_STRATEGY and the slug_strategy
+// request field.
+const (
+	SlugNanoid     = "nanoid"
+	SlugSequential = "sequential"
+	SlugWords      = "words"
+	SlugNocase     = "nocase"
+)
+
+// SlugStrategy produces slugs for new links.
+type SlugStrategy interface {
+	Generate() (string, error)
+}
+
+// SizedSlugStrategy can generate longer slugs on demand. Length is in the
+// strategy's own unit: characters, or words for WordSlugs.
+type SizedSlugStrategy interface {
+	SlugStrategy
+	GenerateSized(n int) (string, error)
+	Size() int
+	Keyspace(n int) float64
+}
+
+// IDSlugStrategy derives the slug from the link's row id, so the slug can
+// only be assigned once the row exists. Its Generate returns a random
+// placeholder to insert the row with.
+type IDSlugStrategy interface {
+	SlugStrategy
+	FromID(id int64) string
+}
+
+// SlugStrategies returns every strategy by name. seqKey seeds the
+// permutation that hides the order of sequential slugs.
+func SlugStrategies(seqKey string) map[string]SlugStrategy {
+	return map[string]SlugStrategy{
+		SlugNanoid:     &RandomSlugs{Alphabet: alphabet, Length: length},
+		SlugSequential: NewSequentialSlugs(seqKey),
+		SlugWords:      &WordSlugs{Words: 3},
+		SlugNocase:     &RandomSlugs{Alphabet: nocaseAlphabet, Length: 8},
+	}
+}
+
 func New() (string, error) {
 	return gonanoid.Generate(alphabet, length)
-}
\ No newline at end of file
+}
+
+// RandomSlugs draws Length characters from Alphabet.
+type RandomSlugs struct {
+	Alphabet string
+	Length   int
+}
+
+func (s *RandomSlugs) Generate() (string, error) {
+	return s.GenerateSized(s.Length)
+}
+
+func (s *RandomSlugs) GenerateSized(n int) (string, error) {
+	return gonanoid.Generate(s.Alphabet, n)
+}
+
+func (s *RandomSlugs) Size() int { return s.Length }
+
+func (s *RandomSlugs) Keyspace(n int) float64 {
+	return math.Pow(float64(len(s.Alphabet)), float64(n))
+}
+
+// FoldSlug maps a slug typed in any case onto its nocase form, signed or
+// not. ok is false when slug can't be a nocase slug.
+func FoldSlug(slug string) (string, bool) {
+	folded := strings.ToLower(slug)
+	if folded == slug {
+		return "", false
+	}
+	body, rest := folded, ""
+	if IsSigned(folded) {
+		i := strings.LastIndex(folded, signedSep)
+		// rest[0] is the kid, which needn't be in the nocase alphabet
+		body, rest = folded[:i], folded[i+2:]
+	}
+	if strings.Trim(body, nocaseAlphabet) != "" || strings.Trim(rest, nocaseAlphabet) != "" {
+		return "", false
+	}
+	return folded, true
+}
+
+// SequentialSlugs encodes links.id in base62 after passing it through a
+// keyed permutation, so consecutive links don't get consecutive slugs.
+// Slugs are 6 characters until ids outgrow 62^6, then grow a character at a
+// time; each width is its own permutation so widths can't collide.
+type SequentialSlugs struct {
+	mul uint64
+	xor uint64
+}
+
+func NewSequentialSlugs(key string) *SequentialSlugs {
+	f := fnv.New64a()
+	f.Write([]byte(key))
+	k := f.Sum64()
+	return &SequentialSlugs{mul: k | 1, xor: k >> 17}
+}
+
+// Generate returns the placeholder the row is inserted with.
+func (s *SequentialSlugs) Generate() (string, error) {
+	return gonanoid.Generate(alphabet, 21)
+}
+
+func (s *SequentialSlugs) FromID(id int64) string {
+	base := uint64(len(alphabet))
+	width, size := 6, uint64(56800235584) // 62^6
+	for uint64(id) >= size && width < 10 {
+		width++
+		size *= base
+	}
+	bits := uint(1)
+	for uint64(1)<<bits < size {
+		bits++
+	}
+
+	// cycle-walk: permuting within 2^bits and repeating until the result is
+	// below size gives a permutation of [0, size)
+	x := uint64(id)
+	for {
+		x = s.permute(x, bits)
+		if x < size {
+			break
+		}
+	}
+
+	out := make([]byte, width)
+	for i := width - 1; i >= 0; i-- {
+		out[i] = alphabet[x%base]
+		x /= base
+	}
+	return string(out)
+}
+
+// permute is a bijection on [0, 2^bits): odd multiplications, xors and
+// xor-shifts modulo a power of two can all be undone.
+func (s *SequentialSlugs) permute(x uint64, bits uint) uint64 {
+	mask := uint64(1)<<bits - 1
+	for i := 0; i < 3; i++ {
+		x = (x * s.mul) & mask
+		x ^= s.xor & mask
+		x ^= x >> (bits / 2)
+	}
+	return x
+}
+
+// WordSlugs joins Words-1 adjectives and a noun, e.g. "brave-quiet-otter".
+type WordSlugs struct {
+	Words int
+}
+
+func (s *WordSlugs) Generate() (string, error) {
+	return s.GenerateSized(s.Words)
+}
+
+func (s *WordSlugs) Size() int { return s.Words }
+
+func (s *WordSlugs) Keyspace(n int) float64 {
+	return math.Pow(float64(len(adjectives)), float64(n-1)) * float64(len(nouns))
+}
+
+func (s *WordSlugs) GenerateSized(n int) (string, error) {
+	parts := make([]string, n)
+	for i := range parts {
+		list := adjectives
+		if i == len(parts)-1 {
+			list = nouns
+		}
+		n, err := rand.Int(rand.Reader, big.NewInt(int64(len(list))))
+		if err != nil {
+			return "", err
+		}
+		parts[i] = list[n.Int64()]
+	}
+	return strings.Join(parts, "-"), nil
+}
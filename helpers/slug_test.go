@@ -0,0 +1,104 @@
+package helpers
+
+import (
+	"regexp"
+	"testing"
+)
+
+func TestSlugStrategies(t *testing.T) {
+	shapes := map[string]*regexp.Regexp{
+		SlugNanoid:     regexp.MustCompile(`^[0-9A-Za-z]{7}$`),
+		SlugSequential: regexp.MustCompile(`^[0-9A-Za-z_-]{21}$`), // the placeholder
+		SlugWords:      regexp.MustCompile(`^[a-z]+-[a-z]+-[a-z]+$`),
+		SlugNocase:     regexp.MustCompile(`^[` + nocaseAlphabet + `]{8}$`),
+	}
+	strategies := SlugStrategies("key")
+	if len(strategies) != len(shapes) {
+		t.Fatalf("got %d strategies, want %d", len(strategies), len(shapes))
+	}
+	for name, s := range strategies {
+		shape := shapes[name]
+		if shape == nil {
+			t.Errorf("unexpected strategy %q", name)
+			continue
+		}
+		seen := make(map[string]bool)
+		for i := 0; i < 50; i++ {
+			slug, err := s.Generate()
+			if err != nil {
+				t.Fatalf("%s: %v", name, err)
+			}
+			if !shape.MatchString(slug) {
+				t.Errorf("%s generated %q", name, slug)
+			}
+			seen[slug] = true
+		}
+		if len(seen) < 45 {
+			t.Errorf("%s generated only %d distinct slugs in 50", name, len(seen))
+		}
+	}
+}
+
+func TestSizedSlugStrategies(t *testing.T) {
+	for name, s := range SlugStrategies("key") {
+		sized, ok := s.(SizedSlugStrategy)
+		if !ok {
+			continue
+		}
+		n := sized.Size()
+		if sized.Keyspace(n+1) <= sized.Keyspace(n) {
+			t.Errorf("%s: keyspace doesn't grow with the size", name)
+		}
+		long, err := sized.GenerateSized(n + 2)
+		if err != nil {
+			t.Fatal(err)
+		}
+		short, err := sized.Generate()
+		if err != nil {
+			t.Fatal(err)
+		}
+		if len(long) <= len(short) {
+			t.Errorf("%s: GenerateSized(%d) = %q, no longer than %q", name, n+2, long, short)
+		}
+	}
+	if _, ok := SlugStrategies("key")[SlugSequential].(IDSlugStrategy); !ok {
+		t.Error("sequential slugs aren't derived from the id")
+	}
+}
+
+func TestSequentialSlugs(t *testing.T) {
+	s := NewSequentialSlugs("key")
+	seen := make(map[string]int64)
+	for id := int64(1); id <= 20000; id++ {
+		slug := s.FromID(id)
+		if len(slug) != 6 {
+			t.Fatalf("FromID(%d) = %q, want 6 characters", id, slug)
+		}
+		if prev, ok := seen[slug]; ok {
+			t.Fatalf("FromID(%d) = FromID(%d) = %q", id, prev, slug)
+		}
+		seen[slug] = id
+	}
+	if s.FromID(7) != NewSequentialSlugs("key").FromID(7) {
+		t.Error("FromID differs between instances with the same key")
+	}
+	if s.FromID(7) == NewSequentialSlugs("other").FromID(7) {
+		t.Error("FromID is the same under another key")
+	}
+	if a, b := s.FromID(100), s.FromID(101); a[:5] == b[:5] {
+		t.Errorf("consecutive ids got similar slugs %q and %q", a, b)
+	}
+	for _, tc := range []struct {
+		id    int64
+		width int
+	}{
+		{56800235583, 6}, // 62^6 - 1
+		{56800235584, 7},
+		{3521614606207, 7}, // 62^7 - 1
+		{3521614606208, 8},
+	} {
+		if slug := s.FromID(tc.id); len(slug) != tc.width {
+			t.Errorf("FromID(%d) = %q, want %d characters", tc.id, slug, tc.width)
+		}
+	}
+}
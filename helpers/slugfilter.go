@@ -0,0 +1,111 @@
+package helpers
+
+import (
+	_ "embed"
+	"strings"
+)
+
+//go:embed slugfilter.txt
+var defaultSlugWords string
+
+// generateTries bounds how often SlugFilter.Generate redraws. Even a
+// crowded wordlist rejects a small share of random slugs.
+const generateTries = 100
+
+// confusables are groups of characters that are easy to misread for each
+// other. A slug may use at most one character from each group.
+var confusables = []string{"0Oo", "1lI", "5S", "2Z", "8B"}
+
+// leet folds digits and symbols onto the letters they stand in for, and
+// the look-alikes i/l onto one letter, before wordlist matching.
+var leet = strings.NewReplacer(
+	"0", "o", "1", "i", "l", "i", "!", "i", "|", "i",
+	"3", "e", "4", "a", "@", "a", "5", "s", "$", "s",
+	"7", "t", "8", "b", "9", "g", "-", "", "_", "",
+)
+
+// SlugFilter rejects slugs that spell a listed word or mix up confusable
+// characters.
+type SlugFilter struct {
+	words []string // already folded with leet
+}
+
+// LoadSlugFilter reads a wordlist, one word per line with '#' comments, or
+// uses the built-in list when path is empty.
+func LoadSlugFilter(path string) (*SlugFilter, error) {
+	var words []string
+	if path == "" {
+		for _, line := range strings.Split(defaultSlugWords, "\n") {
+			if line = strings.TrimSpace(line); line != "" && !strings.HasPrefix(line, "#") {
+				words = append(words, line)
+			}
+		}
+	} else {
+		var err error
+		if words, err = readPatterns(path); err != nil {
+			return nil, err
+		}
+	}
+
+	f := &SlugFilter{}
+	for _, w := range words {
+		if w = leet.Replace(strings.ToLower(w)); w != "" {
+			f.words = append(f.words, w)
+		}
+	}
+	return f, nil
+}
+
+// Offensive reports whether slug contains a listed word, reading it the
+// way a person would: any case, leetspeak, separators ignored. A nil
+// filter allows everything.
+func (f *SlugFilter) Offensive(slug string) bool {
+	if f == nil {
+		return false
+	}
+	s := leet.Replace(strings.ToLower(slug))
+	for _, w := range f.words {
+		if strings.Contains(s, w) {
+			return true
+		}
+	}
+	return false
+}
+
+// Confusing reports whether slug uses two characters that are easy to
+// misread for each other, such as 0 and O or l and 1.
+func Confusing(slug string) bool {
+	for _, group := range confusables {
+		seen := rune(0)
+		for _, r := range slug {
+			if !strings.ContainsRune(group, r) {
+				continue
+			}
+			if seen != 0 && seen != r {
+				return true
+			}
+			seen = r
+		}
+	}
+	return false
+}
+
+// Allowed reports whether slug passes both checks. Generated and vanity
+// slugs are held to the same rules.
+func (f *SlugFilter) Allowed(slug string) bool {
+	return !f.Offensive(slug) && !Confusing(slug)
+}
+
+// Generate calls gen until it returns an allowed slug. If none turns up in
+// generateTries draws the last one is returned anyway, so a wordlist that
+// rejects everything can't stop links from being created.
+func (f *SlugFilter) Generate(gen func() (string, error)) (string, error) {
+	var slug string
+	var err error
+	for i := 0; i < generateTries; i++ {
+		if slug, err = gen(); err != nil || f.Allowed(slug) {
+			return slug, err
+		}
+	}
+	return slug, nil
+}
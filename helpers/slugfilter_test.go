@@ -0,0 +1,81 @@
+package helpers
+
+import (
+	"fmt"
+	"testing"
+)
+
+func TestSlugFilterOffensive(t *testing.T) {
+	f, err := LoadSlugFilter("")
+	if err != nil {
+		t.Fatal(err)
+	}
+	cases := []struct {
+		slug string
+		want bool
+	}{
+		{"fuck", true},
+		{"fuckyou", true},
+		{"FuckYou", true},
+		{"sh1t", true},
+		{"5hit-happens", true},
+		{"my-a55", true},
+		{"f-u-c-k", true},
+		{"summer-sale", false},
+		{"k7mq2x", false},
+	}
+	for _, tc := range cases {
+		if got := f.Offensive(tc.slug); got != tc.want {
+			t.Errorf("Offensive(%q) = %v, want %v", tc.slug, got, tc.want)
+		}
+	}
+
+	var none *SlugFilter
+	if none.Offensive("fuckyou") || !none.Allowed("fuckyou") {
+		t.Error("a nil filter rejected a slug")
+	}
+}
+
+func TestConfusing(t *testing.T) {
+	cases := []struct {
+		slug string
+		want bool
+	}{
+		{"O0-test", true},
+		{"l1nk", true},
+		{"5ale-S", true},
+		{"000", false},
+		{"lll", false},
+		{"abc123", false},
+	}
+	for _, tc := range cases {
+		if got := Confusing(tc.slug); got != tc.want {
+			t.Errorf("Confusing(%q) = %v, want %v", tc.slug, got, tc.want)
+		}
+	}
+}
+
+func TestSlugFilterGenerate(t *testing.T) {
+	f, err := LoadSlugFilter("")
+	if err != nil {
+		t.Fatal(err)
+	}
+	draws := []string{"fuckyou", "O0abc", "good"}
+	calls := 0
+	slug, err := f.Generate(func() (string, error) {
+		calls++
+		return draws[calls-1], nil
+	})
+	if err != nil || slug != "good" || calls != 3 {
+		t.Errorf("Generate = %q, %v after %d draws, want good after 3", slug, err, calls)
+	}
+
+	calls = 0
+	slug, err = f.Generate(func() (string, error) {
+		calls++
+		return fmt.Sprintf("shit%d", calls), nil
+	})
+	if err != nil || calls != generateTries || slug != fmt.Sprintf("shit%d", generateTries) {
+		t.Errorf("Generate = %q, %v after %d draws, want the last of %d", slug, err, calls, generateTries)
+	}
+}
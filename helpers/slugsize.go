@@ -0,0 +1,127 @@
+package helpers
+
+import (
+	"sync"
+	"sync/atomic"
+
+	"go.uber.org/zap"
+)
+
+// Thresholds for growing a sized strategy's slugs. With occupancy p a
+// random slug collides with probability p, so both limits bound the same
+// thing: the chance a create has to retry.
+const (
+	maxOccupancy     = 0.001 // links per possible slug
+	maxCollisionRate = 0.01  // collided attempts per attempt
+	collisionWindow  = 1000  // attempts the collision rate is averaged over
+	escalateAfter    = 3     // colliding attempts before an insert adds a character
+)
+
+// SlugSizer picks the slug length for a sized strategy. It grows the length
+// when the keyspace gets crowded, judged by the number of links or by the
+// observed collision rate, and never shrinks it.
+type SlugSizer struct {
+	name     string
+	strategy SizedSlugStrategy
+	links    *atomic.Int64 // every link counts against every keyspace
+	log      *zap.Logger
+
+	mu         sync.Mutex
+	length     int
+	attempts   float64 // exponentially decayed over collisionWindow
+	collisions float64
+	created    int64
+	collided   int64
+}
+
+// SlugMetrics is a snapshot of a SlugSizer.
+type SlugMetrics struct {
+	Strategy      string  `json:"strategy"`
+	Length        int     `json:"length"`
+	Keyspace      float64 `json:"keyspace"`
+	Links         int64   `json:"links"`
+	Occupancy     float64 `json:"occupancy"`
+	CollisionRate float64 `json:"collision_rate"`
+	Created       int64   `json:"created"`
+	Collisions    int64   `json:"collisions"`
+}
+
+// NewSlugSizer starts at the strategy's own size, grown as needed for the
+// links that already exist. links is the shared link count.
+func NewSlugSizer(name string, s SizedSlugStrategy, links *atomic.Int64, log *zap.Logger) *SlugSizer {
+	z := &SlugSizer{name: name, strategy: s, log: log, length: s.Size(), links: links}
+	z.mu.Lock()
+	z.grow()
+	z.mu.Unlock()
+	return z
+}
+
+// Next generates the slug for the given attempt of an insert. Attempts past
+// escalateAfter add a character each, so an unlucky insert always gets out.
+func (z *SlugSizer) Next(attempt int) (string, error) {
+	z.mu.Lock()
+	n := z.length
+	z.mu.Unlock()
+	if attempt >= escalateAfter {
+		n += attempt - escalateAfter + 1
+	}
+	return z.strategy.GenerateSized(n)
+}
+
+// Record notes a successful insert that took collisions retries. The caller
+// has already counted the new link.
+func (z *SlugSizer) Record(collisions int) {
+	z.mu.Lock()
+	defer z.mu.Unlock()
+
+	decay := 1 - 1.0/collisionWindow
+	for i := 0; i <= collisions; i++ {
+		z.attempts = z.attempts*decay + 1
+		z.collisions *= decay
+		if i < collisions {
+			z.collisions++
+		}
+	}
+	z.created++
+	z.collided += int64(collisions)
+	z.grow()
+}
+
+func (z *SlugSizer) grow() {
+	for {
+		crowded := float64(z.links.Load())/z.strategy.Keyspace(z.length) > maxOccupancy
+		// wait for a reasonable sample before trusting the rate
+		colliding := z.attempts >= collisionWindow/10 && z.collisions/z.attempts > maxCollisionRate
+		if !crowded && !colliding {
+			return
+		}
+		z.length++
+		// the old rate says nothing about the new length
+		z.attempts, z.collisions = 0, 0
+		if z.log != nil {
+			z.log.Warn("slug keyspace crowded, growing slugs",
+				zap.String("strategy", z.name),
+				zap.Int("length", z.length),
+				zap.Int64("links", z.links.Load()),
+				zap.Bool("by_collisions", colliding))
+		}
+	}
+}
+
+func (z *SlugSizer) Metrics() SlugMetrics {
+	z.mu.Lock()
+	defer z.mu.Unlock()
+	m := SlugMetrics{
+		Strategy:   z.name,
+		Length:     z.length,
+		Keyspace:   z.strategy.Keyspace(z.length),
+		Links:      z.links.Load(),
+		Created:    z.created,
+		Collisions: z.collided,
+	}
+	m.Occupancy = float64(m.Links) / m.Keyspace
+	if z.attempts > 0 {
+		m.CollisionRate = z.collisions / z.attempts
+	}
+	return m
+}
@@ -0,0 +1,81 @@
+package helpers
+
+import (
+	"sync/atomic"
+	"testing"
+)
+
+// digits is a sized strategy with a keyspace small enough to crowd.
+var digits = &RandomSlugs{Alphabet: "0123456789", Length: 2}
+
+func TestSlugSizerGrowsWithOccupancy(t *testing.T) {
+	var links atomic.Int64
+	z := NewSlugSizer("digits", digits, &links, nil)
+	if m := z.Metrics(); m.Length != 2 || m.Keyspace != 100 {
+		t.Fatalf("empty: %+v, want length 2 of 100", m)
+	}
+
+	// one link in 100 is far past maxOccupancy; 1 in 1000 is at it
+	links.Store(1)
+	z.Record(0)
+	if m := z.Metrics(); m.Length != 3 || m.Occupancy != 0.001 {
+		t.Errorf("one link: %+v, want length 3 at occupancy 0.001", m)
+	}
+
+	// links already there when the sizer starts count too
+	links.Store(50)
+	if m := NewSlugSizer("digits", digits, &links, nil).Metrics(); m.Length != 5 {
+		t.Errorf("started with 50 links: length %d, want 5", m.Length)
+	}
+}
+
+func TestSlugSizerGrowsWithCollisions(t *testing.T) {
+	var links atomic.Int64
+	z := NewSlugSizer("digits", digits, &links, nil)
+
+	// too few attempts to trust the rate yet
+	for i := 0; i < 10; i++ {
+		z.Record(1)
+	}
+	if m := z.Metrics(); m.Length != 2 || m.CollisionRate < 0.4 {
+		t.Fatalf("after 10 colliding inserts: %+v, want length 2 at a rate near 0.5", m)
+	}
+	inserts := 10
+	for z.Metrics().Length == 2 && inserts < collisionWindow {
+		z.Record(1)
+		inserts++
+	}
+	m := z.Metrics()
+	if m.Length != 3 {
+		t.Fatalf("length %d after a steady collision rate, want 3", m.Length)
+	}
+	if m.Created != int64(inserts) || m.Collisions != m.Created {
+		t.Errorf("metrics = %+v, want %d inserts with one collision each", m, inserts)
+	}
+	if m.CollisionRate != 0 {
+		t.Errorf("collision rate %v carried over to the new length", m.CollisionRate)
+	}
+
+	// quiet inserts never shrink it back
+	grown := m.Length
+	for i := 0; i < collisionWindow; i++ {
+		z.Record(0)
+	}
+	if m := z.Metrics(); m.Length != grown {
+		t.Errorf("length %d after quiet inserts, want it to stay %d", m.Length, grown)
+	}
+}
+
+func TestSlugSizerNextEscalates(t *testing.T) {
+	var links atomic.Int64
+	z := NewSlugSizer("digits", digits, &links, nil)
+	for attempt, want := range []int{2, 2, 2, 3, 4, 5} {
+		slug, err := z.Next(attempt)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if len(slug) != want {
+			t.Errorf("Next(%d) = %q, want %d characters", attempt, slug, want)
+		}
+	}
+}
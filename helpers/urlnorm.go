@@ -0,0 +1,142 @@
+package helpers
+
+import (
+	"net"
+	"net/url"
+	"strings"
+
+	"golang.org/x/net/idna"
+)
+
+// defaultPorts are dropped by NormalizeURL.
+var defaultPorts = map[string]string{"http": "80", "https": "443"}
+
+// hostProfile converts internationalized host names to punycode. It is
+// UTS #46 lookup without the STD3 host name rules, so hosts that net/url
+// accepts, e.g. with underscores, still convert.
+var hostProfile = idna.New(
+	idna.MapForLookup(),
+	idna.BidiRule(),
+	idna.StrictDomainName(false),
+	idna.ValidateLabels(false),
+)
+
+// NormalizeURL spells raw the way every equivalent spelling of it is
+// spelled, so destinations can be compared and stored once: the scheme and
+// host are lower case, an internationalized host is in punycode, a default
+// port is dropped, "." and ".." path segments are resolved, an empty path
+// becomes "/" and an empty query goes away. Normalizing the result again
+// changes nothing.
+func NormalizeURL(raw string) (string, error) {
+	u, err := url.Parse(strings.TrimSpace(raw))
+	if err != nil {
+		return "", err
+	}
+	u.Scheme = strings.ToLower(u.Scheme)
+
+	host, port := u.Hostname(), u.Port()
+	if net.ParseIP(host) == nil && host != "" {
+		if host, err = hostProfile.ToASCII(host); err != nil {
+			return "", err
+		}
+	}
+	host = strings.ToLower(host)
+	if port == defaultPorts[u.Scheme] {
+		port = ""
+	}
+	if port != "" || strings.Contains(host, ":") {
+		host = strings.TrimSuffix(net.JoinHostPort(host, port), ":")
+	}
+	u.Host = host
+
+	if u.Opaque == "" {
+		p := removeDotSegments(u.EscapedPath())
+		if p == "" && u.Host != "" {
+			p = "/"
+		}
+		if u.Path, err = url.PathUnescape(p); err != nil {
+			return "", err
+		}
+		u.RawPath = p
+	}
+	u.ForceQuery = false
+	return u.String(), nil
+}
+
+// removeDotSegments is RFC 3986 section 5.2.4 on an escaped path.
+func removeDotSegments(p string) string {
+	if !strings.Contains(p, ".") {
+		return p
+	}
+	var out []string
+	segs := strings.Split(p, "/")
+	for i, s := range segs {
+		last := i == len(segs)-1
+		switch s {
+		case ".":
+			if last {
+				out = append(out, "")
+			}
+		case "..":
+			// never climb above the root
+			if n := len(out); n > 1 || n == 1 && out[0] != "" {
+				out = out[:n-1]
+			}
+			if last {
+				out = append(out, "")
+			}
+		default:
+			out = append(out, s)
+		}
+	}
+	return strings.Join(out, "/")
+}
+
+// CanonicalURL is the spelling of a destination that gets stored: raw
+// normalized, minus the query parameters matching tracking.
+func CanonicalURL(raw string, tracking []string) (string, error) {
+	u, err := NormalizeURL(raw)
+	if err != nil {
+		return "", err
+	}
+	return StripQueryParams(u, tracking), nil
+}
+
+// StripQueryParams removes the query parameters of raw whose names match
+// patterns and keeps the rest as they were, in order. Names match without
+// regard to case, and a pattern ending in '*' matches any name it prefixes,
+// e.g. "utm_*". raw must be a valid URL.
+func StripQueryParams(raw string, patterns []string) string {
+	if len(patterns) == 0 {
+		return raw
+	}
+	u, err := url.Parse(raw)
+	if err != nil || u.RawQuery == "" {
+		return raw
+	}
+	var kept []string
+	for _, pair := range strings.Split(u.RawQuery, "&") {
+		name, _, _ := strings.Cut(pair, "=")
+		if n, err := url.QueryUnescape(name); err == nil {
+			name = n
+		}
+		if !paramMatches(strings.ToLower(name), patterns) {
+			kept = append(kept, pair)
+		}
+	}
+	u.RawQuery = strings.Join(kept, "&")
+	return u.String()
+}
+
+func paramMatches(name string, patterns []string) bool {
+	for _, p := range patterns {
+		if prefix, ok := strings.CutSuffix(p, "*"); ok {
+			if strings.HasPrefix(name, prefix) {
+				return true
+			}
+		} else if name == p {
+			return true
+		}
+	}
+	return false
+}
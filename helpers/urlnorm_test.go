@@ -0,0 +1,79 @@
+package helpers
+
+import "testing"
+
+func TestNormalizeURL(t *testing.T) {
+	cases := []struct {
+		raw  string
+		want string
+	}{
+		{"https://example.com/a", "https://example.com/a"},
+		{"HTTPS://Example.COM/Path", "https://example.com/Path"},
+		{"  https://example.com/a  ", "https://example.com/a"},
+		{"https://example.com", "https://example.com/"},
+		{"https://example.com:443/a", "https://example.com/a"},
+		{"http://example.com:80/a", "http://example.com/a"},
+		{"http://example.com:443/a", "http://example.com:443/a"},
+		{"https://example.com:8443/a", "https://example.com:8443/a"},
+		{"https://example.com/a/./b/../c", "https://example.com/a/c"},
+		{"https://example.com/../../a", "https://example.com/a"},
+		{"https://example.com/a/..", "https://example.com/"},
+		{"https://example.com/a?", "https://example.com/a"},
+		{"https://example.com/a?b=1&a=2", "https://example.com/a?b=1&a=2"},
+		{"https://example.com/a%2Fb", "https://example.com/a%2Fb"},
+		{"https://example.com/a#frag", "https://example.com/a#frag"},
+		{"https://bücher.example/", "https://xn--bcher-kva.example/"},
+		{"https://BÜCHER.example/", "https://xn--bcher-kva.example/"},
+		{"https://my_host.example/", "https://my_host.example/"},
+		{"http://[::1]:80/", "http://[::1]/"},
+		{"http://[::1]:8080/", "http://[::1]:8080/"},
+		{"http://192.168.0.1:80", "http://192.168.0.1/"},
+		{"mailto:someone@example.com", "mailto:someone@example.com"},
+	}
+	for _, tc := range cases {
+		got, err := NormalizeURL(tc.raw)
+		if err != nil {
+			t.Errorf("NormalizeURL(%q): %v", tc.raw, err)
+			continue
+		}
+		if got != tc.want {
+			t.Errorf("NormalizeURL(%q) = %q, want %q", tc.raw, got, tc.want)
+		}
+		if again, err := NormalizeURL(got); err != nil || again != got {
+			t.Errorf("NormalizeURL(%q) = %q, %v, want it unchanged", got, again, err)
+		}
+	}
+
+	for _, raw := range []string{"https://exa mple.com/", "http://example.com/%zz", ":nope"} {
+		if got, err := NormalizeURL(raw); err == nil {
+			t.Errorf("NormalizeURL(%q) = %q, want an error", raw, got)
+		}
+	}
+}
+
+func TestCanonicalURL(t *testing.T) {
+	tracking := []string{"utm_*", "fbclid"}
+	cases := []struct {
+		raw      string
+		tracking []string
+		want     string
+	}{
+		{"https://example.com/a?utm_source=x&id=1", tracking, "https://example.com/a?id=1"},
+		{"https://example.com/a?UTM_Medium=x&id=1&fbclid=y", tracking, "https://example.com/a?id=1"},
+		{"https://example.com/a?utm_source=x", tracking, "https://example.com/a"},
+		{"https://example.com/a?fbclidx=1", tracking, "https://example.com/a?fbclidx=1"},
+		{"https://example.com/a?utm%5Fsource=x", tracking, "https://example.com/a"},
+		{"https://example.com/a?b=2&a=1&b=1", tracking, "https://example.com/a?b=2&a=1&b=1"},
+		{"HTTPS://Example.com:443/x/../a?utm_source=x", tracking, "https://example.com/a"},
+		{"https://example.com/a?utm_source=x", nil, "https://example.com/a?utm_source=x"},
+		{"https://example.com/a?utm_source=x#top", tracking, "https://example.com/a#top"},
+	}
+	for _, tc := range cases {
+		got, err := CanonicalURL(tc.raw, tc.tracking)
+		if err != nil {
+			t.Errorf("CanonicalURL(%q): %v", tc.raw, err)
+		} else if got != tc.want {
+			t.Errorf("CanonicalURL(%q, %q) = %q, want %q", tc.raw, tc.tracking, got, tc.want)
+		}
+	}
+}
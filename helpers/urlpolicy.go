@@ -0,0 +1,205 @@
+package helpers
+
+import (
+	"bufio"
+	"fmt"
+	"net"
+	"net/url"
+	"os"
+	"path"
+	"strings"
+)
+
+// Codes returned in PolicyError.Code.
+const (
+	PolicyInvalidURL       = "invalid_url"
+	PolicySchemeNotAllowed = "scheme_not_allowed"
+	PolicyPrivateAddress   = "private_address"
+	PolicyInternalHost     = "internal_host"
+	PolicyDomainDenied     = "domain_denied"
+	PolicyDomainNotAllowed = "domain_not_allowed"
+	PolicyShortener        = "external_shortener"
+	PolicyRedirectLoop     = "redirect_loop"
+	PolicyChainTooDeep     = "chain_too_deep"
+	PolicyDanglingLink     = "dangling_short_link"
+)
+
+// PolicyError explains why a destination URL was refused.
+type PolicyError struct {
+	Code   string
+	Reason string
+}
+
+func (e *PolicyError) Error() string { return e.Reason }
+
+// internalSuffixes are names that only resolve inside a private network.
+var internalSuffixes = []string{".localhost", ".local", ".internal", ".intranet", ".lan", ".home.arpa", ".corp"}
+
+// blockedNets are special-purpose ranges the net.IP predicates don't cover:
+// "this network", carrier-grade NAT and the NAT64 prefix, which reaches
+// IPv4 addresses, private ones included, through a translator.
+var blockedNets = mustParseCIDRs("0.0.0.0/8", "100.64.0.0/10", "64:ff9b::/96")
+
+func mustParseCIDRs(cidrs ...string) []*net.IPNet {
+	out := make([]*net.IPNet, len(cidrs))
+	for i, cidr := range cidrs {
+		_, n, err := net.ParseCIDR(cidr)
+		if err != nil {
+			panic(err)
+		}
+		out[i] = n
+	}
+	return out
+}
+
+// URLPolicy decides which destinations may be shortened. Allow and deny
+// entries are host patterns: "example.com" matches only that host, and
+// "*.example.com" matches any subdomain of it. Patterns and hosts are
+// compared in punycode, so "bücher.de" and "xn--bcher-kva.de" are the same
+// entry. A nil *URLPolicy still
+// applies the built-in scheme and private network checks.
+type URLPolicy struct {
+	allow      []string
+	deny       []string
+	shorteners []string
+}
+
+// LoadURLPolicy reads allow and deny lists, one pattern per line with '#'
+// comments. Either path may be empty. A non-empty allow list means only
+// matching hosts are accepted. shorteners are host patterns of other URL
+// shortening services, which Check refuses so links can't be chained
+// through them.
+func LoadURLPolicy(allowPath, denyPath string, shorteners []string) (*URLPolicy, error) {
+	p := &URLPolicy{}
+	for _, pat := range shorteners {
+		ascii, err := asciiPattern(pat)
+		if err != nil {
+			return nil, fmt.Errorf("shortener %q: %w", pat, err)
+		}
+		p.shorteners = append(p.shorteners, ascii)
+	}
+	var err error
+	if allowPath != "" {
+		if p.allow, err = readPatterns(allowPath); err != nil {
+			return nil, err
+		}
+	}
+	if denyPath != "" {
+		if p.deny, err = readPatterns(denyPath); err != nil {
+			return nil, err
+		}
+	}
+	return p, nil
+}
+
+func readPatterns(name string) ([]string, error) {
+	f, err := os.Open(name)
+	if err != nil {
+		return nil, err
+	}
+	defer f.Close()
+
+	var out []string
+	sc := bufio.NewScanner(f)
+	for sc.Scan() {
+		line := sc.Text()
+		if i := strings.IndexByte(line, '#'); i >= 0 {
+			line = line[:i]
+		}
+		line = strings.TrimSuffix(strings.TrimSpace(line), ".")
+		if line == "" {
+			continue
+		}
+		pat, err := asciiPattern(line)
+		if err != nil {
+			return nil, fmt.Errorf("%s: %q: %w", name, line, err)
+		}
+		out = append(out, pat)
+	}
+	return out, sc.Err()
+}
+
+// asciiPattern converts a host pattern to the punycode form Check matches
+// against, keeping a leading "*." wildcard.
+func asciiPattern(pat string) (string, error) {
+	wild, rest := "", pat
+	if strings.HasPrefix(pat, "*.") {
+		wild, rest = "*.", pat[2:]
+	}
+	ascii, err := hostProfile.ToASCII(rest)
+	if err != nil {
+		return "", err
+	}
+	return wild + strings.ToLower(ascii), nil
+}
+
+// Check returns a *PolicyError if raw may not be used as a destination.
+func (p *URLPolicy) Check(raw string) error {
+	u, err := url.Parse(raw)
+	if err != nil || u.Host == "" {
+		return &PolicyError{PolicyInvalidURL, "url is not a valid absolute URL"}
+	}
+	if s := strings.ToLower(u.Scheme); s != "http" && s != "https" {
+		return &PolicyError{PolicySchemeNotAllowed, "only http and https URLs can be shortened"}
+	}
+
+	host := strings.ToLower(strings.TrimSuffix(u.Hostname(), "."))
+	if ip := net.ParseIP(host); ip != nil {
+		if ip.IsLoopback() || ip.IsPrivate() || ip.IsUnspecified() ||
+			ip.IsLinkLocalUnicast() || ip.IsLinkLocalMulticast() || ip.IsMulticast() || inBlockedNet(ip) {
+			return &PolicyError{PolicyPrivateAddress, "url points to a private, loopback or link-local address"}
+		}
+	} else if host, err = hostProfile.ToASCII(host); err != nil {
+		return &PolicyError{PolicyInvalidURL, "url host is not a valid domain name"}
+	} else if host = strings.ToLower(strings.TrimSuffix(host, ".")); isInternalHost(host) {
+		return &PolicyError{PolicyInternalHost, "url points to an internal hostname"}
+	}
+
+	if p == nil {
+		return nil
+	}
+	if matchHost(p.deny, host) {
+		return &PolicyError{PolicyDomainDenied, "url domain is blocked"}
+	}
+	if matchHost(p.shorteners, host) {
+		return &PolicyError{PolicyShortener, "url points to another link shortener"}
+	}
+	if len(p.allow) > 0 && !matchHost(p.allow, host) {
+		return &PolicyError{PolicyDomainNotAllowed, "url domain is not on the allow list"}
+	}
+	return nil
+}
+
+// isInternalHost flags single-label names, well-known private suffixes and
+// numeric hosts such as "2130706433" or "0x7f.1" that browsers would read as
+// IP addresses.
+func isInternalHost(host string) bool {
+	if host == "localhost" || !strings.Contains(host, ".") {
+		return true
+	}
+	for _, suf := range internalSuffixes {
+		if strings.HasSuffix(host, suf) {
+			return true
+		}
+	}
+	tld := host[strings.LastIndexByte(host, '.')+1:]
+	return strings.Trim(tld, "0123456789abcdefx") == "" && strings.ContainsAny(tld, "0123456789")
+}
+
+func inBlockedNet(ip net.IP) bool {
+	for _, n := range blockedNets {
+		if n.Contains(ip) {
+			return true
+		}
+	}
+	return false
+}
+
+func matchHost(patterns []string, host string) bool {
+	for _, pat := range patterns {
+		if ok, _ := path.Match(pat, host); ok {
+			return true
+		}
+	}
+	return false
+}
@@ -0,0 +1,51 @@
+package helpers
+
+import (
+	"errors"
+	"os"
+	"path/filepath"
+	"testing"
+)
+
+func TestURLPolicyCheck(t *testing.T) {
+	dir := t.TempDir()
+	deny := filepath.Join(dir, "deny.txt")
+	if err := os.WriteFile(deny, []byte("bücher.example # unicode entry\n*.XN--MNCHEN-3YA.example.\n"), 0o644); err != nil {
+		t.Fatal(err)
+	}
+	p, err := LoadURLPolicy("", deny, []string{"*.ßhort.example"})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	cases := []struct {
+		url  string
+		want string
+	}{
+		{"https://example.com/", ""},
+		{"http://0.1.2.3/", PolicyPrivateAddress},
+		{"http://100.64.0.1/", PolicyPrivateAddress},
+		{"http://100.127.255.254/", PolicyPrivateAddress},
+		{"http://100.128.0.1/", ""},
+		{"http://[64:ff9b::a00:1]/", PolicyPrivateAddress},
+		{"http://[::ffff:100.64.0.1]/", PolicyPrivateAddress},
+		{"https://bücher.example/", PolicyDomainDenied},
+		{"https://xn--bcher-kva.example/", PolicyDomainDenied},
+		{"https://BÜCHER.example/", PolicyDomainDenied},
+		{"https://www.münchen.example/", PolicyDomainDenied},
+		{"https://münchen.example/", ""},
+		{"https://a.xn--hort-una.example/", PolicyShortener},
+		{"https://a.ßhort.example/", PolicyShortener},
+		{"https://localhost．/", PolicyInternalHost},
+	}
+	for _, tc := range cases {
+		err := p.Check(tc.url)
+		var pe *PolicyError
+		switch {
+		case tc.want == "" && err != nil:
+			t.Errorf("Check(%q) = %v, want ok", tc.url, err)
+		case tc.want != "" && (!errors.As(err, &pe) || pe.Code != tc.want):
+			t.Errorf("Check(%q) = %v, want %s", tc.url, err, tc.want)
+		}
+	}
+}
@@ -0,0 +1,24 @@
+package helpers
+
+import "strings"
+
+// Platform values returned by DetectPlatform.
+const (
+	PlatformIOS     = "ios"
+	PlatformAndroid = "android"
+	PlatformDesktop = "desktop"
+)
+
+// DetectPlatform classifies a User-Agent into one of the Platform* values.
+// Anything that isn't recognisably iOS or Android is treated as desktop.
+func DetectPlatform(ua string) string {
+	ua = strings.ToLower(ua)
+	switch {
+	case strings.Contains(ua, "iphone"), strings.Contains(ua, "ipad"), strings.Contains(ua, "ipod"):
+		return PlatformIOS
+	case strings.Contains(ua, "android"):
+		return PlatformAndroid
+	default:
+		return PlatformDesktop
+	}
+}
@@ -2,39 +2,50 @@ package helpers
 
 import (
 	"context"
-	"database/sql"
 	"errors"
 	"strings"
 	"time"
 
 	"shotr/db"
+	"shotr/store"
 
 	"github.com/avast/retry-go"
+	"github.com/lib/pq"
 	"github.com/mattn/go-sqlite3"
 	"go.uber.org/zap"
 )
 
-func TryInsertWithRetry(ctx context.Context, q *db.Queries, url string, maxRetries int, log *zap.Logger) (db.Link, error) {
+// TryInsertWithRetry inserts a link with params' URL and user, drawing a
+// new slug from gen after each slug collision. gen is told the attempt
+// number, starting at 0, so it can use longer slugs once maxRetries attempts
+// have collided; collisions alone never fail the insert. It also returns how
+// many attempts collided. Each attempt runs in its own savepoint when q is
+// a transaction, since postgres refuses further statements in a
+// transaction once one has failed.
+func TryInsertWithRetry(ctx context.Context, q store.Store, params db.AddLinkParams, gen func(attempt int) (string, error), maxRetries int, log *zap.Logger) (db.Link, int, error) {
 	var created db.Link
-	params := db.AddLinkParams{
-		Url:  url,
-		User: sql.NullString{Valid: false},
-	}
+	attempt := 0
 
 	operation := func() error {
-		slug, err := New()
+		slug, err := gen(attempt)
 		if err != nil {
 			return retry.Unrecoverable(err)
 		}
 		params.Slug = slug
 
-		link, err := q.AddLink(ctx, params)
+		var link db.Link
+		err = q.WithTx(ctx, func(tx store.Store) error {
+			var err error
+			link, err = tx.AddLink(ctx, params)
+			return err
+		})
 		if err == nil {
 			created = link
 			return nil
 		}
 
-		if isUniqueConstraint(err) {
+		if IsUniqueConstraint(err) {
+			attempt++
 			return err
 		}
 
@@ -43,22 +54,34 @@ func TryInsertWithRetry(ctx context.Context, q *db.Queries, url string, maxRetri
 
 	err := retry.Do(
 		operation,
-		retry.Attempts(uint(maxRetries)),
+		// each attempt past maxRetries gets a longer slug, so this bound is
+		// only a backstop against a constraint that isn't about the slug
+		retry.Attempts(uint(maxRetries+slugGrowthLimit)),
 		retry.Delay(50*time.Millisecond),
+		retry.MaxDelay(200*time.Millisecond),
 		retry.LastErrorOnly(true),
 		retry.OnRetry(func(n uint, err error) {
 			log.Warn("retrying slug insert", zap.Uint("attempt", n+1), zap.Error(err))
 		}),
 	)
 
-	return created, err
+	return created, attempt, err
 }
 
-func isUniqueConstraint(err error) bool {
+// slugGrowthLimit caps how many characters a single insert may add to a
+// slug while escaping collisions.
+const slugGrowthLimit = 16
+
+// IsUniqueConstraint reports whether err is a unique constraint violation.
+func IsUniqueConstraint(err error) bool {
 	if err == nil {
 		return false
 	}
 
+	if errors.Is(err, store.ErrDuplicate) {
+		return true
+	}
+
 	var se sqlite3.Error
 	if errors.As(err, &se) {
 		if se.ExtendedCode == sqlite3.ErrConstraintUnique || se.Code == sqlite3.ErrConstraint {
@@ -66,6 +89,11 @@ func isUniqueConstraint(err error) bool {
 		}
 	}
 
+	var pe *pq.Error
+	if errors.As(err, &pe) && pe.Code == "23505" { // unique_violation
+		return true
+	}
+
 	msg := strings.ToLower(err.Error())
 	return strings.Contains(msg, "unique constraint") || strings.Contains(msg, "constraint failed")
 }
\ No newline at end of file
@@ -0,0 +1,39 @@
+package helpers
+
+// Word lists for WordSlugs. Short, common and hard to misspell.
+var adjectives = []string{
+	"able", "amber", "ample", "azure", "bold", "brave", "breezy", "bright",
+	"brisk", "calm", "candid", "chill", "civil", "clean", "clear", "clever",
+	"cosy", "crisp", "curly", "dandy", "daring", "deep", "eager", "early",
+	"easy", "epic", "even", "fair", "fancy", "fast", "fine", "firm",
+	"fluffy", "fond", "free", "fresh", "frosty", "fun", "gentle", "giant",
+	"glad", "golden", "good", "grand", "green", "happy", "hardy", "hasty",
+	"humble", "jolly", "juicy", "keen", "kind", "large", "lazy", "light",
+	"little", "lively", "lucky", "lunar", "magic", "mellow", "merry", "mighty",
+	"mild", "misty", "modern", "neat", "nimble", "noble", "odd", "olive",
+	"open", "plain", "plush", "polite", "proud", "quick", "quiet", "rapid",
+	"rare", "ready", "regal", "rosy", "round", "royal", "rustic", "safe",
+	"salty", "sandy", "sharp", "shiny", "silent", "silky", "simple", "sleek",
+	"smart", "smooth", "snowy", "soft", "solar", "solid", "spicy", "steady",
+	"still", "stormy", "sturdy", "sunny", "super", "swift", "tame", "tidy",
+	"tiny", "topaz", "tough", "true", "vast", "velvet", "vivid", "warm",
+	"wavy", "wild", "windy", "wise", "witty", "young", "zany", "zesty",
+}
+
+var nouns = []string{
+	"acorn", "anchor", "apple", "badger", "bagel", "beacon", "beaver", "berry",
+	"bison", "breeze", "brook", "cactus", "canyon", "cedar", "cherry", "cloud",
+	"comet", "coral", "cotton", "crane", "creek", "daisy", "delta", "dingo",
+	"dolphin", "dune", "eagle", "ember", "falcon", "fern", "finch", "fjord",
+	"forest", "fox", "gecko", "glacier", "grove", "harbor", "hawk", "hazel",
+	"heron", "hill", "island", "ivy", "jaguar", "jasper", "kettle", "kiwi",
+	"koala", "lagoon", "lake", "lantern", "lemon", "lily", "lion", "lotus",
+	"lynx", "maple", "meadow", "mango", "marble", "meteor", "moose", "moss",
+	"mountain", "nebula", "nutmeg", "oak", "ocean", "olive", "orbit", "orchid",
+	"otter", "owl", "panda", "parrot", "peach", "pebble", "pepper", "pine",
+	"planet", "plum", "pond", "poppy", "puffin", "quail", "quartz", "rabbit",
+	"raven", "reef", "ridge", "river", "robin", "rocket", "saddle", "sage",
+	"salmon", "sparrow", "spruce", "squid", "star", "stone", "summit", "swan",
+	"thistle", "thunder", "tiger", "toucan", "tulip", "tundra", "turtle", "valley",
+	"violet", "walnut", "walrus", "willow", "wombat", "wren", "yak", "zebra",
+}
@@ -5,16 +5,22 @@ import (
 	"fmt"
 	"log"
 	"os"
+	"strconv"
+	"strings"
 	"time"
 
 	_ "github.com/joho/godotenv/autoload"                // loads .env automatically if present
+	_ "github.com/lib/pq"                                // PostgreSQL driver
 	_ "github.com/mattn/go-sqlite3"                      // local fallback driver (sqlite file)
 	_ "github.com/tursodatabase/libsql-client-go/libsql" // libSQL (Turso) driver
 
 	"go.uber.org/zap"
 
 	"shotr/config"
-	"shotr/db"
+	"shotr/handlers/link"
+	"shotr/helpers"
+	"shotr/migrations"
+	"shotr/store"
 	"shotr/workers"
 )
 
@@ -36,35 +42,146 @@ func main() {
 		logger.Fatal("create data dir", zap.Error(err))
 	}
 
-	var dbConn *sql.DB
-	if cfg.DatabaseURL != "" {
+	if len(os.Args) > 1 && os.Args[1] == "restore" {
+		if cfg.DatabaseURL != "" {
+			logger.Fatal("restore: only the local sqlite file can be restored")
+		}
+		if err := runRestore(cfg, logger, os.Args[2:]); err != nil {
+			logger.Fatal("restore", zap.Error(err))
+		}
+		return
+	}
+
+	var dbConn, readConn *sql.DB
+	dialect := migrations.SQLite
+	if cfg.DatabaseURL == store.MemoryURL {
+		logger.Warn("using in-memory store; all links are lost on exit")
+	} else if strings.HasPrefix(cfg.DatabaseURL, "postgres://") || strings.HasPrefix(cfg.DatabaseURL, "postgresql://") {
+		logger.Info("using PostgreSQL DB")
+		dbConn, err = sql.Open("postgres", cfg.DatabaseURL)
+		if err != nil {
+			logger.Fatal("open postgres", zap.Error(err))
+		}
+		dialect = migrations.Postgres
+	} else if cfg.DatabaseURL != "" {
 		logger.Info("using libsql (Turso) DB", zap.String("url", cfg.DatabaseURL))
 		dbConn, err = sql.Open("libsql", cfg.DatabaseURL)
 		if err != nil {
 			logger.Fatal("open libsql", zap.Error(err))
 		}
 	} else {
-		logger.Info("using local sqlite file", zap.String("path", cfg.DatabasePath))
-		dbConn, err = sql.Open("sqlite3", cfg.DatabasePath)
+		logger.Info("using local sqlite file", zap.String("path", cfg.DatabasePath), zap.Int("readers", cfg.SQLiteReaders))
+		dbConn, readConn, err = openSQLite(cfg.DatabasePath, cfg.SQLiteReaders, cfg.SQLiteBusyTimeout)
 		if err != nil {
 			logger.Fatal("open sqlite", zap.Error(err))
 		}
-		_, _ = dbConn.Exec("PRAGMA journal_mode=WAL;")
-		_, _ = dbConn.Exec("PRAGMA synchronous=NORMAL;")
+		defer readConn.Close()
+	}
+
+	var st store.Store = store.NewMemory()
+	if dbConn != nil {
+		defer dbConn.Close()
+	}
+	switch {
+	case dialect == migrations.Postgres:
+		// postgres handles concurrent writers itself; use a real pool
+		dbConn.SetMaxOpenConns(16)
+		dbConn.SetMaxIdleConns(4)
+		st = store.NewPostgres(dbConn)
+	case dbConn != nil:
+		dbConn.SetMaxOpenConns(1)
+		dbConn.SetMaxIdleConns(1)
+		st = store.NewSQL(dbConn, readConn)
+	}
+
+	if len(os.Args) > 1 && os.Args[1] == "migrate" {
+		if dbConn == nil {
+			logger.Fatal("migrate: the in-memory store has no schema")
+		}
+		if err := runMigrate(dbConn, dialect, logger, os.Args[2:]); err != nil {
+			logger.Fatal("migrate", zap.Error(err))
+		}
+		return
+	}
+	if len(os.Args) > 1 && os.Args[1] == "backup" {
+		if cfg.DatabaseURL != "" {
+			logger.Fatal("backup: only the local sqlite file can be backed up; use your database's own tooling")
+		}
+		if err := runBackup(dbConn, cfg, os.Args[2:]); err != nil {
+			logger.Fatal("backup", zap.Error(err))
+		}
+		return
+	}
+	if dbConn != nil {
+		if err := checkSchema(dbConn, dialect, logger, cfg.AutoMigrate); err != nil {
+			logger.Fatal("database schema", zap.Error(err))
+		}
 	}
 
-	dbConn.SetMaxOpenConns(1)
-	dbConn.SetMaxIdleConns(1)
-	defer dbConn.Close()
+	policy, err := helpers.LoadURLPolicy(cfg.URLAllowlistPath, cfg.URLDenylistPath, cfg.ShortenerDomains)
+	if err != nil {
+		logger.Fatal("load url policy", zap.Error(err))
+	}
+
+	keyring, err := helpers.ParseKeyring(cfg.SlugSigningKeys)
+	if err != nil {
+		logger.Fatal("parse SLUG_SIGNING_KEYS", zap.Error(err))
+	}
 
-	q := db.New(dbConn)
+	if len(os.Args) > 1 && os.Args[1] == "export" {
+		if err := runExport(st, logger, os.Args[2:]); err != nil {
+			logger.Fatal("export", zap.Error(err))
+		}
+		return
+	}
+	if len(os.Args) > 1 && os.Args[1] == "import" {
+		// the parts of the link handler that vet destinations on create
+		vet := &link.Link{Store: st, Log: logger, BaseHost: cfg.BaseHost, Policy: policy, MaxChainDepth: cfg.MaxChainDepth, Keyring: keyring}
+		if cfg.StripTracking {
+			vet.TrackingParams = cfg.TrackingParams
+		}
+		if err := runImport(st, logger, vet, os.Args[2:]); err != nil {
+			logger.Fatal("import", zap.Error(err))
+		}
+		return
+	}
 
-	cw := workers.NewClickWorker(dbConn, q, logger, 400, 250*time.Millisecond, 8192)
+	cw := workers.NewClickWorker(st, logger, 400, 250*time.Millisecond, 8192)
 	cw.Start()
 	defer cw.Stop()
 
-	srv := NewServer(dbConn, logger, q, cfg.BaseHost)
-	srv.ClickWorkers = cw
+	if cfg.BackupInterval > 0 {
+		if cfg.DatabaseURL != "" {
+			logger.Warn("BACKUP_INTERVAL ignored; scheduled backups only cover the local sqlite file")
+		} else {
+			bw := workers.NewBackupWorker(dbConn, cfg.BackupDir, cfg.BackupKeep, cfg.BackupInterval, logger)
+			bw.Start()
+			defer bw.Stop()
+			logger.Info("scheduled backups enabled", zap.String("dir", cfg.BackupDir), zap.Duration("every", cfg.BackupInterval), zap.Int("keep", cfg.BackupKeep))
+		}
+	}
+
+	var geo *helpers.GeoIP
+	if cfg.GeoIPPath != "" {
+		geo, err = helpers.OpenGeoIP(cfg.GeoIPPath)
+		if err != nil {
+			logger.Fatal("open geoip db", zap.String("path", cfg.GeoIPPath), zap.Error(err))
+		}
+		defer geo.Close()
+		logger.Info("geoip lookups enabled", zap.String("path", cfg.GeoIPPath))
+	}
+
+	filter, err := helpers.LoadSlugFilter(cfg.SlugWordlistPath)
+	if err != nil {
+		logger.Fatal("load slug wordlist", zap.Error(err))
+	}
+
+	keys, err := helpers.ParseAPIKeys(cfg.APIKeys)
+	if err != nil {
+		logger.Fatal("parse API_KEYS", zap.Error(err))
+	}
+
+	srv := NewServer(st, logger, cfg, cw, geo, policy, keyring, filter, keys)
 
 	addr := fmt.Sprintf(":%s", cfg.Port)
 	logger.Info("starting server", zap.String("address", addr))
@@ -72,3 +189,30 @@ func main() {
 		logger.Fatal("server failed", zap.Error(err))
 	}
 }
+
+// openSQLite opens the sqlite file as a writer, which the caller limits to
+// one connection, and a pool of read-only connections. In WAL mode readers
+// don't block on the writer, so redirects don't queue behind click flushes.
+// Both wait up to busy for locks held by other processes, e.g. shotr
+// migrate, and the writer takes its lock when a transaction begins rather
+// than failing to upgrade halfway through.
+func openSQLite(path string, readers int, busy time.Duration) (*sql.DB, *sql.DB, error) {
+	timeout := strconv.FormatInt(busy.Milliseconds(), 10)
+	writer, err := sql.Open("sqlite3", path+"?_journal_mode=WAL&_synchronous=NORMAL&_txlock=immediate&_busy_timeout="+timeout)
+	if err != nil {
+		return nil, nil, err
+	}
+	// switch the file to WAL before any reader opens it
+	if err := writer.Ping(); err != nil {
+		writer.Close()
+		return nil, nil, err
+	}
+	reader, err := sql.Open("sqlite3", path+"?_query_only=true&_busy_timeout="+timeout)
+	if err != nil {
+		writer.Close()
+		return nil, nil, err
+	}
+	reader.SetMaxOpenConns(readers)
+	reader.SetMaxIdleConns(readers)
+	return writer, reader, nil
+}
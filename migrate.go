@@ -0,0 +1,55 @@
+package main
+
+import (
+	"context"
+	"database/sql"
+	"fmt"
+
+	"go.uber.org/zap"
+
+	"shotr/migrations"
+)
+
+// runMigrate implements "shotr migrate [up|status]".
+func runMigrate(dbConn *sql.DB, dialect *migrations.Dialect, logger *zap.Logger, args []string) error {
+	ctx := context.Background()
+	cmd := "up"
+	if len(args) > 0 {
+		cmd = args[0]
+	}
+
+	switch cmd {
+	case "up":
+		return dialect.Up(ctx, dbConn, logger)
+	case "status":
+		pending, current, err := dialect.Pending(ctx, dbConn)
+		if err != nil {
+			return err
+		}
+		fmt.Printf("current version: %d\nlatest version:  %d\n", current, dialect.Latest())
+		for _, m := range pending {
+			fmt.Printf("pending: %s\n", m.Name)
+		}
+		return nil
+	default:
+		return fmt.Errorf("unknown migrate command %q (want up or status)", cmd)
+	}
+}
+
+// checkSchema runs at startup: it applies pending migrations when auto is
+// set and otherwise refuses to run against an out of date schema. A schema
+// newer than this binary is always refused.
+func checkSchema(dbConn *sql.DB, dialect *migrations.Dialect, logger *zap.Logger, auto bool) error {
+	ctx := context.Background()
+	if auto {
+		return dialect.Up(ctx, dbConn, logger)
+	}
+	pending, current, err := dialect.Pending(ctx, dbConn)
+	if err != nil {
+		return err
+	}
+	if len(pending) > 0 {
+		return fmt.Errorf("schema is at version %d with %d pending migrations; run shotr migrate", current, len(pending))
+	}
+	return nil
+}
@@ -0,0 +1,12 @@
+-- +goose Up
+CREATE TABLE IF NOT EXISTS link_targets (
+  id INTEGER PRIMARY KEY AUTOINCREMENT,
+  slug TEXT NOT NULL,
+  kind TEXT NOT NULL,            -- rule dimension, e.g. "device"
+  value TEXT NOT NULL,           -- value matched against the visitor, e.g. "ios"
+  url TEXT NOT NULL,             -- destination when the rule matches
+  UNIQUE(slug, kind, value)
+);
+
+-- +goose Down
+DROP TABLE IF EXISTS link_targets;
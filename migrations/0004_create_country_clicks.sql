@@ -0,0 +1,12 @@
+-- +goose Up
+CREATE TABLE IF NOT EXISTS country_clicks (
+  id INTEGER PRIMARY KEY AUTOINCREMENT,
+  slug TEXT NOT NULL,
+  day DATE NOT NULL,
+  country TEXT NOT NULL,         -- ISO 3166-1 alpha-2 code
+  clicks INTEGER DEFAULT 0,
+  UNIQUE(slug, day, country)
+);
+
+-- +goose Down
+DROP TABLE IF EXISTS country_clicks;
@@ -0,0 +1,13 @@
+-- +goose Up
+CREATE TABLE IF NOT EXISTS link_variants (
+  id INTEGER PRIMARY KEY AUTOINCREMENT,
+  slug TEXT NOT NULL,
+  name TEXT NOT NULL,            -- variant label, e.g. "a"
+  url TEXT NOT NULL,
+  weight INTEGER NOT NULL,       -- relative share of traffic
+  clicks INTEGER DEFAULT 0,
+  UNIQUE(slug, name)
+);
+
+-- +goose Down
+DROP TABLE IF EXISTS link_variants;
@@ -0,0 +1,12 @@
+-- +goose Up
+CREATE TABLE IF NOT EXISTS language_clicks (
+  id INTEGER PRIMARY KEY AUTOINCREMENT,
+  slug TEXT NOT NULL,
+  day DATE NOT NULL,
+  language TEXT NOT NULL,        -- language mapping served, or "default"
+  clicks INTEGER DEFAULT 0,
+  UNIQUE(slug, day, language)
+);
+
+-- +goose Down
+DROP TABLE IF EXISTS language_clicks;
@@ -0,0 +1,13 @@
+-- +goose Up
+CREATE TABLE IF NOT EXISTS link_schedules (
+  id INTEGER PRIMARY KEY AUTOINCREMENT,
+  slug TEXT NOT NULL,
+  starts_at DATETIME DEFAULT NULL,  -- inclusive; NULL means open-ended
+  ends_at DATETIME DEFAULT NULL,    -- exclusive; NULL means open-ended
+  url TEXT NOT NULL
+);
+
+CREATE INDEX IF NOT EXISTS idx_link_schedules_slug ON link_schedules(slug);
+
+-- +goose Down
+DROP TABLE IF EXISTS link_schedules;
@@ -0,0 +1,5 @@
+-- +goose Up
+ALTER TABLE links ADD COLUMN password_hash TEXT DEFAULT NULL;  -- bcrypt hash; NULL means no password
+
+-- +goose Down
+ALTER TABLE links DROP COLUMN password_hash;
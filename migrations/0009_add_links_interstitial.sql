@@ -0,0 +1,5 @@
+-- +goose Up
+ALTER TABLE links ADD COLUMN interstitial BOOLEAN NOT NULL DEFAULT 0;  -- always show the preview page before redirecting
+
+-- +goose Down
+ALTER TABLE links DROP COLUMN interstitial;
@@ -0,0 +1,12 @@
+-- +goose Up
+CREATE TABLE IF NOT EXISTS source_clicks (
+  id INTEGER PRIMARY KEY AUTOINCREMENT,
+  slug TEXT NOT NULL,
+  day DATE NOT NULL,
+  source TEXT NOT NULL,          -- how the visitor arrived, e.g. "qr"
+  clicks INTEGER DEFAULT 0,
+  UNIQUE(slug, day, source)
+);
+
+-- +goose Down
+DROP TABLE IF EXISTS source_clicks;
@@ -0,0 +1,22 @@
+-- +goose Up
+CREATE TABLE IF NOT EXISTS reports (
+  id INTEGER PRIMARY KEY AUTOINCREMENT,
+  slug TEXT NOT NULL,
+  reason TEXT NOT NULL,          -- phishing | malware | spam | other
+  details TEXT DEFAULT NULL,
+  contact TEXT DEFAULT NULL,     -- optional reporter email
+  reporter_ip TEXT DEFAULT NULL,
+  status TEXT NOT NULL DEFAULT 'open',  -- open | actioned | dismissed
+  created_at DATETIME NOT NULL DEFAULT (datetime('now')),
+  resolved_at DATETIME DEFAULT NULL
+);
+
+CREATE INDEX IF NOT EXISTS idx_reports_status ON reports(status, created_at);
+
+ALTER TABLE links ADD COLUMN takedown_status INTEGER DEFAULT NULL;  -- 410 or 451 once taken down
+ALTER TABLE links ADD COLUMN takedown_reason TEXT DEFAULT NULL;
+
+-- +goose Down
+ALTER TABLE links DROP COLUMN takedown_reason;
+ALTER TABLE links DROP COLUMN takedown_status;
+DROP TABLE IF EXISTS reports;
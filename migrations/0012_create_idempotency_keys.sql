@@ -0,0 +1,24 @@
+-- +goose Up
+CREATE TABLE IF NOT EXISTS idempotency_keys (
+  owner TEXT NOT NULL,             -- API key name, '' without API keys
+  key TEXT NOT NULL,               -- the client's Idempotency-Key header
+  request_hash TEXT NOT NULL,      -- sha256 of the request, to catch a key reused for another request
+  status INTEGER NOT NULL,
+  body TEXT NOT NULL,              -- the response to replay
+  expires_at INTEGER NOT NULL,     -- unix seconds
+  PRIMARY KEY (owner, key)
+);
+
+CREATE INDEX IF NOT EXISTS idx_idempotency_keys_expires ON idempotency_keys(expires_at);
+
+CREATE TABLE IF NOT EXISTS link_destinations (
+  slug TEXT PRIMARY KEY,
+  owner TEXT NOT NULL,
+  url_key TEXT NOT NULL            -- normalized destination of a plain link
+);
+
+CREATE INDEX IF NOT EXISTS idx_link_destinations_owner ON link_destinations(owner, url_key);
+
+-- +goose Down
+DROP TABLE IF EXISTS link_destinations;
+DROP TABLE IF EXISTS idempotency_keys;
@@ -0,0 +1,5 @@
+-- +goose Up
+ALTER TABLE links ADD COLUMN original_url TEXT DEFAULT NULL;  -- the destination as submitted, when url is a canonicalized spelling of it
+
+-- +goose Down
+ALTER TABLE links DROP COLUMN original_url;
@@ -0,0 +1,322 @@
+// Package migrations embeds the schema migrations and applies them to
+// sqlite3 and libsql databases alike, or to PostgreSQL from the postgres
+// directory. Files use goose's annotations, so sqlc reads the same files
+// for the schema.
+package migrations
+
+import (
+	"bufio"
+	"context"
+	"database/sql"
+	"embed"
+	"fmt"
+	"io/fs"
+	"sort"
+	"strconv"
+	"strings"
+
+	"go.uber.org/zap"
+)
+
+//go:embed *.sql postgres/*.sql
+var files embed.FS
+
+// schemaTable records which migrations have been applied.
+const schemaTable = "schema_migrations"
+
+// Dialect is the SQL flavour of a database: which migration files apply to
+// it and how to ask about its tables.
+type Dialect struct {
+	Name         string
+	dir          string
+	bind         string // placeholder for the single bind parameter
+	timeType     string
+	now          string
+	tablesQuery  string
+	columnsQuery string
+	// markers[i] is the table, or "table.column", that migration i+1
+	// leaves behind. They date a database with no migration history.
+	markers []string
+}
+
+var (
+	SQLite = &Dialect{
+		Name:         "sqlite",
+		dir:          ".",
+		bind:         "?",
+		timeType:     "DATETIME",
+		now:          "(datetime('now'))",
+		tablesQuery:  `SELECT COUNT(*) FROM sqlite_master WHERE type = 'table' AND name = ?`,
+		columnsQuery: `SELECT COUNT(*) FROM pragma_table_info(?) WHERE name = ?`,
+		markers: []string{
+			"links",
+			"daily_clicks",
+			"link_targets",
+			"country_clicks",
+			"link_variants",
+			"language_clicks",
+			"link_schedules",
+			"links.password_hash",
+			"links.interstitial",
+			"source_clicks",
+			"links.takedown_reason",
+			"link_destinations",
+			"links.original_url",
+		},
+	}
+	Postgres = &Dialect{
+		Name:         "postgres",
+		dir:          "postgres",
+		bind:         "$1",
+		timeType:     "TIMESTAMPTZ",
+		now:          "now()",
+		tablesQuery:  `SELECT COUNT(*) FROM information_schema.tables WHERE table_schema = current_schema() AND table_name = $1`,
+		columnsQuery: `SELECT COUNT(*) FROM information_schema.columns WHERE table_schema = current_schema() AND table_name = $1 AND column_name = $2`,
+		markers:      []string{"reports", "link_destinations", "links.original_url"},
+	}
+)
+
+// Migration is one numbered file's Up statements.
+type Migration struct {
+	Version    int64
+	Name       string
+	Statements []string
+}
+
+// ErrSchemaTooNew is returned when the database has migrations this binary
+// doesn't know about, i.e. it was migrated by a newer release.
+type ErrSchemaTooNew struct {
+	Current, Latest int64
+}
+
+func (e *ErrSchemaTooNew) Error() string {
+	return fmt.Sprintf("database schema version %d is newer than this binary supports (%d); upgrade shotr", e.Current, e.Latest)
+}
+
+// All returns the dialect's embedded migrations in version order.
+func (d *Dialect) All() ([]Migration, error) {
+	dir, err := fs.Sub(files, d.dir)
+	if err != nil {
+		return nil, err
+	}
+	entries, err := fs.ReadDir(dir, ".")
+	if err != nil {
+		return nil, err
+	}
+	var out []Migration
+	for _, e := range entries {
+		num, _, ok := strings.Cut(e.Name(), "_")
+		if !ok {
+			continue
+		}
+		v, err := strconv.ParseInt(num, 10, 64)
+		if err != nil {
+			continue
+		}
+		body, err := fs.ReadFile(dir, e.Name())
+		if err != nil {
+			return nil, err
+		}
+		out = append(out, Migration{Version: v, Name: e.Name(), Statements: upStatements(string(body))})
+	}
+	sort.Slice(out, func(i, j int) bool { return out[i].Version < out[j].Version })
+	for i := 1; i < len(out); i++ {
+		if out[i].Version == out[i-1].Version {
+			return nil, fmt.Errorf("duplicate migration version %d", out[i].Version)
+		}
+	}
+	return out, nil
+}
+
+// Latest is the highest embedded migration version.
+func (d *Dialect) Latest() int64 {
+	all, err := d.All()
+	if err != nil || len(all) == 0 {
+		return 0
+	}
+	return all[len(all)-1].Version
+}
+
+// upStatements extracts the statements of a goose file's Up section.
+// Statements end at a line ending in ';', except inside a
+// StatementBegin/StatementEnd block.
+func upStatements(body string) []string {
+	var (
+		out   []string
+		cur   strings.Builder
+		up    bool
+		block bool
+	)
+	flush := func() {
+		if s := strings.TrimSpace(cur.String()); s != "" {
+			out = append(out, s)
+		}
+		cur.Reset()
+	}
+
+	sc := bufio.NewScanner(strings.NewReader(body))
+	for sc.Scan() {
+		line := sc.Text()
+		switch strings.TrimSpace(line) {
+		case "-- +goose Up":
+			up = true
+			continue
+		case "-- +goose Down":
+			up = false
+			continue
+		case "-- +goose StatementBegin":
+			block = true
+			continue
+		case "-- +goose StatementEnd":
+			block = false
+			flush()
+			continue
+		}
+		if !up {
+			continue
+		}
+		code := line
+		if i := strings.Index(code, "--"); i >= 0 && strings.Count(code[:i], "'")%2 == 0 {
+			code = code[:i]
+		}
+		if strings.TrimSpace(code) == "" && cur.Len() == 0 {
+			continue
+		}
+		cur.WriteString(code)
+		cur.WriteByte('\n')
+		if !block && strings.HasSuffix(strings.TrimSpace(code), ";") {
+			flush()
+		}
+	}
+	flush()
+	return out
+}
+
+// Current returns the highest applied version, creating the schema table
+// if needed. Databases migrated with goose, or created from the old
+// turso_*.sql files, are adopted at the version they are known to be at.
+func (d *Dialect) Current(ctx context.Context, db *sql.DB) (int64, error) {
+	if _, err := db.ExecContext(ctx, `CREATE TABLE IF NOT EXISTS `+schemaTable+` (
+  version BIGINT PRIMARY KEY,
+  applied_at `+d.timeType+` NOT NULL DEFAULT `+d.now+`
+)`); err != nil {
+		return 0, err
+	}
+
+	var v sql.NullInt64
+	if err := db.QueryRowContext(ctx, `SELECT MAX(version) FROM `+schemaTable).Scan(&v); err != nil {
+		return 0, err
+	}
+	if v.Valid {
+		return v.Int64, nil
+	}
+
+	adopt, err := d.adoptVersion(ctx, db)
+	if err != nil || adopt == 0 {
+		return 0, err
+	}
+	if _, err := db.ExecContext(ctx, `INSERT INTO `+schemaTable+` (version) VALUES (`+d.bind+`)`, adopt); err != nil {
+		return 0, err
+	}
+	return adopt, nil
+}
+
+// adoptVersion works out the version of a database that predates the
+// schema table: goose's own bookkeeping if present, otherwise the last
+// migration whose marker, and every earlier one, exists. The old
+// turso_*.sql files were kept by hand and may stop anywhere along the way.
+// An empty database is at 0.
+func (d *Dialect) adoptVersion(ctx context.Context, db *sql.DB) (int64, error) {
+	if exists, err := d.tableExists(ctx, db, "goose_db_version"); err != nil || exists {
+		if err != nil {
+			return 0, err
+		}
+		var v sql.NullInt64
+		err := db.QueryRowContext(ctx, `SELECT MAX(version_id) FROM goose_db_version WHERE is_applied`).Scan(&v)
+		return v.Int64, err
+	}
+	for i, marker := range d.markers {
+		var exists bool
+		var err error
+		if table, column, ok := strings.Cut(marker, "."); ok {
+			exists, err = d.columnExists(ctx, db, table, column)
+		} else {
+			exists, err = d.tableExists(ctx, db, marker)
+		}
+		if err != nil || !exists {
+			return int64(i), err
+		}
+	}
+	return int64(len(d.markers)), nil
+}
+
+func (d *Dialect) tableExists(ctx context.Context, db *sql.DB, name string) (bool, error) {
+	var n int
+	err := db.QueryRowContext(ctx, d.tablesQuery, name).Scan(&n)
+	return n > 0, err
+}
+
+func (d *Dialect) columnExists(ctx context.Context, db *sql.DB, table, column string) (bool, error) {
+	var n int
+	err := db.QueryRowContext(ctx, d.columnsQuery, table, column).Scan(&n)
+	return n > 0, err
+}
+
+// Pending returns the migrations not yet applied, or *ErrSchemaTooNew.
+func (d *Dialect) Pending(ctx context.Context, db *sql.DB) ([]Migration, int64, error) {
+	all, err := d.All()
+	if err != nil {
+		return nil, 0, err
+	}
+	current, err := d.Current(ctx, db)
+	if err != nil {
+		return nil, 0, err
+	}
+	if latest := all[len(all)-1].Version; current > latest {
+		return nil, current, &ErrSchemaTooNew{Current: current, Latest: latest}
+	}
+	var pending []Migration
+	for _, m := range all {
+		if m.Version > current {
+			pending = append(pending, m)
+		}
+	}
+	return pending, current, nil
+}
+
+// Up applies every pending migration, each in its own transaction.
+func (d *Dialect) Up(ctx context.Context, db *sql.DB, log *zap.Logger) error {
+	pending, current, err := d.Pending(ctx, db)
+	if err != nil {
+		return err
+	}
+	if len(pending) == 0 {
+		log.Info("database schema up to date", zap.Int64("version", current))
+		return nil
+	}
+	for _, m := range pending {
+		if err := d.apply(ctx, db, m); err != nil {
+			return fmt.Errorf("migration %s: %w", m.Name, err)
+		}
+		log.Info("applied migration", zap.String("name", m.Name))
+	}
+	return nil
+}
+
+func (d *Dialect) apply(ctx context.Context, db *sql.DB, m Migration) error {
+	tx, err := db.BeginTx(ctx, nil)
+	if err != nil {
+		return err
+	}
+	for _, stmt := range m.Statements {
+		if _, err := tx.ExecContext(ctx, stmt); err != nil {
+			_ = tx.Rollback()
+			return err
+		}
+	}
+	if _, err := tx.ExecContext(ctx, `INSERT INTO `+schemaTable+` (version) VALUES (`+d.bind+`)`, m.Version); err != nil {
+		_ = tx.Rollback()
+		return err
+	}
+	return tx.Commit()
+}
@@ -0,0 +1,104 @@
+-- +goose Up
+-- Postgres schema, equivalent to sqlite migrations 0001-0011. Integer
+-- columns are BIGINT so generated code matches the sqlite models.
+CREATE TABLE IF NOT EXISTS links (
+  id BIGSERIAL PRIMARY KEY,
+  slug TEXT NOT NULL UNIQUE,
+  url TEXT NOT NULL,
+  "user" TEXT DEFAULT NULL,
+  created_at TIMESTAMPTZ NOT NULL DEFAULT now(),
+  clicks BIGINT DEFAULT 0,
+  password_hash TEXT DEFAULT NULL,
+  interstitial BOOLEAN NOT NULL DEFAULT false,
+  takedown_status BIGINT DEFAULT NULL,
+  takedown_reason TEXT DEFAULT NULL
+);
+
+CREATE TABLE IF NOT EXISTS daily_clicks (
+  id BIGSERIAL PRIMARY KEY,
+  slug TEXT NOT NULL,
+  day DATE NOT NULL,
+  clicks BIGINT DEFAULT 0,
+  UNIQUE(slug, day)
+);
+
+CREATE TABLE IF NOT EXISTS link_targets (
+  id BIGSERIAL PRIMARY KEY,
+  slug TEXT NOT NULL,
+  kind TEXT NOT NULL,
+  value TEXT NOT NULL,
+  url TEXT NOT NULL,
+  UNIQUE(slug, kind, value)
+);
+
+CREATE TABLE IF NOT EXISTS country_clicks (
+  id BIGSERIAL PRIMARY KEY,
+  slug TEXT NOT NULL,
+  day DATE NOT NULL,
+  country TEXT NOT NULL,
+  clicks BIGINT DEFAULT 0,
+  UNIQUE(slug, day, country)
+);
+
+CREATE TABLE IF NOT EXISTS link_variants (
+  id BIGSERIAL PRIMARY KEY,
+  slug TEXT NOT NULL,
+  name TEXT NOT NULL,
+  url TEXT NOT NULL,
+  weight BIGINT NOT NULL,
+  clicks BIGINT DEFAULT 0,
+  UNIQUE(slug, name)
+);
+
+CREATE TABLE IF NOT EXISTS language_clicks (
+  id BIGSERIAL PRIMARY KEY,
+  slug TEXT NOT NULL,
+  day DATE NOT NULL,
+  language TEXT NOT NULL,
+  clicks BIGINT DEFAULT 0,
+  UNIQUE(slug, day, language)
+);
+
+CREATE TABLE IF NOT EXISTS link_schedules (
+  id BIGSERIAL PRIMARY KEY,
+  slug TEXT NOT NULL,
+  starts_at TIMESTAMPTZ DEFAULT NULL,
+  ends_at TIMESTAMPTZ DEFAULT NULL,
+  url TEXT NOT NULL
+);
+
+CREATE INDEX IF NOT EXISTS idx_link_schedules_slug ON link_schedules(slug);
+
+CREATE TABLE IF NOT EXISTS source_clicks (
+  id BIGSERIAL PRIMARY KEY,
+  slug TEXT NOT NULL,
+  day DATE NOT NULL,
+  source TEXT NOT NULL,
+  clicks BIGINT DEFAULT 0,
+  UNIQUE(slug, day, source)
+);
+
+CREATE TABLE IF NOT EXISTS reports (
+  id BIGSERIAL PRIMARY KEY,
+  slug TEXT NOT NULL,
+  reason TEXT NOT NULL,
+  details TEXT DEFAULT NULL,
+  contact TEXT DEFAULT NULL,
+  reporter_ip TEXT DEFAULT NULL,
+  status TEXT NOT NULL DEFAULT 'open',
+  created_at TIMESTAMPTZ NOT NULL DEFAULT now(),
+  resolved_at TIMESTAMPTZ DEFAULT NULL
+);
+
+CREATE INDEX IF NOT EXISTS idx_reports_status ON reports(status, created_at);
+
+-- +goose Down
+DROP TABLE IF EXISTS reports;
+DROP TABLE IF EXISTS source_clicks;
+DROP TABLE IF EXISTS link_schedules;
+DROP TABLE IF EXISTS language_clicks;
+DROP TABLE IF EXISTS link_variants;
+DROP TABLE IF EXISTS country_clicks;
+DROP TABLE IF EXISTS link_targets;
+DROP TABLE IF EXISTS daily_clicks;
+DROP TABLE IF EXISTS links;
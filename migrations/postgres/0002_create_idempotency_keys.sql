@@ -0,0 +1,25 @@
+-- +goose Up
+-- sqlite migration 0012.
+CREATE TABLE IF NOT EXISTS idempotency_keys (
+  owner TEXT NOT NULL,
+  key TEXT NOT NULL,
+  request_hash TEXT NOT NULL,
+  status BIGINT NOT NULL,
+  body TEXT NOT NULL,
+  expires_at BIGINT NOT NULL,
+  PRIMARY KEY (owner, key)
+);
+
+CREATE INDEX IF NOT EXISTS idx_idempotency_keys_expires ON idempotency_keys(expires_at);
+
+CREATE TABLE IF NOT EXISTS link_destinations (
+  slug TEXT PRIMARY KEY,
+  owner TEXT NOT NULL,
+  url_key TEXT NOT NULL
+);
+
+CREATE INDEX IF NOT EXISTS idx_link_destinations_owner ON link_destinations(owner, url_key);
+
+-- +goose Down
+DROP TABLE IF EXISTS link_destinations;
+DROP TABLE IF EXISTS idempotency_keys;
@@ -0,0 +1,6 @@
+-- +goose Up
+-- sqlite migration 0013.
+ALTER TABLE links ADD COLUMN IF NOT EXISTS original_url TEXT DEFAULT NULL;
+
+-- +goose Down
+ALTER TABLE links DROP COLUMN IF EXISTS original_url;
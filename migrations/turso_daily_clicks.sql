@@ -1,7 +0,0 @@
-CREATE TABLE IF NOT EXISTS daily_clicks (
-  id INTEGER PRIMARY KEY AUTOINCREMENT,
-  slug TEXT NOT NULL,
-  day DATE NOT NULL,
-  clicks INTEGER DEFAULT 0,
-  UNIQUE(slug, day)
-);
\ No newline at end of file
@@ -1,30 +1,37 @@
 package main
 
 import (
-	"database/sql"
 	"net/http"
+	"time"
 
 	lru "github.com/hashicorp/golang-lru"
 	"github.com/labstack/echo/v4"
 	"github.com/labstack/echo/v4/middleware"
 	"go.uber.org/zap"
 
-	"shotr/db"
+	"shotr/config"
+	"shotr/helpers"
 	link "shotr/handlers/link"
+	"shotr/store"
 	"shotr/workers"
 )
 
 type Server struct {
 	E        *echo.Echo
-	DB       *sql.DB
-	Q        *db.Queries
+	Store    store.Store
 	Log      *zap.Logger
 	BaseHost string
+	Cfg      *config.Config
 	ClickWorkers *workers.ClickWorker
 	Cache *lru.Cache
+	Geo   *helpers.GeoIP
+	Policy *helpers.URLPolicy
+	Keyring *helpers.Keyring
+	SlugFilter *helpers.SlugFilter
+	APIKeys map[string]string
 }
 
-func NewServer(dbConn *sql.DB, log *zap.Logger, q *db.Queries, baseHost string) *Server {
+func NewServer(st store.Store, log *zap.Logger, cfg *config.Config, cw *workers.ClickWorker, geo *helpers.GeoIP, policy *helpers.URLPolicy, keyring *helpers.Keyring, filter *helpers.SlugFilter, keys map[string]string) *Server {
 	e := echo.New()
 
 	// essential middleware only
@@ -35,12 +42,35 @@ func NewServer(dbConn *sql.DB, log *zap.Logger, q *db.Queries, baseHost string)
 	e.HideBanner = true
 	e.HidePort = true
 
+	// client IPs key rate limits, quotas and abuse reports, so forwarding
+	// headers only count when they come from a configured proxy
+	if len(cfg.TrustedProxies) > 0 {
+		trust := []echo.TrustOption{echo.TrustLoopback(false), echo.TrustLinkLocal(false), echo.TrustPrivateNet(false)}
+		for _, p := range cfg.TrustedProxies {
+			trust = append(trust, echo.TrustIPRange(p))
+		}
+		e.IPExtractor = echo.ExtractIPFromXFFHeader(trust...)
+	} else {
+		e.IPExtractor = echo.ExtractIPDirect()
+	}
+
 	s := &Server{
 		E:        e,
-		DB:       dbConn,
-		Q:        q,
+		Store:    st,
 		Log:      log,
-		BaseHost: baseHost,
+		BaseHost: cfg.BaseHost,
+		Cfg:      cfg,
+		ClickWorkers: cw,
+		Geo:      geo,
+		Policy:   policy,
+		Keyring:  keyring,
+		SlugFilter: filter,
+		APIKeys:  keys,
+	}
+
+	if cfg.CacheSize > 0 {
+		// lru.New only fails for a non-positive size
+		s.Cache, _ = lru.New(cfg.CacheSize)
 	}
 
 	s.routes()
@@ -52,12 +82,40 @@ func (s *Server) routes() {
 		return c.String(http.StatusOK, "ok")
 	})
 
-	link := link.New(s.Q, s.Log, s.BaseHost, s.ClickWorkers, s.Cache)
+	link := link.New(s.Store, s.Log, s.Cfg, s.ClickWorkers, s.Cache, s.Geo, s.Policy, s.Keyring, s.SlugFilter)
 
-	s.E.POST("/api/v1/links", link.Create)
-	s.E.GET("/:slug", link.Redirect)
-	s.E.HEAD("/:slug", link.Redirect)
-	s.E.GET("/api/v1/links/:slug/stats", link.Stats)
+	// creating links needs an API key once any are configured
+	var keyed []echo.MiddlewareFunc
+	if len(s.APIKeys) > 0 {
+		keyed = append(keyed, helpers.RequireAPIKey(s.APIKeys))
+	}
+	s.E.POST("/api/v1/links", link.Create, keyed...)
+	s.E.POST("/api/v1/links/batch", link.Batch, keyed...)
+	// links can be changed by the API key that made them, or by the admin
+	if len(s.APIKeys) > 0 || s.Cfg.AdminToken != "" {
+		s.E.PATCH("/api/v1/links/:slug", link.Update, helpers.RequireKeyOrBearer(s.APIKeys, s.Cfg.AdminToken))
+	} else {
+		s.Log.Info("link updates disabled; set API_KEYS or ADMIN_TOKEN to enable them")
+	}
+	s.E.GET("/:slug", link.Redirect, link.CheckSignature)
+	s.E.POST("/:slug", link.Unlock, link.CheckSignature)
+	s.E.HEAD("/:slug", link.Redirect, link.CheckSignature)
+	s.E.GET("/api/v1/links/:slug/stats", link.Stats, link.CheckSignature)
+	s.E.GET("/api/v1/links/:slug/qr", link.QR, link.CheckSignature)
+
+	// public abuse reports; keep it cheap to stop report flooding
+	s.E.POST("/:slug/report", link.Report, link.CheckSignature, helpers.NewRateLimiter(10, time.Hour).Middleware)
+
+	if s.Cfg.AdminToken == "" {
+		s.Log.Info("admin API disabled; set ADMIN_TOKEN to enable it")
+		return
+	}
+	admin := s.E.Group("/api/v1/admin", helpers.RequireBearer(s.Cfg.AdminToken))
+	admin.GET("/reports", link.ListReports)
+	admin.POST("/reports/:id/resolve", link.ResolveReport)
+	admin.POST("/links/:slug/takedown", link.Takedown)
+	admin.DELETE("/links/:slug/takedown", link.Restore)
+	admin.GET("/slugs", link.SlugMetrics)
 }
 
 func (s *Server) Start(addr string) error {
@@ -0,0 +1,121 @@
+package main
+
+import (
+	"context"
+	"fmt"
+	"path/filepath"
+	"sync"
+	"testing"
+	"time"
+
+	"go.uber.org/zap"
+
+	"shotr/db"
+	"shotr/migrations"
+	"shotr/store"
+)
+
+// benchLinks is how many links the redirect benchmarks look up and the
+// click load writes to.
+const benchLinks = 200
+
+// BenchmarkRedirectUnderClickLoad measures the redirect path's reads
+// while a click flush commits back to back, once with every query on the
+// single writer connection and once with reads on the read-only pool.
+func BenchmarkRedirectUnderClickLoad(b *testing.B) {
+	for _, readers := range []int{0, 4} {
+		name := "writer-only"
+		if readers > 0 {
+			name = fmt.Sprintf("read-pool-%d", readers)
+		}
+		b.Run(name, func(b *testing.B) {
+			st := benchStore(b, readers)
+			ctx := context.Background()
+
+			stop := make(chan struct{})
+			var wg sync.WaitGroup
+			wg.Add(1)
+			go func() {
+				defer wg.Done()
+				for {
+					select {
+					case <-stop:
+						return
+					default:
+					}
+					batch := store.NewClickBatch()
+					for i := 0; i < benchLinks; i++ {
+						batch.Links[benchSlug(i)]++
+						batch.Countries[store.BreakdownKey{Slug: benchSlug(i), Value: "DE"}]++
+					}
+					if _, err := st.FlushClicks(ctx, batch); err != nil {
+						b.Error(err)
+						return
+					}
+				}
+			}()
+
+			b.ResetTimer()
+			b.RunParallel(func(pb *testing.PB) {
+				i := 0
+				for pb.Next() {
+					slug := benchSlug(i % benchLinks)
+					i++
+					if _, err := st.GetLink(ctx, slug); err != nil {
+						b.Error(err)
+						return
+					}
+					if _, err := st.GetLinkTargets(ctx, slug); err != nil {
+						b.Error(err)
+						return
+					}
+					if _, err := st.GetLinkVariants(ctx, slug); err != nil {
+						b.Error(err)
+						return
+					}
+					if _, err := st.GetLinkSchedules(ctx, slug); err != nil {
+						b.Error(err)
+						return
+					}
+				}
+			})
+			b.StopTimer()
+			close(stop)
+			wg.Wait()
+		})
+	}
+}
+
+// benchStore opens a migrated sqlite file the way main does, with a read
+// pool of readers connections or none, and fills it with benchLinks links.
+func benchStore(b *testing.B, readers int) *store.SQL {
+	b.Helper()
+	writer, reader, err := openSQLite(filepath.Join(b.TempDir(), "bench.db"), max(readers, 1), 5*time.Second)
+	if err != nil {
+		b.Fatal(err)
+	}
+	writer.SetMaxOpenConns(1)
+	b.Cleanup(func() {
+		reader.Close()
+		writer.Close()
+	})
+	if err := migrations.SQLite.Up(context.Background(), writer, zap.NewNop()); err != nil {
+		b.Fatal(err)
+	}
+
+	st := store.NewSQL(writer, nil)
+	if readers > 0 {
+		st = store.NewSQL(writer, reader)
+	}
+	ctx := context.Background()
+	for i := 0; i < benchLinks; i++ {
+		if _, err := st.AddLink(ctx, db.AddLinkParams{Slug: benchSlug(i), Url: "https://example.com/"}); err != nil {
+			b.Fatal(err)
+		}
+	}
+	return st
+}
+
+func benchSlug(i int) string {
+	return fmt.Sprintf("bench%d", i)
+}
@@ -0,0 +1,214 @@
+package store
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+	"fmt"
+	"os"
+	"path/filepath"
+	"sort"
+	"strings"
+	"time"
+)
+
+// Backups are named backupPrefix + UTC timestamp + backupSuffix so that
+// sorting the names sorts them by age.
+const (
+	backupPrefix = "shotr-"
+	backupSuffix = ".sqlite3"
+	backupStamp  = "20060102T150405Z"
+)
+
+// Backup writes a consistent snapshot of the sqlite database behind conn
+// into dir with VACUUM INTO, which reads inside one transaction and so is
+// safe while the server keeps writing. Snapshots beyond the newest keep are
+// deleted; keep <= 0 keeps them all. It returns the new file's path.
+func Backup(ctx context.Context, conn *sql.DB, dir string, keep int) (string, error) {
+	if err := os.MkdirAll(dir, 0o755); err != nil {
+		return "", err
+	}
+	path := filepath.Join(dir, backupPrefix+time.Now().UTC().Format(backupStamp)+backupSuffix)
+	if _, err := os.Stat(path); err == nil {
+		return "", fmt.Errorf("backup %s already exists", path)
+	}
+
+	// write under a temporary name so a half-written file never looks
+	// like a backup
+	tmp := path + ".tmp"
+	_ = os.Remove(tmp)
+	if _, err := conn.ExecContext(ctx, `VACUUM INTO ?`, tmp); err != nil {
+		_ = os.Remove(tmp)
+		return "", fmt.Errorf("vacuum into %s: %w", tmp, err)
+	}
+	if err := os.Rename(tmp, path); err != nil {
+		_ = os.Remove(tmp)
+		return "", err
+	}
+
+	if keep > 0 {
+		if err := pruneBackups(dir, keep); err != nil {
+			return path, fmt.Errorf("prune old backups: %w", err)
+		}
+	}
+	return path, nil
+}
+
+// pruneBackups deletes all but the newest keep backups in dir.
+func pruneBackups(dir string, keep int) error {
+	entries, err := os.ReadDir(dir)
+	if err != nil {
+		return err
+	}
+	var names []string
+	for _, e := range entries {
+		if n := e.Name(); !e.IsDir() && strings.HasPrefix(n, backupPrefix) && strings.HasSuffix(n, backupSuffix) {
+			names = append(names, n)
+		}
+	}
+	sort.Strings(names)
+	for len(names) > keep {
+		if err := os.Remove(filepath.Join(dir, names[0])); err != nil {
+			return err
+		}
+		names = names[1:]
+	}
+	return nil
+}
+
+// CheckBackup opens a backup read-only and checks that it is an intact
+// shotr database.
+func CheckBackup(ctx context.Context, path string) error {
+	if _, err := os.Stat(path); err != nil {
+		return err
+	}
+	conn, err := sql.Open("sqlite3", "file:"+path+"?mode=ro")
+	if err != nil {
+		return err
+	}
+	defer conn.Close()
+
+	rows, err := conn.QueryContext(ctx, `PRAGMA integrity_check`)
+	if err != nil {
+		return err
+	}
+	defer rows.Close()
+	var problems []string
+	for rows.Next() {
+		var msg string
+		if err := rows.Scan(&msg); err != nil {
+			return err
+		}
+		if msg != "ok" {
+			problems = append(problems, msg)
+		}
+	}
+	if err := rows.Err(); err != nil {
+		return err
+	}
+	if len(problems) > 0 {
+		return fmt.Errorf("integrity check failed: %s", strings.Join(problems, "; "))
+	}
+
+	var links int64
+	if err := conn.QueryRowContext(ctx, `SELECT COUNT(*) FROM links`).Scan(&links); err != nil {
+		return fmt.Errorf("not a shotr database: %w", err)
+	}
+	return nil
+}
+
+// Restore replaces the sqlite database at dst with the backup at src. The
+// backup is checked first, then copied next to dst and checked again, and
+// only then swapped in. The replaced database and its WAL files are kept
+// with a .pre-restore suffix. dst is locked exclusively for the swap, so
+// Restore fails while a server or anything else has it open.
+func Restore(ctx context.Context, src, dst string) (string, error) {
+	if err := CheckBackup(ctx, src); err != nil {
+		return "", fmt.Errorf("%s: %w", src, err)
+	}
+
+	tmp := dst + ".restore"
+	if err := copyFile(src, tmp); err != nil {
+		_ = os.Remove(tmp)
+		return "", err
+	}
+	if err := CheckBackup(ctx, tmp); err != nil {
+		_ = os.Remove(tmp)
+		return "", fmt.Errorf("copy of %s: %w", src, err)
+	}
+
+	if _, err := os.Stat(dst); err == nil {
+		conn, err := lockDatabase(ctx, dst)
+		if err != nil {
+			_ = os.Remove(tmp)
+			return "", fmt.Errorf("lock %s (is the server still running?): %w", dst, err)
+		}
+		defer conn.Close()
+	}
+
+	aside := dst + ".pre-restore-" + time.Now().UTC().Format(backupStamp)
+	for _, suffix := range []string{"", "-wal", "-shm"} {
+		err := os.Rename(dst+suffix, aside+suffix)
+		if err != nil && !errors.Is(err, os.ErrNotExist) {
+			_ = os.Remove(tmp)
+			return "", err
+		}
+	}
+	if err := os.Rename(tmp, dst); err != nil {
+		return "", err
+	}
+	return aside, nil
+}
+
+// lockDatabase takes an exclusive lock on the database at path that lasts
+// until the returned handle is closed, then folds the WAL back into the
+// file so that the file alone is the whole database. Every connection to
+// a WAL database holds a shared lock for as long as it is open, idle or
+// not, so this fails while another process has the database open.
+func lockDatabase(ctx context.Context, path string) (*sql.DB, error) {
+	conn, err := sql.Open("sqlite3", path+"?_locking_mode=EXCLUSIVE&_busy_timeout=1000")
+	if err != nil {
+		return nil, err
+	}
+	// the lock belongs to one connection
+	conn.SetMaxOpenConns(1)
+
+	// in exclusive locking mode the lock outlives the transaction
+	for _, stmt := range []string{`BEGIN EXCLUSIVE`, `COMMIT`} {
+		if _, err := conn.ExecContext(ctx, stmt); err != nil {
+			conn.Close()
+			return nil, err
+		}
+	}
+	var busy, logFrames, done int
+	if err := conn.QueryRowContext(ctx, `PRAGMA wal_checkpoint(TRUNCATE)`).Scan(&busy, &logFrames, &done); err != nil {
+		conn.Close()
+		return nil, err
+	}
+	if busy != 0 {
+		conn.Close()
+		return nil, errors.New("database is busy")
+	}
+	return conn, nil
+}
+
+func copyFile(src, dst string) error {
+	in, err := os.Open(src)
+	if err != nil {
+		return err
+	}
+	defer in.Close()
+	out, err := os.OpenFile(dst, os.O_WRONLY|os.O_CREATE|os.O_TRUNC, 0o644)
+	if err != nil {
+		return err
+	}
+	if _, err := out.ReadFrom(in); err != nil {
+		out.Close()
+		return err
+	}
+	if err := out.Sync(); err != nil {
+		out.Close()
+		return err
+	}
+	return out.Close()
+}
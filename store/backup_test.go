@@ -0,0 +1,97 @@
+package store_test
+
+import (
+	"context"
+	"database/sql"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"shotr/store"
+)
+
+// countLinks opens the database at path just long enough to count its
+// links.
+func countLinks(t *testing.T, path string) int64 {
+	t.Helper()
+	conn := openFile(t, path)
+	defer conn.Close()
+	n, err := store.NewSQL(conn, nil).CountLinks(context.Background())
+	if err != nil {
+		t.Fatal(err)
+	}
+	return n
+}
+
+func TestBackupAndRestore(t *testing.T) {
+	ctx := context.Background()
+	dir := t.TempDir()
+	path := filepath.Join(dir, "shotr.db")
+	conn := openFile(t, path)
+	st := store.NewSQL(conn, nil)
+	addLink(t, st, "a", "https://example.com/a")
+	addLink(t, st, "b", "https://example.com/b")
+
+	backup, err := store.Backup(ctx, conn, filepath.Join(dir, "backups"), 0)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if err := store.CheckBackup(ctx, backup); err != nil {
+		t.Fatalf("CheckBackup(%s): %v", backup, err)
+	}
+	addLink(t, st, "c", "https://example.com/c")
+
+	// the connection is idle but open, as a running server's would be
+	if _, err := store.Restore(ctx, backup, path); err == nil {
+		t.Fatal("Restore succeeded while the database was open")
+	}
+	if _, err := st.GetLink(ctx, "c"); err != nil {
+		t.Fatalf("database changed by the refused restore: %v", err)
+	}
+	if _, err := os.Stat(path + ".restore"); !os.IsNotExist(err) {
+		t.Errorf("refused restore left %s.restore behind", path)
+	}
+	conn.Close()
+
+	aside, err := store.Restore(ctx, backup, path)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if n := countLinks(t, path); n != 2 {
+		t.Errorf("restored database has %d links, want the 2 backed up", n)
+	}
+	if err := store.CheckBackup(ctx, aside); err != nil {
+		t.Errorf("CheckBackup(%s): %v", aside, err)
+	}
+	if n := countLinks(t, aside); n != 3 {
+		t.Errorf("database set aside has %d links, want 3", n)
+	}
+}
+
+func TestCheckBackupRejects(t *testing.T) {
+	ctx := context.Background()
+	dir := t.TempDir()
+
+	garbage := filepath.Join(dir, "garbage.sqlite3")
+	if err := os.WriteFile(garbage, []byte("not a database"), 0o644); err != nil {
+		t.Fatal(err)
+	}
+	empty := filepath.Join(dir, "empty.sqlite3")
+	conn, err := sql.Open("sqlite3", empty)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if _, err := conn.Exec(`CREATE TABLE other (x)`); err != nil {
+		t.Fatal(err)
+	}
+	conn.Close()
+
+	for _, path := range []string{garbage, empty, filepath.Join(dir, "missing.sqlite3")} {
+		if err := store.CheckBackup(ctx, path); err == nil {
+			t.Errorf("CheckBackup(%s) = nil", filepath.Base(path))
+		}
+		if _, err := store.Restore(ctx, path, filepath.Join(dir, "shotr.db")); err == nil {
+			t.Errorf("Restore(%s) = nil", filepath.Base(path))
+		}
+	}
+}
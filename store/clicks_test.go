@@ -0,0 +1,143 @@
+package store_test
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+	"slices"
+	"testing"
+
+	"shotr/db"
+	"shotr/store"
+)
+
+func TestFlushClicks(t *testing.T) {
+	forEachStore(t, func(t *testing.T, st store.Store) {
+		ctx := context.Background()
+		addLink(t, st, "abc", "https://example.com/")
+		addLink(t, st, "def", "https://example.com/")
+		if err := st.AddLinkVariant(ctx, db.AddLinkVariantParams{Slug: "abc", Name: "a", Url: "https://example.com/a", Weight: 1}); err != nil {
+			t.Fatal(err)
+		}
+
+		batch := store.NewClickBatch()
+		batch.Links["abc"] = 3
+		batch.Links["def"] = 1
+		batch.Countries[store.BreakdownKey{Slug: "abc", Value: "DE"}] = 2
+		batch.Countries[store.BreakdownKey{Slug: "abc", Value: "FR"}] = 1
+		batch.Sources[store.BreakdownKey{Slug: "def", Value: "qr"}] = 1
+		batch.Variants[store.BreakdownKey{Slug: "abc", Value: "a"}] = 3
+		// twice, to check that counters add up rather than overwrite
+		for i := 0; i < 2; i++ {
+			orphaned, err := st.FlushClicks(ctx, batch)
+			if err != nil {
+				t.Fatal(err)
+			}
+			if len(orphaned) != 0 {
+				t.Fatalf("orphaned = %v, want none", orphaned)
+			}
+		}
+
+		for slug, want := range map[string]int64{"abc": 6, "def": 2} {
+			link, err := st.GetLinkStats(ctx, slug)
+			if err != nil {
+				t.Fatal(err)
+			}
+			if link.Clicks.Int64 != want {
+				t.Errorf("%s clicks = %d, want %d", slug, link.Clicks.Int64, want)
+			}
+			daily, err := st.GetDailyClicks(ctx, slug)
+			if err != nil {
+				t.Fatal(err)
+			}
+			if len(daily) != 1 || daily[0].Clicks.Int64 != want {
+				t.Errorf("%s daily = %+v, want one day of %d", slug, daily, want)
+			}
+		}
+
+		countries, err := st.GetCountryClicks(ctx, "abc")
+		if err != nil {
+			t.Fatal(err)
+		}
+		got := map[string]int64{}
+		for _, c := range countries {
+			got[c.Country] = c.Clicks
+		}
+		if got["DE"] != 4 || got["FR"] != 2 || len(got) != 2 {
+			t.Errorf("countries = %v, want DE 4 and FR 2", got)
+		}
+		if sources, _ := st.GetSourceClicks(ctx, "def"); len(sources) != 1 || sources[0].Clicks != 2 {
+			t.Errorf("sources = %+v, want qr 2", sources)
+		}
+		if variants, _ := st.GetLinkVariants(ctx, "abc"); len(variants) != 1 || variants[0].Clicks.Int64 != 6 {
+			t.Errorf("variants = %+v, want a with 6 clicks", variants)
+		}
+	})
+}
+
+// Clicks for a slug that has no link, e.g. one deleted while its clicks
+// were queued, must not create a row or any breakdown, and must not stop
+// the rest of the batch.
+func TestFlushClicksDropsOrphans(t *testing.T) {
+	forEachStore(t, func(t *testing.T, st store.Store) {
+		ctx := context.Background()
+		addLink(t, st, "kept", "https://example.com/")
+		gone := addLink(t, st, "gone", "https://example.com/")
+		if err := st.DeleteLinkByID(ctx, gone.ID); err != nil {
+			t.Fatal(err)
+		}
+
+		batch := store.NewClickBatch()
+		for _, slug := range []string{"kept", "gone", "never"} {
+			batch.Links[slug] = 2
+			batch.Countries[store.BreakdownKey{Slug: slug, Value: "DE"}] = 2
+			batch.Languages[store.BreakdownKey{Slug: slug, Value: "en"}] = 2
+		}
+		orphaned, err := st.FlushClicks(ctx, batch)
+		if err != nil {
+			t.Fatal(err)
+		}
+		slices.Sort(orphaned)
+		if !slices.Equal(orphaned, []string{"gone", "never"}) {
+			t.Errorf("orphaned = %v, want [gone never]", orphaned)
+		}
+
+		link, err := st.GetLinkStats(ctx, "kept")
+		if err != nil {
+			t.Fatal(err)
+		}
+		if link.Clicks.Int64 != 2 {
+			t.Errorf("kept clicks = %d, want 2", link.Clicks.Int64)
+		}
+		for _, slug := range []string{"gone", "never"} {
+			if _, err := st.GetLink(ctx, slug); !errors.Is(err, sql.ErrNoRows) {
+				t.Errorf("GetLink(%s): err = %v, want sql.ErrNoRows", slug, err)
+			}
+			if daily, _ := st.GetDailyClicks(ctx, slug); len(daily) != 0 {
+				t.Errorf("%s has daily clicks %+v", slug, daily)
+			}
+			if countries, _ := st.GetCountryClicks(ctx, slug); len(countries) != 0 {
+				t.Errorf("%s has country clicks %+v", slug, countries)
+			}
+			if languages, _ := st.GetLanguageClicks(ctx, slug); len(languages) != 0 {
+				t.Errorf("%s has language clicks %+v", slug, languages)
+			}
+		}
+	})
+}
+
+func TestAddClickReportsMissingLink(t *testing.T) {
+	forEachStore(t, func(t *testing.T, st store.Store) {
+		ctx := context.Background()
+		addLink(t, st, "abc", "https://example.com/")
+		for slug, want := range map[string]int64{"abc": 1, "nope": 0} {
+			n, err := st.AddClick(ctx, db.AddClickParams{Clicks: sql.NullInt64{Int64: 1, Valid: true}, Slug: slug})
+			if err != nil {
+				t.Fatal(err)
+			}
+			if n != want {
+				t.Errorf("AddClick(%s) = %d rows, want %d", slug, n, want)
+			}
+		}
+	})
+}
@@ -0,0 +1,667 @@
+package store
+
+import (
+	"context"
+	"database/sql"
+	"slices"
+	"sort"
+	"sync"
+	"time"
+
+	"shotr/db"
+)
+
+// MemoryURL as DATABASE_URL selects the Memory store.
+const MemoryURL = "memory://"
+
+// Memory is a Store that keeps everything in process, for running shotr
+// ephemerally and for tests. It follows the SQL store's semantics: missing
+// rows are sql.ErrNoRows, ids are never reused, analytics cover the last
+// seven UTC days.
+type Memory struct {
+	mu   *sync.Mutex
+	inTx bool // the Store given to a WithTx callback already holds mu
+	d    *memData
+}
+
+type dayKey struct {
+	Slug  string
+	Day   time.Time
+	Value string
+}
+
+type idemKey struct {
+	Owner string
+	Key   string
+}
+
+type memData struct {
+	links    map[string]db.Link
+	linkSeq  int64
+	dests    []db.AddLinkDestinationParams
+	idem     map[idemKey]db.SaveIdempotentResponseParams
+	targets  []db.LinkTarget
+	variants []db.LinkVariant
+	sched    []db.LinkSchedule
+	rowSeq   int64 // ids for targets, variants and schedules
+	reports  []db.Report
+	daily    map[dayKey]int64
+	country  map[dayKey]int64
+	language map[dayKey]int64
+	source   map[dayKey]int64
+}
+
+var _ Store = (*Memory)(nil)
+
+func NewMemory() *Memory {
+	return &Memory{
+		mu: &sync.Mutex{},
+		d: &memData{
+			links:    make(map[string]db.Link),
+			idem:     make(map[idemKey]db.SaveIdempotentResponseParams),
+			daily:    make(map[dayKey]int64),
+			country:  make(map[dayKey]int64),
+			language: make(map[dayKey]int64),
+			source:   make(map[dayKey]int64),
+		},
+	}
+}
+
+func (m *Memory) lock() func() {
+	if m.inTx {
+		return func() {}
+	}
+	m.mu.Lock()
+	return m.mu.Unlock
+}
+
+// WithTx holds the lock for the whole of fn and puts a snapshot back if fn
+// fails. Nested calls take their own snapshot.
+func (m *Memory) WithTx(ctx context.Context, fn func(s Store) error) error {
+	if m.inTx {
+		snap := m.d.clone()
+		if err := fn(m); err != nil {
+			*m.d = *snap
+			return err
+		}
+		return nil
+	}
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	snap := m.d.clone()
+	if err := fn(&Memory{mu: m.mu, inTx: true, d: m.d}); err != nil {
+		*m.d = *snap
+		return err
+	}
+	return nil
+}
+
+func (d *memData) clone() *memData {
+	c := *d
+	c.links = make(map[string]db.Link, len(d.links))
+	for k, v := range d.links {
+		c.links[k] = v
+	}
+	c.targets = append([]db.LinkTarget(nil), d.targets...)
+	c.variants = append([]db.LinkVariant(nil), d.variants...)
+	c.sched = append([]db.LinkSchedule(nil), d.sched...)
+	c.reports = append([]db.Report(nil), d.reports...)
+	c.dests = append([]db.AddLinkDestinationParams(nil), d.dests...)
+	c.idem = make(map[idemKey]db.SaveIdempotentResponseParams, len(d.idem))
+	for k, v := range d.idem {
+		c.idem[k] = v
+	}
+	c.daily = cloneCounts(d.daily)
+	c.country = cloneCounts(d.country)
+	c.language = cloneCounts(d.language)
+	c.source = cloneCounts(d.source)
+	return &c
+}
+
+func cloneCounts(in map[dayKey]int64) map[dayKey]int64 {
+	out := make(map[dayKey]int64, len(in))
+	for k, v := range in {
+		out[k] = v
+	}
+	return out
+}
+
+func today() time.Time { return time.Now().UTC().Truncate(24 * time.Hour) }
+
+// --- links ---
+
+func (m *Memory) AddLink(ctx context.Context, arg db.AddLinkParams) (db.Link, error) {
+	defer m.lock()()
+	if _, ok := m.d.links[arg.Slug]; ok {
+		return db.Link{}, ErrDuplicate
+	}
+	m.d.linkSeq++
+	l := db.Link{
+		ID:        m.d.linkSeq,
+		Slug:      arg.Slug,
+		Url:       arg.Url,
+		User:      arg.User,
+		CreatedAt: time.Now().UTC().Truncate(time.Second),
+		Clicks:    sql.NullInt64{Int64: 0, Valid: true},
+	}
+	m.d.links[arg.Slug] = l
+	return l, nil
+}
+
+func (m *Memory) GetLink(ctx context.Context, slug string) (db.Link, error) {
+	defer m.lock()()
+	l, ok := m.d.links[slug]
+	if !ok {
+		return db.Link{}, sql.ErrNoRows
+	}
+	return l, nil
+}
+
+func (m *Memory) GetLinkStats(ctx context.Context, slug string) (db.Link, error) {
+	return m.GetLink(ctx, slug)
+}
+
+func (m *Memory) CountLinks(ctx context.Context) (int64, error) {
+	defer m.lock()()
+	return int64(len(m.d.links)), nil
+}
+
+func (m *Memory) DeleteLinkByID(ctx context.Context, id int64) error {
+	defer m.lock()()
+	for slug, l := range m.d.links {
+		if l.ID == id {
+			delete(m.d.links, slug)
+		}
+	}
+	return nil
+}
+
+// updateLink applies fn to the link with slug, if there is one; like an
+// UPDATE, a missing link isn't an error.
+func (m *Memory) updateLink(slug string, fn func(l *db.Link)) {
+	defer m.lock()()
+	if l, ok := m.d.links[slug]; ok {
+		fn(&l)
+		m.d.links[slug] = l
+	}
+}
+
+func (m *Memory) UpdateLinkURL(ctx context.Context, arg db.UpdateLinkURLParams) error {
+	m.updateLink(arg.Slug, func(l *db.Link) { l.Url = arg.Url })
+	return nil
+}
+
+func (m *Memory) UpdateLinkSlug(ctx context.Context, arg db.UpdateLinkSlugParams) error {
+	defer m.lock()()
+	if _, ok := m.d.links[arg.Slug]; ok {
+		return ErrDuplicate
+	}
+	for slug, l := range m.d.links {
+		if l.ID == arg.ID {
+			delete(m.d.links, slug)
+			l.Slug = arg.Slug
+			m.d.links[arg.Slug] = l
+			break
+		}
+	}
+	return nil
+}
+
+func (m *Memory) SetLinkPassword(ctx context.Context, arg db.SetLinkPasswordParams) error {
+	m.updateLink(arg.Slug, func(l *db.Link) { l.PasswordHash = arg.PasswordHash })
+	return nil
+}
+
+func (m *Memory) SetLinkInterstitial(ctx context.Context, arg db.SetLinkInterstitialParams) error {
+	m.updateLink(arg.Slug, func(l *db.Link) { l.Interstitial = arg.Interstitial })
+	return nil
+}
+
+func (m *Memory) SetLinkOriginalURL(ctx context.Context, arg db.SetLinkOriginalURLParams) error {
+	m.updateLink(arg.Slug, func(l *db.Link) { l.OriginalUrl = arg.OriginalUrl })
+	return nil
+}
+
+func (m *Memory) SetLinkTakedown(ctx context.Context, arg db.SetLinkTakedownParams) error {
+	m.updateLink(arg.Slug, func(l *db.Link) {
+		l.TakedownStatus = arg.TakedownStatus
+		l.TakedownReason = arg.TakedownReason
+	})
+	return nil
+}
+
+func (m *Memory) AddLinkTarget(ctx context.Context, arg db.AddLinkTargetParams) error {
+	defer m.lock()()
+	for _, t := range m.d.targets {
+		if t.Slug == arg.Slug && t.Kind == arg.Kind && t.Value == arg.Value {
+			return ErrDuplicate
+		}
+	}
+	m.d.rowSeq++
+	m.d.targets = append(m.d.targets, db.LinkTarget{ID: m.d.rowSeq, Slug: arg.Slug, Kind: arg.Kind, Value: arg.Value, Url: arg.Url})
+	return nil
+}
+
+func (m *Memory) GetLinkTargets(ctx context.Context, slug string) ([]db.LinkTarget, error) {
+	defer m.lock()()
+	var out []db.LinkTarget
+	for _, t := range m.d.targets {
+		if t.Slug == slug {
+			out = append(out, t)
+		}
+	}
+	return out, nil
+}
+
+func (m *Memory) AddLinkVariant(ctx context.Context, arg db.AddLinkVariantParams) error {
+	defer m.lock()()
+	for _, v := range m.d.variants {
+		if v.Slug == arg.Slug && v.Name == arg.Name {
+			return ErrDuplicate
+		}
+	}
+	m.d.rowSeq++
+	m.d.variants = append(m.d.variants, db.LinkVariant{
+		ID:     m.d.rowSeq,
+		Slug:   arg.Slug,
+		Name:   arg.Name,
+		Url:    arg.Url,
+		Weight: arg.Weight,
+		Clicks: sql.NullInt64{Int64: 0, Valid: true},
+	})
+	return nil
+}
+
+func (m *Memory) GetLinkVariants(ctx context.Context, slug string) ([]db.LinkVariant, error) {
+	defer m.lock()()
+	var out []db.LinkVariant
+	for _, v := range m.d.variants {
+		if v.Slug == slug {
+			out = append(out, v)
+		}
+	}
+	return out, nil
+}
+
+func (m *Memory) AddLinkSchedule(ctx context.Context, arg db.AddLinkScheduleParams) error {
+	defer m.lock()()
+	m.d.rowSeq++
+	m.d.sched = append(m.d.sched, db.LinkSchedule{ID: m.d.rowSeq, Slug: arg.Slug, StartsAt: arg.StartsAt, EndsAt: arg.EndsAt, Url: arg.Url})
+	return nil
+}
+
+func (m *Memory) GetLinkSchedules(ctx context.Context, slug string) ([]db.LinkSchedule, error) {
+	defer m.lock()()
+	var out []db.LinkSchedule
+	for _, s := range m.d.sched {
+		if s.Slug == slug {
+			out = append(out, s)
+		}
+	}
+	// NULL starts sort first, as in sqlite
+	sort.SliceStable(out, func(i, j int) bool {
+		a, b := out[i].StartsAt, out[j].StartsAt
+		return !a.Valid && b.Valid || a.Valid && b.Valid && a.Time.Before(b.Time)
+	})
+	return out, nil
+}
+
+func (m *Memory) AddLinkDestination(ctx context.Context, arg db.AddLinkDestinationParams) error {
+	defer m.lock()()
+	for _, d := range m.d.dests {
+		if d.Slug == arg.Slug {
+			return ErrDuplicate
+		}
+	}
+	m.d.dests = append(m.d.dests, arg)
+	return nil
+}
+
+func (m *Memory) FindLinkByDestination(ctx context.Context, arg db.FindLinkByDestinationParams) (db.FindLinkByDestinationRow, error) {
+	defer m.lock()()
+	var (
+		found db.FindLinkByDestinationRow
+		ok    bool
+	)
+	for _, d := range m.d.dests {
+		if d.Owner != arg.Owner || d.UrlKey != arg.UrlKey {
+			continue
+		}
+		l, exists := m.d.links[d.Slug]
+		if !exists || l.Url != d.UrlKey || l.TakedownStatus.Valid || ok && l.ID > found.ID {
+			continue
+		}
+		found, ok = db.FindLinkByDestinationRow{ID: l.ID, Slug: l.Slug, Url: l.Url}, true
+	}
+	if !ok {
+		return found, sql.ErrNoRows
+	}
+	return found, nil
+}
+
+// --- clicks ---
+
+func (m *Memory) FlushClicks(ctx context.Context, b ClickBatch) ([]string, error) {
+	var orphaned []string
+	err := m.WithTx(ctx, func(s Store) error {
+		tx := s.(*Memory)
+		var found []string
+		for slug := range b.Links {
+			if _, ok := tx.d.links[slug]; ok {
+				found = append(found, slug)
+			}
+		}
+		orphaned = b.orphans(found)
+		b := b.without(orphaned)
+
+		day := today()
+		for slug, n := range b.Links {
+			tx.updateLink(slug, func(l *db.Link) { l.Clicks.Int64 += n })
+			tx.d.daily[dayKey{Slug: slug, Day: day}] += n
+		}
+		for k, n := range b.Countries {
+			tx.d.country[dayKey{k.Slug, day, k.Value}] += n
+		}
+		for k, n := range b.Languages {
+			tx.d.language[dayKey{k.Slug, day, k.Value}] += n
+		}
+		for k, n := range b.Sources {
+			tx.d.source[dayKey{k.Slug, day, k.Value}] += n
+		}
+		for k, n := range b.Variants {
+			tx.addVariantClick(k.Slug, k.Value, n)
+		}
+		return nil
+	})
+	if err != nil {
+		return nil, err
+	}
+	return orphaned, nil
+}
+
+func (m *Memory) AddClick(ctx context.Context, arg db.AddClickParams) (int64, error) {
+	var n int64
+	m.updateLink(arg.Slug, func(l *db.Link) {
+		l.Clicks.Int64 += arg.Clicks.Int64
+		n = 1
+	})
+	return n, nil
+}
+
+func (m *Memory) addCount(table func(d *memData) map[dayKey]int64, slug, value string, n int64) {
+	defer m.lock()()
+	table(m.d)[dayKey{slug, today(), value}] += n
+}
+
+func dailyTable(d *memData) map[dayKey]int64    { return d.daily }
+func countryTable(d *memData) map[dayKey]int64  { return d.country }
+func languageTable(d *memData) map[dayKey]int64 { return d.language }
+func sourceTable(d *memData) map[dayKey]int64   { return d.source }
+
+func (m *Memory) SaveDailyClicks(ctx context.Context, arg db.SaveDailyClicksParams) error {
+	m.addCount(dailyTable, arg.Slug, "", arg.Clicks.Int64)
+	return nil
+}
+
+func (m *Memory) SaveCountryClicks(ctx context.Context, arg db.SaveCountryClicksParams) error {
+	m.addCount(countryTable, arg.Slug, arg.Country, arg.Clicks.Int64)
+	return nil
+}
+
+func (m *Memory) SaveLanguageClicks(ctx context.Context, arg db.SaveLanguageClicksParams) error {
+	m.addCount(languageTable, arg.Slug, arg.Language, arg.Clicks.Int64)
+	return nil
+}
+
+func (m *Memory) SaveSourceClicks(ctx context.Context, arg db.SaveSourceClicksParams) error {
+	m.addCount(sourceTable, arg.Slug, arg.Source, arg.Clicks.Int64)
+	return nil
+}
+
+func (m *Memory) AddVariantClick(ctx context.Context, arg db.AddVariantClickParams) error {
+	defer m.lock()()
+	m.addVariantClick(arg.Slug, arg.Name, arg.Clicks.Int64)
+	return nil
+}
+
+// addVariantClick expects the lock to be held.
+func (m *Memory) addVariantClick(slug, name string, n int64) {
+	for i, v := range m.d.variants {
+		if v.Slug == slug && v.Name == name {
+			m.d.variants[i].Clicks.Int64 += n
+		}
+	}
+}
+
+// lastWeek sums counts for slug over the last seven days, by value.
+func (m *Memory) lastWeek(table func(d *memData) map[dayKey]int64, slug string) map[string]int64 {
+	defer m.lock()()
+	counts := table(m.d)
+	since := today().AddDate(0, 0, -6)
+	out := make(map[string]int64)
+	for k, n := range counts {
+		if k.Slug == slug && !k.Day.Before(since) {
+			out[k.Value] += n
+		}
+	}
+	return out
+}
+
+// byClicks orders a breakdown by clicks, most first.
+func byClicks(counts map[string]int64) []string {
+	keys := make([]string, 0, len(counts))
+	for k := range counts {
+		keys = append(keys, k)
+	}
+	sort.Slice(keys, func(i, j int) bool {
+		if counts[keys[i]] != counts[keys[j]] {
+			return counts[keys[i]] > counts[keys[j]]
+		}
+		return keys[i] < keys[j]
+	})
+	return keys
+}
+
+func (m *Memory) GetDailyClicks(ctx context.Context, slug string) ([]db.GetDailyClicksRow, error) {
+	defer m.lock()()
+	since := today().AddDate(0, 0, -6)
+	var out []db.GetDailyClicksRow
+	for k, n := range m.d.daily {
+		if k.Slug == slug && !k.Day.Before(since) {
+			out = append(out, db.GetDailyClicksRow{Day: k.Day, Clicks: sql.NullInt64{Int64: n, Valid: true}})
+		}
+	}
+	sort.Slice(out, func(i, j int) bool { return out[i].Day.Before(out[j].Day) })
+	return out, nil
+}
+
+func (m *Memory) GetCountryClicks(ctx context.Context, slug string) ([]db.GetCountryClicksRow, error) {
+	counts := m.lastWeek(countryTable, slug)
+	var out []db.GetCountryClicksRow
+	for _, k := range byClicks(counts) {
+		out = append(out, db.GetCountryClicksRow{Country: k, Clicks: counts[k]})
+	}
+	return out, nil
+}
+
+func (m *Memory) GetLanguageClicks(ctx context.Context, slug string) ([]db.GetLanguageClicksRow, error) {
+	counts := m.lastWeek(languageTable, slug)
+	var out []db.GetLanguageClicksRow
+	for _, k := range byClicks(counts) {
+		out = append(out, db.GetLanguageClicksRow{Language: k, Clicks: counts[k]})
+	}
+	return out, nil
+}
+
+func (m *Memory) GetSourceClicks(ctx context.Context, slug string) ([]db.GetSourceClicksRow, error) {
+	counts := m.lastWeek(sourceTable, slug)
+	var out []db.GetSourceClicksRow
+	for _, k := range byClicks(counts) {
+		out = append(out, db.GetSourceClicksRow{Source: k, Clicks: counts[k]})
+	}
+	return out, nil
+}
+
+// --- transfer ---
+
+func (m *Memory) ListLinks(ctx context.Context, arg db.ListLinksParams) ([]db.Link, error) {
+	defer m.lock()()
+	var out []db.Link
+	for _, l := range m.d.links {
+		if l.ID > arg.AfterID {
+			out = append(out, l)
+		}
+	}
+	sort.Slice(out, func(i, j int) bool { return out[i].ID < out[j].ID })
+	if int64(len(out)) > arg.Limit {
+		out = out[:arg.Limit]
+	}
+	return out, nil
+}
+
+func (m *Memory) ListDailyClicks(ctx context.Context, slug string) ([]db.ListDailyClicksRow, error) {
+	defer m.lock()()
+	var out []db.ListDailyClicksRow
+	for k, n := range m.d.daily {
+		if k.Slug == slug {
+			out = append(out, db.ListDailyClicksRow{Day: k.Day, Clicks: sql.NullInt64{Int64: n, Valid: true}})
+		}
+	}
+	sort.Slice(out, func(i, j int) bool { return out[i].Day.Before(out[j].Day) })
+	return out, nil
+}
+
+func (m *Memory) ListLinkDestinations(ctx context.Context, slug string) ([]db.ListLinkDestinationsRow, error) {
+	defer m.lock()()
+	var out []db.ListLinkDestinationsRow
+	for _, d := range m.d.dests {
+		if d.Slug == slug {
+			out = append(out, db.ListLinkDestinationsRow{Owner: d.Owner, UrlKey: d.UrlKey})
+		}
+	}
+	sort.Slice(out, func(i, j int) bool { return out[i].Owner < out[j].Owner })
+	return out, nil
+}
+
+func (m *Memory) PutLink(ctx context.Context, rec LinkRecord) error {
+	return m.WithTx(ctx, func(st Store) error {
+		tx := st.(*Memory)
+		old, ok := tx.d.links[rec.Slug]
+		id := old.ID
+		if !ok {
+			tx.d.linkSeq++
+			id = tx.d.linkSeq
+		}
+		// a fresh row, so an overwrite keeps nothing of the old link
+		tx.d.links[rec.Slug] = db.Link{
+			ID:             id,
+			Slug:           rec.Slug,
+			Url:            rec.URL,
+			User:           nullString(rec.User),
+			CreatedAt:      rec.CreatedAt.UTC().Truncate(time.Second),
+			Clicks:         sql.NullInt64{Int64: rec.Clicks, Valid: true},
+			PasswordHash:   nullString(rec.PasswordHash),
+			Interstitial:   rec.Interstitial,
+			TakedownStatus: sql.NullInt64{Int64: rec.TakedownStatus, Valid: rec.TakedownStatus != 0},
+			TakedownReason: nullString(rec.TakedownReason),
+			OriginalUrl:    nullString(rec.OriginalURL),
+		}
+
+		for _, counts := range []map[dayKey]int64{tx.d.daily, tx.d.country, tx.d.language, tx.d.source} {
+			for k := range counts {
+				if k.Slug == rec.Slug {
+					delete(counts, k)
+				}
+			}
+		}
+		tx.d.targets = slices.DeleteFunc(tx.d.targets, func(t db.LinkTarget) bool { return t.Slug == rec.Slug })
+		tx.d.variants = slices.DeleteFunc(tx.d.variants, func(v db.LinkVariant) bool { return v.Slug == rec.Slug })
+		tx.d.sched = slices.DeleteFunc(tx.d.sched, func(s db.LinkSchedule) bool { return s.Slug == rec.Slug })
+		tx.d.dests = slices.DeleteFunc(tx.d.dests, func(d db.AddLinkDestinationParams) bool { return d.Slug == rec.Slug })
+		for _, d := range rec.Daily {
+			tx.d.daily[dayKey{Slug: rec.Slug, Day: d.Day.UTC().Truncate(24 * time.Hour)}] = d.Clicks
+		}
+		return putLinkRules(ctx, tx, rec)
+	})
+}
+
+// --- idempotency ---
+
+func (m *Memory) GetIdempotentResponse(ctx context.Context, arg db.GetIdempotentResponseParams) (db.GetIdempotentResponseRow, error) {
+	defer m.lock()()
+	r, ok := m.d.idem[idemKey{Owner: arg.Owner, Key: arg.Key}]
+	if !ok || r.ExpiresAt <= arg.Now {
+		return db.GetIdempotentResponseRow{}, sql.ErrNoRows
+	}
+	return db.GetIdempotentResponseRow{RequestHash: r.RequestHash, Status: r.Status, Body: r.Body}, nil
+}
+
+func (m *Memory) SaveIdempotentResponse(ctx context.Context, arg db.SaveIdempotentResponseParams) (int64, error) {
+	defer m.lock()()
+	k := idemKey{Owner: arg.Owner, Key: arg.Key}
+	if _, ok := m.d.idem[k]; ok {
+		return 0, nil
+	}
+	m.d.idem[k] = arg
+	return 1, nil
+}
+
+func (m *Memory) DeleteExpiredIdempotencyKeys(ctx context.Context, now int64) error {
+	defer m.lock()()
+	for k, r := range m.d.idem {
+		if r.ExpiresAt <= now {
+			delete(m.d.idem, k)
+		}
+	}
+	return nil
+}
+
+// --- reports ---
+
+func (m *Memory) AddReport(ctx context.Context, arg db.AddReportParams) (db.Report, error) {
+	defer m.lock()()
+	r := db.Report{
+		ID:         int64(len(m.d.reports)) + 1,
+		Slug:       arg.Slug,
+		Reason:     arg.Reason,
+		Details:    arg.Details,
+		Contact:    arg.Contact,
+		ReporterIp: arg.ReporterIp,
+		Status:     "open",
+		CreatedAt:  time.Now().UTC().Truncate(time.Second),
+	}
+	m.d.reports = append(m.d.reports, r)
+	return r, nil
+}
+
+func (m *Memory) GetReport(ctx context.Context, id int64) (db.Report, error) {
+	defer m.lock()()
+	if id < 1 || id > int64(len(m.d.reports)) {
+		return db.Report{}, sql.ErrNoRows
+	}
+	return m.d.reports[id-1], nil
+}
+
+func (m *Memory) ListReports(ctx context.Context, arg db.ListReportsParams) ([]db.Report, error) {
+	defer m.lock()()
+	var out []db.Report
+	for _, r := range m.d.reports {
+		if r.Status == arg.Status && int64(len(out)) < arg.Limit {
+			out = append(out, r)
+		}
+	}
+	return out, nil
+}
+
+func (m *Memory) ResolveReports(ctx context.Context, arg db.ResolveReportsParams) error {
+	defer m.lock()()
+	now := time.Now().UTC().Truncate(time.Second)
+	for i, r := range m.d.reports {
+		if r.Slug == arg.Slug && r.Status == "open" {
+			m.d.reports[i].Status = arg.Status
+			m.d.reports[i].ResolvedAt = sql.NullTime{Time: now, Valid: true}
+		}
+	}
+	return nil
+}
@@ -0,0 +1,375 @@
+package store
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+
+	"shotr/db"
+	"shotr/db/pg"
+)
+
+// Postgres is the Store over a PostgreSQL connection. Its queries are
+// generated from db/pg/queries, which mirror db/queries column for column,
+// so params and rows convert straight between the two packages.
+type Postgres struct {
+	q  *pg.Queries
+	db *sql.DB
+	tx *sql.Tx // set on the Store handed to a WithTx callback
+}
+
+var _ Store = (*Postgres)(nil)
+
+func NewPostgres(dbConn *sql.DB) *Postgres {
+	return &Postgres{q: pg.New(dbConn), db: dbConn}
+}
+
+func (p *Postgres) WithTx(ctx context.Context, fn func(s Store) error) error {
+	if p.tx != nil {
+		return savepoint(ctx, p.tx, func() error { return fn(p) })
+	}
+	tx, err := p.db.BeginTx(ctx, nil)
+	if err != nil {
+		return err
+	}
+	if err := fn(&Postgres{q: p.q.WithTx(tx), db: p.db, tx: tx}); err != nil {
+		_ = tx.Rollback()
+		return err
+	}
+	return tx.Commit()
+}
+
+// PutLink writes rec in its own transaction, or in the caller's.
+func (p *Postgres) PutLink(ctx context.Context, rec LinkRecord) error {
+	return p.WithTx(ctx, func(st Store) error {
+		tx := st.(*Postgres)
+		q := tx.q
+		row := pg.ImportLinkParams{
+			Slug:           rec.Slug,
+			Url:            rec.URL,
+			User:           nullString(rec.User),
+			CreatedAt:      rec.CreatedAt,
+			Clicks:         sql.NullInt64{Int64: rec.Clicks, Valid: true},
+			OriginalUrl:    nullString(rec.OriginalURL),
+			PasswordHash:   nullString(rec.PasswordHash),
+			Interstitial:   rec.Interstitial,
+			TakedownStatus: sql.NullInt64{Int64: rec.TakedownStatus, Valid: rec.TakedownStatus != 0},
+			TakedownReason: nullString(rec.TakedownReason),
+		}
+
+		_, err := q.GetLink(ctx, rec.Slug)
+		switch {
+		case err == nil:
+			if err := q.ReplaceLink(ctx, pg.ReplaceLinkParams{
+				Url: row.Url, User: row.User, CreatedAt: row.CreatedAt, Clicks: row.Clicks,
+				OriginalUrl: row.OriginalUrl, PasswordHash: row.PasswordHash, Interstitial: row.Interstitial,
+				TakedownStatus: row.TakedownStatus, TakedownReason: row.TakedownReason, Slug: row.Slug,
+			}); err != nil {
+				return err
+			}
+			// the record replaces the link whole, so nothing of the old
+			// one may survive it
+			for _, del := range []func(context.Context, string) error{
+				q.DeleteDailyClicks, q.DeleteCountryClicks, q.DeleteLanguageClicks, q.DeleteSourceClicks,
+				q.DeleteLinkTargets, q.DeleteLinkVariants, q.DeleteLinkSchedules, q.DeleteLinkDestinations,
+			} {
+				if err := del(ctx, rec.Slug); err != nil {
+					return err
+				}
+			}
+		case errors.Is(err, sql.ErrNoRows):
+			if err := q.ImportLink(ctx, row); err != nil {
+				return err
+			}
+		default:
+			return err
+		}
+
+		for _, d := range rec.Daily {
+			if err := q.SetDailyClicks(ctx, pg.SetDailyClicksParams{
+				Slug:   rec.Slug,
+				Day:    d.Day,
+				Clicks: sql.NullInt64{Int64: d.Clicks, Valid: true},
+			}); err != nil {
+				return err
+			}
+		}
+		return putLinkRules(ctx, tx, rec)
+	})
+}
+
+// FlushClicks writes the batch with one unnest-based statement per table,
+// all in one transaction. The links update runs first and reports which
+// slugs it found; the rest of the batch is written for those only.
+func (p *Postgres) FlushClicks(ctx context.Context, b ClickBatch) ([]string, error) {
+	var orphaned []string
+	err := p.WithTx(ctx, func(st Store) error {
+		q := st.(*Postgres).q
+
+		slugs, clicks := linkColumns(b.Links)
+		found, err := q.FlushLinkClicks(ctx, pg.FlushLinkClicksParams{Slugs: slugs, Clicks: clicks})
+		if err != nil {
+			return err
+		}
+		orphaned = b.orphans(found)
+		b := b.without(orphaned)
+		if len(b.Links) == 0 {
+			return nil
+		}
+
+		slugs, clicks = linkColumns(b.Links)
+		if err := q.FlushDailyClicks(ctx, pg.FlushDailyClicksParams{Slugs: slugs, Clicks: clicks}); err != nil {
+			return err
+		}
+
+		if rows := breakdownColumns(b.Countries); rows != nil {
+			if err := q.FlushCountryClicks(ctx, pg.FlushCountryClicksParams(*rows)); err != nil {
+				return err
+			}
+		}
+		if rows := breakdownColumns(b.Languages); rows != nil {
+			if err := q.FlushLanguageClicks(ctx, pg.FlushLanguageClicksParams(*rows)); err != nil {
+				return err
+			}
+		}
+		if rows := breakdownColumns(b.Sources); rows != nil {
+			if err := q.FlushSourceClicks(ctx, pg.FlushSourceClicksParams(*rows)); err != nil {
+				return err
+			}
+		}
+		if rows := breakdownColumns(b.Variants); rows != nil {
+			if err := q.FlushVariantClicks(ctx, pg.FlushVariantClicksParams(*rows)); err != nil {
+				return err
+			}
+		}
+		return nil
+	})
+	if err != nil {
+		return nil, err
+	}
+	return orphaned, nil
+}
+
+// linkColumns splits per-link counters into parallel arrays.
+func linkColumns(rows map[string]int64) ([]string, []int64) {
+	slugs := make([]string, 0, len(rows))
+	clicks := make([]int64, 0, len(rows))
+	for slug, cnt := range rows {
+		slugs = append(slugs, slug)
+		clicks = append(clicks, cnt)
+	}
+	return slugs, clicks
+}
+
+// breakdownColumns splits breakdown counters into the parallel arrays the
+// Flush*Clicks queries unnest, or returns nil when there are none.
+func breakdownColumns(rows map[BreakdownKey]int64) *pg.FlushCountryClicksParams {
+	if len(rows) == 0 {
+		return nil
+	}
+	out := &pg.FlushCountryClicksParams{
+		Slugs:  make([]string, 0, len(rows)),
+		Vals:   make([]string, 0, len(rows)),
+		Clicks: make([]int64, 0, len(rows)),
+	}
+	for k, cnt := range rows {
+		out.Slugs = append(out.Slugs, k.Slug)
+		out.Vals = append(out.Vals, k.Value)
+		out.Clicks = append(out.Clicks, cnt)
+	}
+	return out
+}
+
+// convertRows maps a slice of generated pg rows to their db twins.
+func convertRows[P, D any](rows []P, err error, conv func(P) D) ([]D, error) {
+	if err != nil {
+		return nil, err
+	}
+	out := make([]D, len(rows))
+	for i, r := range rows {
+		out[i] = conv(r)
+	}
+	return out, nil
+}
+
+// links
+
+func (p *Postgres) AddLink(ctx context.Context, arg db.AddLinkParams) (db.Link, error) {
+	l, err := p.q.AddLink(ctx, pg.AddLinkParams(arg))
+	return db.Link(l), err
+}
+
+func (p *Postgres) GetLink(ctx context.Context, slug string) (db.Link, error) {
+	l, err := p.q.GetLink(ctx, slug)
+	return db.Link(l), err
+}
+
+func (p *Postgres) GetLinkStats(ctx context.Context, slug string) (db.Link, error) {
+	l, err := p.q.GetLinkStats(ctx, slug)
+	return db.Link(l), err
+}
+
+func (p *Postgres) CountLinks(ctx context.Context) (int64, error) {
+	return p.q.CountLinks(ctx)
+}
+
+func (p *Postgres) DeleteLinkByID(ctx context.Context, id int64) error {
+	return p.q.DeleteLinkByID(ctx, id)
+}
+
+func (p *Postgres) UpdateLinkURL(ctx context.Context, arg db.UpdateLinkURLParams) error {
+	return p.q.UpdateLinkURL(ctx, pg.UpdateLinkURLParams(arg))
+}
+
+func (p *Postgres) UpdateLinkSlug(ctx context.Context, arg db.UpdateLinkSlugParams) error {
+	return p.q.UpdateLinkSlug(ctx, pg.UpdateLinkSlugParams(arg))
+}
+
+func (p *Postgres) SetLinkPassword(ctx context.Context, arg db.SetLinkPasswordParams) error {
+	return p.q.SetLinkPassword(ctx, pg.SetLinkPasswordParams(arg))
+}
+
+func (p *Postgres) SetLinkInterstitial(ctx context.Context, arg db.SetLinkInterstitialParams) error {
+	return p.q.SetLinkInterstitial(ctx, pg.SetLinkInterstitialParams(arg))
+}
+
+func (p *Postgres) SetLinkOriginalURL(ctx context.Context, arg db.SetLinkOriginalURLParams) error {
+	return p.q.SetLinkOriginalURL(ctx, pg.SetLinkOriginalURLParams(arg))
+}
+
+func (p *Postgres) SetLinkTakedown(ctx context.Context, arg db.SetLinkTakedownParams) error {
+	return p.q.SetLinkTakedown(ctx, pg.SetLinkTakedownParams(arg))
+}
+
+func (p *Postgres) ListLinks(ctx context.Context, arg db.ListLinksParams) ([]db.Link, error) {
+	rows, err := p.q.ListLinks(ctx, pg.ListLinksParams(arg))
+	return convertRows(rows, err, func(r pg.Link) db.Link { return db.Link(r) })
+}
+
+func (p *Postgres) AddLinkTarget(ctx context.Context, arg db.AddLinkTargetParams) error {
+	return p.q.AddLinkTarget(ctx, pg.AddLinkTargetParams(arg))
+}
+
+func (p *Postgres) GetLinkTargets(ctx context.Context, slug string) ([]db.LinkTarget, error) {
+	rows, err := p.q.GetLinkTargets(ctx, slug)
+	return convertRows(rows, err, func(r pg.LinkTarget) db.LinkTarget { return db.LinkTarget(r) })
+}
+
+func (p *Postgres) AddLinkVariant(ctx context.Context, arg db.AddLinkVariantParams) error {
+	return p.q.AddLinkVariant(ctx, pg.AddLinkVariantParams(arg))
+}
+
+func (p *Postgres) GetLinkVariants(ctx context.Context, slug string) ([]db.LinkVariant, error) {
+	rows, err := p.q.GetLinkVariants(ctx, slug)
+	return convertRows(rows, err, func(r pg.LinkVariant) db.LinkVariant { return db.LinkVariant(r) })
+}
+
+func (p *Postgres) AddLinkSchedule(ctx context.Context, arg db.AddLinkScheduleParams) error {
+	return p.q.AddLinkSchedule(ctx, pg.AddLinkScheduleParams(arg))
+}
+
+func (p *Postgres) GetLinkSchedules(ctx context.Context, slug string) ([]db.LinkSchedule, error) {
+	rows, err := p.q.GetLinkSchedules(ctx, slug)
+	return convertRows(rows, err, func(r pg.LinkSchedule) db.LinkSchedule { return db.LinkSchedule(r) })
+}
+
+func (p *Postgres) AddLinkDestination(ctx context.Context, arg db.AddLinkDestinationParams) error {
+	return p.q.AddLinkDestination(ctx, pg.AddLinkDestinationParams(arg))
+}
+
+func (p *Postgres) FindLinkByDestination(ctx context.Context, arg db.FindLinkByDestinationParams) (db.FindLinkByDestinationRow, error) {
+	r, err := p.q.FindLinkByDestination(ctx, pg.FindLinkByDestinationParams(arg))
+	return db.FindLinkByDestinationRow(r), err
+}
+
+// clicks
+
+func (p *Postgres) AddClick(ctx context.Context, arg db.AddClickParams) (int64, error) {
+	return p.q.AddClick(ctx, pg.AddClickParams(arg))
+}
+
+func (p *Postgres) SaveDailyClicks(ctx context.Context, arg db.SaveDailyClicksParams) error {
+	return p.q.SaveDailyClicks(ctx, pg.SaveDailyClicksParams(arg))
+}
+
+func (p *Postgres) SaveCountryClicks(ctx context.Context, arg db.SaveCountryClicksParams) error {
+	return p.q.SaveCountryClicks(ctx, pg.SaveCountryClicksParams(arg))
+}
+
+func (p *Postgres) SaveLanguageClicks(ctx context.Context, arg db.SaveLanguageClicksParams) error {
+	return p.q.SaveLanguageClicks(ctx, pg.SaveLanguageClicksParams(arg))
+}
+
+func (p *Postgres) SaveSourceClicks(ctx context.Context, arg db.SaveSourceClicksParams) error {
+	return p.q.SaveSourceClicks(ctx, pg.SaveSourceClicksParams(arg))
+}
+
+func (p *Postgres) AddVariantClick(ctx context.Context, arg db.AddVariantClickParams) error {
+	return p.q.AddVariantClick(ctx, pg.AddVariantClickParams(arg))
+}
+
+func (p *Postgres) GetDailyClicks(ctx context.Context, slug string) ([]db.GetDailyClicksRow, error) {
+	rows, err := p.q.GetDailyClicks(ctx, slug)
+	return convertRows(rows, err, func(r pg.GetDailyClicksRow) db.GetDailyClicksRow { return db.GetDailyClicksRow(r) })
+}
+
+func (p *Postgres) GetCountryClicks(ctx context.Context, slug string) ([]db.GetCountryClicksRow, error) {
+	rows, err := p.q.GetCountryClicks(ctx, slug)
+	return convertRows(rows, err, func(r pg.GetCountryClicksRow) db.GetCountryClicksRow { return db.GetCountryClicksRow(r) })
+}
+
+func (p *Postgres) GetLanguageClicks(ctx context.Context, slug string) ([]db.GetLanguageClicksRow, error) {
+	rows, err := p.q.GetLanguageClicks(ctx, slug)
+	return convertRows(rows, err, func(r pg.GetLanguageClicksRow) db.GetLanguageClicksRow { return db.GetLanguageClicksRow(r) })
+}
+
+func (p *Postgres) GetSourceClicks(ctx context.Context, slug string) ([]db.GetSourceClicksRow, error) {
+	rows, err := p.q.GetSourceClicks(ctx, slug)
+	return convertRows(rows, err, func(r pg.GetSourceClicksRow) db.GetSourceClicksRow { return db.GetSourceClicksRow(r) })
+}
+
+func (p *Postgres) ListLinkDestinations(ctx context.Context, slug string) ([]db.ListLinkDestinationsRow, error) {
+	rows, err := p.q.ListLinkDestinations(ctx, slug)
+	return convertRows(rows, err, func(r pg.ListLinkDestinationsRow) db.ListLinkDestinationsRow { return db.ListLinkDestinationsRow(r) })
+}
+
+func (p *Postgres) ListDailyClicks(ctx context.Context, slug string) ([]db.ListDailyClicksRow, error) {
+	rows, err := p.q.ListDailyClicks(ctx, slug)
+	return convertRows(rows, err, func(r pg.ListDailyClicksRow) db.ListDailyClicksRow { return db.ListDailyClicksRow(r) })
+}
+
+// idempotency
+
+func (p *Postgres) GetIdempotentResponse(ctx context.Context, arg db.GetIdempotentResponseParams) (db.GetIdempotentResponseRow, error) {
+	r, err := p.q.GetIdempotentResponse(ctx, pg.GetIdempotentResponseParams(arg))
+	return db.GetIdempotentResponseRow(r), err
+}
+
+func (p *Postgres) SaveIdempotentResponse(ctx context.Context, arg db.SaveIdempotentResponseParams) (int64, error) {
+	return p.q.SaveIdempotentResponse(ctx, pg.SaveIdempotentResponseParams(arg))
+}
+
+func (p *Postgres) DeleteExpiredIdempotencyKeys(ctx context.Context, now int64) error {
+	return p.q.DeleteExpiredIdempotencyKeys(ctx, now)
+}
+
+// reports
+
+func (p *Postgres) AddReport(ctx context.Context, arg db.AddReportParams) (db.Report, error) {
+	r, err := p.q.AddReport(ctx, pg.AddReportParams(arg))
+	return db.Report(r), err
+}
+
+func (p *Postgres) GetReport(ctx context.Context, id int64) (db.Report, error) {
+	r, err := p.q.GetReport(ctx, id)
+	return db.Report(r), err
+}
+
+func (p *Postgres) ListReports(ctx context.Context, arg db.ListReportsParams) ([]db.Report, error) {
+	rows, err := p.q.ListReports(ctx, pg.ListReportsParams(arg))
+	return convertRows(rows, err, func(r pg.Report) db.Report { return db.Report(r) })
+}
+
+func (p *Postgres) ResolveReports(ctx context.Context, arg db.ResolveReportsParams) error {
+	return p.q.ResolveReports(ctx, pg.ResolveReportsParams(arg))
+}
@@ -0,0 +1,288 @@
+package store
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+	"fmt"
+	"strings"
+
+	"shotr/db"
+)
+
+// SQL is the Store over a sqlite3 or libsql connection. The link, click
+// and report methods come straight from the embedded sqlc Queries, except
+// the hot lookups, which go to the read pool when there is one.
+type SQL struct {
+	*db.Queries
+	db     *sql.DB
+	tx     *sql.Tx     // set on the Store handed to a WithTx callback
+	reader *db.Queries // nil inside transactions and without a read pool
+}
+
+var _ Store = (*SQL)(nil)
+
+// NewSQL wraps a writer connection and an optional read-only pool over the
+// same database. Pass a nil reader to send everything to the writer.
+func NewSQL(writer, reader *sql.DB) *SQL {
+	s := &SQL{Queries: db.New(writer), db: writer}
+	if reader != nil {
+		s.reader = db.New(reader)
+	}
+	return s
+}
+
+// reads picks where lookups go: the read pool if there is one, otherwise
+// the writer or the current transaction, which also sees its own writes.
+func (s *SQL) reads() *db.Queries {
+	if s.reader != nil {
+		return s.reader
+	}
+	return s.Queries
+}
+
+func (s *SQL) GetLink(ctx context.Context, slug string) (db.Link, error) {
+	return s.reads().GetLink(ctx, slug)
+}
+
+func (s *SQL) GetLinkStats(ctx context.Context, slug string) (db.Link, error) {
+	return s.reads().GetLinkStats(ctx, slug)
+}
+
+func (s *SQL) GetDailyClicks(ctx context.Context, slug string) ([]db.GetDailyClicksRow, error) {
+	return s.reads().GetDailyClicks(ctx, slug)
+}
+
+// the redirect path reads a link's rules on every cache miss
+
+func (s *SQL) GetLinkTargets(ctx context.Context, slug string) ([]db.LinkTarget, error) {
+	return s.reads().GetLinkTargets(ctx, slug)
+}
+
+func (s *SQL) GetLinkVariants(ctx context.Context, slug string) ([]db.LinkVariant, error) {
+	return s.reads().GetLinkVariants(ctx, slug)
+}
+
+func (s *SQL) GetLinkSchedules(ctx context.Context, slug string) ([]db.LinkSchedule, error) {
+	return s.reads().GetLinkSchedules(ctx, slug)
+}
+
+func (s *SQL) WithTx(ctx context.Context, fn func(s Store) error) error {
+	if s.tx != nil {
+		return savepoint(ctx, s.tx, func() error { return fn(s) })
+	}
+	tx, err := s.db.BeginTx(ctx, nil)
+	if err != nil {
+		return err
+	}
+	if err := fn(&SQL{Queries: s.Queries.WithTx(tx), db: s.db, tx: tx}); err != nil {
+		_ = tx.Rollback()
+		return err
+	}
+	return tx.Commit()
+}
+
+// savepoint runs fn inside a savepoint of tx. Savepoints of the same
+// name nest, each RELEASE or ROLLBACK TO acting on the innermost.
+func savepoint(ctx context.Context, tx *sql.Tx, fn func() error) error {
+	if _, err := tx.ExecContext(ctx, "SAVEPOINT nested"); err != nil {
+		return err
+	}
+	if err := fn(); err != nil {
+		// on postgres this also makes the transaction usable again
+		_, _ = tx.ExecContext(ctx, "ROLLBACK TO SAVEPOINT nested")
+		_, _ = tx.ExecContext(ctx, "RELEASE SAVEPOINT nested")
+		return err
+	}
+	_, err := tx.ExecContext(ctx, "RELEASE SAVEPOINT nested")
+	return err
+}
+
+// PutLink writes rec in its own transaction, or in the caller's.
+func (s *SQL) PutLink(ctx context.Context, rec LinkRecord) error {
+	return s.WithTx(ctx, func(st Store) error {
+		tx := st.(*SQL)
+		// same text form as datetime('now') writes, so values compare
+		row := db.ImportLinkParams{
+			Slug:           rec.Slug,
+			Url:            rec.URL,
+			User:           nullString(rec.User),
+			CreatedAt:      rec.CreatedAt.UTC().Format("2006-01-02 15:04:05"),
+			Clicks:         sql.NullInt64{Int64: rec.Clicks, Valid: true},
+			OriginalUrl:    nullString(rec.OriginalURL),
+			PasswordHash:   nullString(rec.PasswordHash),
+			Interstitial:   rec.Interstitial,
+			TakedownStatus: sql.NullInt64{Int64: rec.TakedownStatus, Valid: rec.TakedownStatus != 0},
+			TakedownReason: nullString(rec.TakedownReason),
+		}
+
+		_, err := tx.GetLink(ctx, rec.Slug)
+		switch {
+		case err == nil:
+			if err := tx.ReplaceLink(ctx, db.ReplaceLinkParams{
+				Url: row.Url, User: row.User, CreatedAt: row.CreatedAt, Clicks: row.Clicks,
+				OriginalUrl: row.OriginalUrl, PasswordHash: row.PasswordHash, Interstitial: row.Interstitial,
+				TakedownStatus: row.TakedownStatus, TakedownReason: row.TakedownReason, Slug: row.Slug,
+			}); err != nil {
+				return err
+			}
+			// the record replaces the link whole, so nothing of the old
+			// one may survive it
+			for _, del := range []func(context.Context, string) error{
+				tx.DeleteDailyClicks, tx.DeleteCountryClicks, tx.DeleteLanguageClicks, tx.DeleteSourceClicks,
+				tx.DeleteLinkTargets, tx.DeleteLinkVariants, tx.DeleteLinkSchedules, tx.DeleteLinkDestinations,
+			} {
+				if err := del(ctx, rec.Slug); err != nil {
+					return err
+				}
+			}
+		case errors.Is(err, sql.ErrNoRows):
+			if err := tx.ImportLink(ctx, row); err != nil {
+				return err
+			}
+		default:
+			return err
+		}
+
+		for _, d := range rec.Daily {
+			if err := tx.SetDailyClicks(ctx, db.SetDailyClicksParams{
+				Slug:   rec.Slug,
+				Day:    d.Day.UTC().Format("2006-01-02"),
+				Clicks: sql.NullInt64{Int64: d.Clicks, Valid: true},
+			}); err != nil {
+				return err
+			}
+		}
+		return putLinkRules(ctx, tx, rec)
+	})
+}
+
+// FlushClicks writes the batch with one multi-row statement per table, all
+// in one transaction. The links update runs first and reports which slugs
+// it found; the rest of the batch is written for those only.
+func (s *SQL) FlushClicks(ctx context.Context, b ClickBatch) ([]string, error) {
+	var orphaned []string
+	err := s.WithTx(ctx, func(st Store) error {
+		tx := st.(*SQL)
+		found, err := tx.updateLinkClicks(ctx, b.Links)
+		if err != nil {
+			return err
+		}
+		orphaned = b.orphans(found)
+		b := b.without(orphaned)
+		if len(b.Links) == 0 {
+			return nil
+		}
+
+		dailyQ, dailyArgs := buildUpsertDaily(b.Links)
+		if _, err := tx.tx.ExecContext(ctx, dailyQ, dailyArgs...); err != nil {
+			return err
+		}
+		for _, bd := range []struct {
+			table, column string
+			rows          map[BreakdownKey]int64
+		}{
+			{"country_clicks", "country", b.Countries},
+			{"language_clicks", "language", b.Languages},
+			{"source_clicks", "source", b.Sources},
+		} {
+			if len(bd.rows) == 0 {
+				continue
+			}
+			q, args := buildUpsertBreakdown(bd.table, bd.column, bd.rows)
+			if _, err := tx.tx.ExecContext(ctx, q, args...); err != nil {
+				return err
+			}
+		}
+		// variants are few per slug, so plain updates are fine here
+		for k, cnt := range b.Variants {
+			if err := tx.AddVariantClick(ctx, db.AddVariantClickParams{
+				Clicks: sql.NullInt64{Int64: cnt, Valid: true},
+				Slug:   k.Slug,
+				Name:   k.Value,
+			}); err != nil {
+				return err
+			}
+		}
+		return nil
+	})
+	if err != nil {
+		return nil, err
+	}
+	return orphaned, nil
+}
+
+// updateLinkClicks adds the batch's counts to existing links only and
+// returns the slugs it updated.
+func (s *SQL) updateLinkClicks(ctx context.Context, rows map[string]int64) ([]string, error) {
+	if len(rows) == 0 {
+		return nil, nil
+	}
+	q, args := buildUpdateLinks(rows)
+	res, err := s.tx.QueryContext(ctx, q, args...)
+	if err != nil {
+		return nil, err
+	}
+	defer res.Close()
+	var found []string
+	for res.Next() {
+		var slug string
+		if err := res.Scan(&slug); err != nil {
+			return nil, err
+		}
+		found = append(found, slug)
+	}
+	return found, res.Err()
+}
+
+// buildUpdateLinks builds a multi-row update of links.clicks that never
+// inserts, returning the slugs of the rows it touched.
+func buildUpdateLinks(rows map[string]int64) (string, []interface{}) {
+	n := len(rows)
+	// VALUES (?, ?), (?, ?) ...
+	v := make([]string, 0, n)
+	args := make([]interface{}, 0, n*2)
+	for slug, cnt := range rows {
+		v = append(v, "(?, ?)")
+		args = append(args, slug, cnt)
+	}
+	q := fmt.Sprintf(
+		"WITH batch(slug, clicks) AS (VALUES %s) UPDATE links SET clicks = links.clicks + batch.clicks FROM batch WHERE links.slug = batch.slug RETURNING links.slug;",
+		strings.Join(v, ","),
+	)
+	return q, args
+}
+
+// buildUpsertDaily builds multi-row upsert for daily_clicks (uses date('now')).
+func buildUpsertDaily(rows map[string]int64) (string, []interface{}) {
+	n := len(rows)
+	v := make([]string, 0, n)
+	args := make([]interface{}, 0, n*2)
+	for slug, cnt := range rows {
+		// VALUES (?, date('now'), ?)
+		v = append(v, "(?, date('now'), ?)")
+		args = append(args, slug, cnt)
+	}
+	q := fmt.Sprintf(
+		"INSERT INTO daily_clicks (slug, day, clicks) VALUES %s ON CONFLICT(slug, day) DO UPDATE SET clicks = clicks + excluded.clicks;",
+		strings.Join(v, ","),
+	)
+	return q, args
+}
+
+// buildUpsertBreakdown builds multi-row upsert for a per-day breakdown table
+// such as country_clicks, where column holds the breakdown value (uses date('now')).
+func buildUpsertBreakdown(table, column string, rows map[BreakdownKey]int64) (string, []interface{}) {
+	n := len(rows)
+	v := make([]string, 0, n)
+	args := make([]interface{}, 0, n*3)
+	for k, cnt := range rows {
+		v = append(v, "(?, date('now'), ?, ?)")
+		args = append(args, k.Slug, k.Value, cnt)
+	}
+	q := fmt.Sprintf(
+		"INSERT INTO %s (slug, day, %s, clicks) VALUES %s ON CONFLICT(slug, day, %s) DO UPDATE SET clicks = clicks + excluded.clicks;",
+		table, column, strings.Join(v, ","), column,
+	)
+	return q, args
+}
@@ -0,0 +1,282 @@
+// Package store is shotr's persistence layer. Handlers and the click
+// worker talk to the Store interface; SQL backs it with sqlc's queries
+// against sqlite3 or libsql, Postgres with their PostgreSQL twins, and
+// Memory keeps everything in process.
+package store
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+	"time"
+
+	"shotr/db"
+)
+
+// ErrDuplicate is returned by implementations without a driver error of
+// their own when an insert hits a unique key, e.g. a slug that is taken.
+var ErrDuplicate = errors.New("store: duplicate key")
+
+// LinkStore holds links and everything configured on them. Lookups of a
+// missing link return sql.ErrNoRows.
+type LinkStore interface {
+	AddLink(ctx context.Context, arg db.AddLinkParams) (db.Link, error)
+	GetLink(ctx context.Context, slug string) (db.Link, error)
+	GetLinkStats(ctx context.Context, slug string) (db.Link, error)
+	CountLinks(ctx context.Context) (int64, error)
+	DeleteLinkByID(ctx context.Context, id int64) error
+	UpdateLinkURL(ctx context.Context, arg db.UpdateLinkURLParams) error
+	UpdateLinkSlug(ctx context.Context, arg db.UpdateLinkSlugParams) error
+	SetLinkPassword(ctx context.Context, arg db.SetLinkPasswordParams) error
+	SetLinkInterstitial(ctx context.Context, arg db.SetLinkInterstitialParams) error
+	SetLinkOriginalURL(ctx context.Context, arg db.SetLinkOriginalURLParams) error
+	SetLinkTakedown(ctx context.Context, arg db.SetLinkTakedownParams) error
+
+	AddLinkTarget(ctx context.Context, arg db.AddLinkTargetParams) error
+	GetLinkTargets(ctx context.Context, slug string) ([]db.LinkTarget, error)
+	AddLinkVariant(ctx context.Context, arg db.AddLinkVariantParams) error
+	GetLinkVariants(ctx context.Context, slug string) ([]db.LinkVariant, error)
+	AddLinkSchedule(ctx context.Context, arg db.AddLinkScheduleParams) error
+	GetLinkSchedules(ctx context.Context, slug string) ([]db.LinkSchedule, error)
+
+	// AddLinkDestination records who created a plain link and its
+	// normalized destination, so FindLinkByDestination can hand the link
+	// back instead of creating another. Taken down links, and links since
+	// pointed at another URL, aren't found.
+	AddLinkDestination(ctx context.Context, arg db.AddLinkDestinationParams) error
+	FindLinkByDestination(ctx context.Context, arg db.FindLinkByDestinationParams) (db.FindLinkByDestinationRow, error)
+}
+
+// ClickStore records clicks and reads back the last week of analytics.
+type ClickStore interface {
+	// FlushClicks writes a batch of aggregated clicks in one go. Counters
+	// only move for links that exist; clicks for any other slug, e.g. one
+	// deleted since the click was queued, are dropped and the slugs returned.
+	FlushClicks(ctx context.Context, b ClickBatch) (orphaned []string, err error)
+
+	// AddClick reports how many links it updated, 0 for a missing slug.
+	AddClick(ctx context.Context, arg db.AddClickParams) (int64, error)
+	SaveDailyClicks(ctx context.Context, arg db.SaveDailyClicksParams) error
+	SaveCountryClicks(ctx context.Context, arg db.SaveCountryClicksParams) error
+	SaveLanguageClicks(ctx context.Context, arg db.SaveLanguageClicksParams) error
+	SaveSourceClicks(ctx context.Context, arg db.SaveSourceClicksParams) error
+	AddVariantClick(ctx context.Context, arg db.AddVariantClickParams) error
+
+	GetDailyClicks(ctx context.Context, slug string) ([]db.GetDailyClicksRow, error)
+	GetCountryClicks(ctx context.Context, slug string) ([]db.GetCountryClicksRow, error)
+	GetLanguageClicks(ctx context.Context, slug string) ([]db.GetLanguageClicksRow, error)
+	GetSourceClicks(ctx context.Context, slug string) ([]db.GetSourceClicksRow, error)
+}
+
+// ReportStore holds abuse reports.
+type ReportStore interface {
+	AddReport(ctx context.Context, arg db.AddReportParams) (db.Report, error)
+	GetReport(ctx context.Context, id int64) (db.Report, error)
+	ListReports(ctx context.Context, arg db.ListReportsParams) ([]db.Report, error)
+	ResolveReports(ctx context.Context, arg db.ResolveReportsParams) error
+}
+
+// IdempotencyStore keeps the responses to create requests that carried an
+// Idempotency-Key, so a retry gets the same answer instead of a second link.
+// Times are unix seconds.
+type IdempotencyStore interface {
+	// GetIdempotentResponse finds a response that hasn't expired by arg.Now.
+	GetIdempotentResponse(ctx context.Context, arg db.GetIdempotentResponseParams) (db.GetIdempotentResponseRow, error)
+	// SaveIdempotentResponse reports 0 rows if the key already has one.
+	SaveIdempotentResponse(ctx context.Context, arg db.SaveIdempotentResponseParams) (int64, error)
+	DeleteExpiredIdempotencyKeys(ctx context.Context, now int64) error
+}
+
+// TransferStore moves links and their click history in and out in bulk,
+// for shotr export and shotr import.
+type TransferStore interface {
+	// ListLinks pages through links in id order, after arg.AfterID.
+	ListLinks(ctx context.Context, arg db.ListLinksParams) ([]db.Link, error)
+	// ListDailyClicks is a link's whole daily history, oldest first.
+	ListDailyClicks(ctx context.Context, slug string) ([]db.ListDailyClicksRow, error)
+	// ListLinkDestinations is who may have the link handed back by
+	// FindLinkByDestination, and for which destination.
+	ListLinkDestinations(ctx context.Context, slug string) ([]db.ListLinkDestinationsRow, error)
+	// PutLink inserts rec, or if its slug is taken replaces that link
+	// whole: its row, rules, destinations and click history become rec's.
+	PutLink(ctx context.Context, rec LinkRecord) error
+}
+
+// LinkRecord is a link as it travels between shotr instances.
+type LinkRecord struct {
+	Slug      string
+	URL       string
+	User      string
+	CreatedAt time.Time
+	Clicks    int64
+	Daily     []DailyCount
+
+	OriginalURL    string // the URL as submitted, when canonicalizing changed it
+	PasswordHash   string
+	Interstitial   bool
+	TakedownStatus int64 // 410 or 451 once taken down, otherwise 0
+	TakedownReason string
+
+	Targets      []TargetRecord
+	Variants     []VariantRecord
+	Schedules    []ScheduleRecord
+	Destinations []db.ListLinkDestinationsRow
+}
+
+// TargetRecord is a device, country or language rule.
+type TargetRecord struct {
+	Kind  string
+	Value string
+	URL   string
+}
+
+// VariantRecord is one arm of an A/B split and the clicks it has had.
+type VariantRecord struct {
+	Name   string
+	URL    string
+	Weight int64
+	Clicks int64
+}
+
+// ScheduleRecord is a time window; a zero StartsAt or EndsAt leaves that
+// end open.
+type ScheduleRecord struct {
+	StartsAt time.Time
+	EndsAt   time.Time
+	URL      string
+}
+
+// DailyCount is one UTC day of a link's clicks.
+type DailyCount struct {
+	Day    time.Time
+	Clicks int64
+}
+
+// Store is the whole persistence layer.
+type Store interface {
+	LinkStore
+	ClickStore
+	ReportStore
+	IdempotencyStore
+	TransferStore
+
+	// WithTx runs fn against a Store whose writes commit together if fn
+	// returns nil and are discarded otherwise. A nested call is a
+	// savepoint: if its fn fails only its own writes are discarded and the
+	// outer transaction carries on.
+	WithTx(ctx context.Context, fn func(s Store) error) error
+}
+
+// BreakdownKey identifies one (slug, value) counter in a batch, e.g. a
+// country or a variant name.
+type BreakdownKey struct {
+	Slug  string
+	Value string
+}
+
+// ClickBatch is a flush worth of clicks, summed per slug and per breakdown.
+type ClickBatch struct {
+	Links     map[string]int64
+	Countries map[BreakdownKey]int64
+	Languages map[BreakdownKey]int64
+	Sources   map[BreakdownKey]int64
+	Variants  map[BreakdownKey]int64
+}
+
+// NewClickBatch returns an empty batch ready to count into.
+func NewClickBatch() ClickBatch {
+	return ClickBatch{
+		Links:     make(map[string]int64),
+		Countries: make(map[BreakdownKey]int64),
+		Languages: make(map[BreakdownKey]int64),
+		Sources:   make(map[BreakdownKey]int64),
+		Variants:  make(map[BreakdownKey]int64),
+	}
+}
+
+// without returns the batch minus every counter of the given slugs.
+func (b ClickBatch) without(slugs []string) ClickBatch {
+	if len(slugs) == 0 {
+		return b
+	}
+	drop := make(map[string]bool, len(slugs))
+	for _, s := range slugs {
+		drop[s] = true
+	}
+	out := NewClickBatch()
+	for slug, n := range b.Links {
+		if !drop[slug] {
+			out.Links[slug] = n
+		}
+	}
+	for _, m := range []struct{ from, to map[BreakdownKey]int64 }{
+		{b.Countries, out.Countries},
+		{b.Languages, out.Languages},
+		{b.Sources, out.Sources},
+		{b.Variants, out.Variants},
+	} {
+		for k, n := range m.from {
+			if !drop[k.Slug] {
+				m.to[k] = n
+			}
+		}
+	}
+	return out
+}
+
+// orphans lists the batch's slugs missing from found.
+func (b ClickBatch) orphans(found []string) []string {
+	seen := make(map[string]bool, len(found))
+	for _, s := range found {
+		seen[s] = true
+	}
+	var out []string
+	for slug := range b.Links {
+		if !seen[slug] {
+			out = append(out, slug)
+		}
+	}
+	return out
+}
+
+// putLinkRules writes the rules and destinations of rec, whose link row
+// PutLink has just written with none left over from before.
+func putLinkRules(ctx context.Context, st Store, rec LinkRecord) error {
+	for _, t := range rec.Targets {
+		if err := st.AddLinkTarget(ctx, db.AddLinkTargetParams{Slug: rec.Slug, Kind: t.Kind, Value: t.Value, Url: t.URL}); err != nil {
+			return err
+		}
+	}
+	for _, v := range rec.Variants {
+		if err := st.AddLinkVariant(ctx, db.AddLinkVariantParams{Slug: rec.Slug, Name: v.Name, Url: v.URL, Weight: v.Weight}); err != nil {
+			return err
+		}
+		if v.Clicks == 0 {
+			continue
+		}
+		if err := st.AddVariantClick(ctx, db.AddVariantClickParams{Clicks: sql.NullInt64{Int64: v.Clicks, Valid: true}, Slug: rec.Slug, Name: v.Name}); err != nil {
+			return err
+		}
+	}
+	for _, w := range rec.Schedules {
+		if err := st.AddLinkSchedule(ctx, db.AddLinkScheduleParams{
+			Slug:     rec.Slug,
+			StartsAt: sql.NullTime{Time: w.StartsAt.UTC(), Valid: !w.StartsAt.IsZero()},
+			EndsAt:   sql.NullTime{Time: w.EndsAt.UTC(), Valid: !w.EndsAt.IsZero()},
+			Url:      w.URL,
+		}); err != nil {
+			return err
+		}
+	}
+	for _, d := range rec.Destinations {
+		if err := st.AddLinkDestination(ctx, db.AddLinkDestinationParams{Slug: rec.Slug, Owner: d.Owner, UrlKey: d.UrlKey}); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// nullString is s, NULL when empty.
+func nullString(s string) sql.NullString {
+	return sql.NullString{String: s, Valid: s != ""}
+}
@@ -0,0 +1,365 @@
+package store_test
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+	"time"
+
+	_ "github.com/lib/pq"
+	_ "github.com/mattn/go-sqlite3"
+	"go.uber.org/zap"
+
+	"shotr/db"
+	"shotr/migrations"
+	"shotr/store"
+)
+
+// backends are the stores every shared case runs against. Postgres only
+// runs when DATABASE_URL points at a PostgreSQL database the tests may
+// empty, e.g. the one from docker-compose.yml.
+var backends = []struct {
+	name string
+	open func(t *testing.T) store.Store
+}{
+	{"memory", func(t *testing.T) store.Store { return store.NewMemory() }},
+	{"sqlite", openSQLite},
+	{"postgres", openPostgres},
+}
+
+// forEachStore runs fn as a subtest against a fresh, empty store of each
+// backend.
+func forEachStore(t *testing.T, fn func(t *testing.T, st store.Store)) {
+	for _, b := range backends {
+		t.Run(b.name, func(t *testing.T) {
+			fn(t, b.open(t))
+		})
+	}
+}
+
+// openSQLite is a migrated sqlite file in the test's temp dir.
+func openSQLite(t *testing.T) store.Store {
+	t.Helper()
+	return store.NewSQL(openFile(t, filepath.Join(t.TempDir(), "shotr.db")), nil)
+}
+
+// openFile opens the sqlite database at path the way main opens the
+// writer, migrating it first.
+func openFile(t *testing.T, path string) *sql.DB {
+	t.Helper()
+	conn, err := sql.Open("sqlite3", path+"?_journal_mode=WAL&_txlock=immediate&_busy_timeout=5000")
+	if err != nil {
+		t.Fatal(err)
+	}
+	conn.SetMaxOpenConns(1)
+	t.Cleanup(func() { conn.Close() })
+	if err := migrations.SQLite.Up(context.Background(), conn, zap.NewNop()); err != nil {
+		t.Fatal(err)
+	}
+	return conn
+}
+
+// openPostgres migrates the database at DATABASE_URL and empties every
+// table, so each case starts from nothing.
+func openPostgres(t *testing.T) store.Store {
+	t.Helper()
+	url := os.Getenv("DATABASE_URL")
+	if !strings.HasPrefix(url, "postgres://") && !strings.HasPrefix(url, "postgresql://") {
+		t.Skip("set DATABASE_URL to a postgres:// database to run the PostgreSQL cases")
+	}
+	conn, err := sql.Open("postgres", url)
+	if err != nil {
+		t.Fatal(err)
+	}
+	t.Cleanup(func() { conn.Close() })
+	ctx := context.Background()
+	if err := migrations.Postgres.Up(ctx, conn, zap.NewNop()); err != nil {
+		t.Fatal(err)
+	}
+
+	rows, err := conn.QueryContext(ctx, `SELECT table_name FROM information_schema.tables
+WHERE table_schema = current_schema() AND table_type = 'BASE TABLE' AND table_name <> 'schema_migrations'`)
+	if err != nil {
+		t.Fatal(err)
+	}
+	var tables []string
+	for rows.Next() {
+		var name string
+		if err := rows.Scan(&name); err != nil {
+			t.Fatal(err)
+		}
+		tables = append(tables, name)
+	}
+	if err := rows.Close(); err != nil {
+		t.Fatal(err)
+	}
+	if _, err := conn.ExecContext(ctx, `TRUNCATE `+strings.Join(tables, ", ")+` RESTART IDENTITY`); err != nil {
+		t.Fatal(err)
+	}
+	return store.NewPostgres(conn)
+}
+
+func addLink(t *testing.T, st store.Store, slug, url string) db.Link {
+	t.Helper()
+	link, err := st.AddLink(context.Background(), db.AddLinkParams{Slug: slug, Url: url})
+	if err != nil {
+		t.Fatalf("add %s: %v", slug, err)
+	}
+	return link
+}
+
+func TestAddAndGetLink(t *testing.T) {
+	forEachStore(t, func(t *testing.T, st store.Store) {
+		ctx := context.Background()
+		added, err := st.AddLink(ctx, db.AddLinkParams{
+			Slug: "abc",
+			Url:  "https://example.com/",
+			User: sql.NullString{String: "alice", Valid: true},
+		})
+		if err != nil {
+			t.Fatal(err)
+		}
+		got, err := st.GetLink(ctx, "abc")
+		if err != nil {
+			t.Fatal(err)
+		}
+		if got.ID != added.ID || got.Url != "https://example.com/" || got.User.String != "alice" {
+			t.Errorf("GetLink = %+v, want what AddLink returned, %+v", got, added)
+		}
+
+		if _, err := st.AddLink(ctx, db.AddLinkParams{Slug: "abc", Url: "https://other.example/"}); err == nil {
+			t.Error("adding a taken slug succeeded")
+		}
+		if _, err := st.GetLink(ctx, "nope"); !errors.Is(err, sql.ErrNoRows) {
+			t.Errorf("GetLink of a missing slug: err = %v, want sql.ErrNoRows", err)
+		}
+	})
+}
+
+func TestWithTxRollsBack(t *testing.T) {
+	forEachStore(t, func(t *testing.T, st store.Store) {
+		ctx := context.Background()
+		boom := errors.New("boom")
+		err := st.WithTx(ctx, func(tx store.Store) error {
+			addLink(t, tx, "gone", "https://example.com/")
+			return boom
+		})
+		if err != boom {
+			t.Fatalf("WithTx = %v, want the callback's error", err)
+		}
+		if _, err := st.GetLink(ctx, "gone"); !errors.Is(err, sql.ErrNoRows) {
+			t.Errorf("link from a rolled back transaction: err = %v, want sql.ErrNoRows", err)
+		}
+	})
+}
+
+// A failed statement inside a nested WithTx must leave the outer
+// transaction usable; postgres refuses everything after an error unless
+// it was rolled back to a savepoint.
+func TestNestedWithTxRecoversFromFailedStatement(t *testing.T) {
+	forEachStore(t, func(t *testing.T, st store.Store) {
+		ctx := context.Background()
+		err := st.WithTx(ctx, func(tx store.Store) error {
+			addLink(t, tx, "first", "https://example.com/1")
+			err := tx.WithTx(ctx, func(q store.Store) error {
+				_, err := q.AddLink(ctx, db.AddLinkParams{Slug: "first", Url: "https://example.com/dup"})
+				return err
+			})
+			if err == nil {
+				t.Error("nested insert of a taken slug succeeded")
+			}
+			_, err = tx.AddLink(ctx, db.AddLinkParams{Slug: "second", Url: "https://example.com/2"})
+			return err
+		})
+		if err != nil {
+			t.Fatalf("outer transaction after a failed savepoint: %v", err)
+		}
+		for _, slug := range []string{"first", "second"} {
+			if _, err := st.GetLink(ctx, slug); err != nil {
+				t.Errorf("GetLink(%s): %v", slug, err)
+			}
+		}
+	})
+}
+
+func TestIdempotentResponses(t *testing.T) {
+	forEachStore(t, func(t *testing.T, st store.Store) {
+		ctx := context.Background()
+		now := time.Now().Unix()
+		save := db.SaveIdempotentResponseParams{
+			Owner: "alice", Key: "k1", RequestHash: "h", Status: 201, Body: `{"slug":"abc"}`, ExpiresAt: now + 60,
+		}
+		if n, err := st.SaveIdempotentResponse(ctx, save); err != nil || n != 1 {
+			t.Fatalf("first save = %d, %v; want 1 row", n, err)
+		}
+		again := save
+		again.Body = `{"slug":"other"}`
+		if n, err := st.SaveIdempotentResponse(ctx, again); err != nil || n != 0 {
+			t.Fatalf("second save = %d, %v; want 0 rows", n, err)
+		}
+
+		got, err := st.GetIdempotentResponse(ctx, db.GetIdempotentResponseParams{Owner: "alice", Key: "k1", Now: now})
+		if err != nil {
+			t.Fatal(err)
+		}
+		if got.Body != save.Body || got.Status != 201 {
+			t.Errorf("got %+v, want the first response", got)
+		}
+		if _, err := st.GetIdempotentResponse(ctx, db.GetIdempotentResponseParams{Owner: "bob", Key: "k1", Now: now}); !errors.Is(err, sql.ErrNoRows) {
+			t.Errorf("another owner's key: err = %v, want sql.ErrNoRows", err)
+		}
+
+		if err := st.DeleteExpiredIdempotencyKeys(ctx, now+60); err != nil {
+			t.Fatal(err)
+		}
+		if _, err := st.GetIdempotentResponse(ctx, db.GetIdempotentResponseParams{Owner: "alice", Key: "k1", Now: now}); !errors.Is(err, sql.ErrNoRows) {
+			t.Errorf("expired key: err = %v, want sql.ErrNoRows", err)
+		}
+	})
+}
+
+func TestPutLinkOverwritesWholeLink(t *testing.T) {
+	forEachStore(t, func(t *testing.T, st store.Store) {
+		ctx := context.Background()
+		addLink(t, st, "abc", "https://old.example/")
+		for _, err := range []error{
+			st.SetLinkPassword(ctx, db.SetLinkPasswordParams{PasswordHash: sql.NullString{String: "hash", Valid: true}, Slug: "abc"}),
+			st.SetLinkTakedown(ctx, db.SetLinkTakedownParams{TakedownStatus: sql.NullInt64{Int64: 410, Valid: true}, Slug: "abc"}),
+			st.AddLinkTarget(ctx, db.AddLinkTargetParams{Slug: "abc", Kind: "device", Value: "ios", Url: "https://old.example/ios"}),
+			st.AddLinkVariant(ctx, db.AddLinkVariantParams{Slug: "abc", Name: "a", Url: "https://old.example/a", Weight: 1}),
+		} {
+			if err != nil {
+				t.Fatal(err)
+			}
+		}
+
+		day := time.Now().UTC().Truncate(24 * time.Hour)
+		err := st.PutLink(ctx, store.LinkRecord{
+			Slug:      "abc",
+			URL:       "https://new.example/",
+			User:      "bob",
+			CreatedAt: day,
+			Clicks:    7,
+			Daily:     []store.DailyCount{{Day: day, Clicks: 7}},
+		})
+		if err != nil {
+			t.Fatal(err)
+		}
+
+		link, err := st.GetLink(ctx, "abc")
+		if err != nil {
+			t.Fatal(err)
+		}
+		if link.Url != "https://new.example/" || link.User.String != "bob" || link.Clicks.Int64 != 7 {
+			t.Errorf("link = %+v, want the imported record", link)
+		}
+		if link.PasswordHash.Valid || link.TakedownStatus.Valid {
+			t.Errorf("link kept its old password or takedown: %+v", link)
+		}
+		if targets, _ := st.GetLinkTargets(ctx, "abc"); len(targets) != 0 {
+			t.Errorf("link kept its old targets: %+v", targets)
+		}
+		if variants, _ := st.GetLinkVariants(ctx, "abc"); len(variants) != 0 {
+			t.Errorf("link kept its old variants: %+v", variants)
+		}
+		daily, err := st.ListDailyClicks(ctx, "abc")
+		if err != nil {
+			t.Fatal(err)
+		}
+		if len(daily) != 1 || daily[0].Clicks.Int64 != 7 {
+			t.Errorf("daily clicks = %+v, want the imported day", daily)
+		}
+	})
+}
+
+func TestPutLinkWritesWholeRecord(t *testing.T) {
+	forEachStore(t, func(t *testing.T, st store.Store) {
+		ctx := context.Background()
+		start := time.Date(2026, 1, 1, 0, 0, 0, 0, time.UTC)
+		rec := store.LinkRecord{
+			Slug:           "abc",
+			URL:            "https://example.com/",
+			CreatedAt:      start,
+			OriginalURL:    "https://EXAMPLE.com",
+			PasswordHash:   "hash",
+			Interstitial:   true,
+			TakedownStatus: 451,
+			TakedownReason: "court order",
+			Targets:        []store.TargetRecord{{Kind: "device", Value: "ios", URL: "https://example.com/ios"}},
+			Variants:       []store.VariantRecord{{Name: "a", URL: "https://example.com/a", Weight: 3, Clicks: 5}},
+			Schedules:      []store.ScheduleRecord{{StartsAt: start, URL: "https://example.com/later"}},
+			Destinations:   []db.ListLinkDestinationsRow{{Owner: "alice", UrlKey: "https://example.com/"}},
+		}
+		// twice, so the second write goes through the overwrite path
+		for i := 0; i < 2; i++ {
+			if err := st.PutLink(ctx, rec); err != nil {
+				t.Fatal(err)
+			}
+		}
+
+		link, err := st.GetLink(ctx, "abc")
+		if err != nil {
+			t.Fatal(err)
+		}
+		if link.OriginalUrl.String != rec.OriginalURL || link.PasswordHash.String != "hash" || !link.Interstitial ||
+			link.TakedownStatus.Int64 != 451 || link.TakedownReason.String != "court order" {
+			t.Errorf("link = %+v, want every column of the record", link)
+		}
+		if targets, _ := st.GetLinkTargets(ctx, "abc"); len(targets) != 1 || targets[0].Url != "https://example.com/ios" {
+			t.Errorf("targets = %+v, want the record's one", targets)
+		}
+		if variants, _ := st.GetLinkVariants(ctx, "abc"); len(variants) != 1 || variants[0].Weight != 3 || variants[0].Clicks.Int64 != 5 {
+			t.Errorf("variants = %+v, want a with weight 3 and 5 clicks", variants)
+		}
+		windows, _ := st.GetLinkSchedules(ctx, "abc")
+		if len(windows) != 1 || !windows[0].StartsAt.Time.Equal(start) || windows[0].EndsAt.Valid {
+			t.Errorf("schedules = %+v, want one window open at the end", windows)
+		}
+		if dests, _ := st.ListLinkDestinations(ctx, "abc"); len(dests) != 1 || dests[0].Owner != "alice" {
+			t.Errorf("destinations = %+v, want alice's", dests)
+		}
+	})
+}
+
+func TestFindLinkByDestination(t *testing.T) {
+	forEachStore(t, func(t *testing.T, st store.Store) {
+		ctx := context.Background()
+		const dest = "https://example.com/"
+		for _, slug := range []string{"first", "second"} {
+			addLink(t, st, slug, dest)
+			if err := st.AddLinkDestination(ctx, db.AddLinkDestinationParams{Slug: slug, Owner: "alice", UrlKey: dest}); err != nil {
+				t.Fatal(err)
+			}
+		}
+		find := func(owner string) (string, error) {
+			row, err := st.FindLinkByDestination(ctx, db.FindLinkByDestinationParams{Owner: owner, UrlKey: dest})
+			return row.Slug, err
+		}
+
+		if slug, err := find("alice"); err != nil || slug != "first" {
+			t.Errorf("find = %q, %v; want the oldest link, first", slug, err)
+		}
+		if _, err := find("bob"); !errors.Is(err, sql.ErrNoRows) {
+			t.Errorf("another owner: err = %v, want sql.ErrNoRows", err)
+		}
+
+		// pointing the oldest link elsewhere must not hide the next one
+		if err := st.UpdateLinkURL(ctx, db.UpdateLinkURLParams{Url: "https://other.example/", Slug: "first"}); err != nil {
+			t.Fatal(err)
+		}
+		if slug, err := find("alice"); err != nil || slug != "second" {
+			t.Errorf("after moving first: find = %q, %v; want second", slug, err)
+		}
+
+		if err := st.SetLinkTakedown(ctx, db.SetLinkTakedownParams{TakedownStatus: sql.NullInt64{Int64: 410, Valid: true}, Slug: "second"}); err != nil {
+			t.Fatal(err)
+		}
+		if _, err := find("alice"); !errors.Is(err, sql.ErrNoRows) {
+			t.Errorf("after taking down second: err = %v, want sql.ErrNoRows", err)
+		}
+	})
+}
@@ -0,0 +1,161 @@
+package transfer
+
+import (
+	"bufio"
+	"context"
+	"encoding/csv"
+	"encoding/json"
+	"io"
+	"strconv"
+	"time"
+
+	"shotr/db"
+	"shotr/store"
+)
+
+// csvHeader is the column order of shotr's own CSV. The rules and
+// destinations are JSON arrays in their cells. original_url is written as
+// submitted_url, because other shorteners' exports use original_url for
+// the destination.
+var csvHeader = []string{
+	"slug", "url", "user", "created_at", "clicks", "daily",
+	"submitted_url", "password_hash", "interstitial", "takedown_status", "takedown_reason",
+	"targets", "variants", "schedules", "destinations",
+}
+
+// Export writes every link with its rules and daily clicks to w in the
+// given format and returns how many links it wrote.
+func Export(ctx context.Context, st store.Store, w io.Writer, format string) (int, error) {
+	if err := checkFormat(format); err != nil {
+		return 0, err
+	}
+	bw := bufio.NewWriter(w)
+	var write func(rec store.LinkRecord) error
+	var cw *csv.Writer
+	if format == FormatCSV {
+		cw = csv.NewWriter(bw)
+		if err := cw.Write(csvHeader); err != nil {
+			return 0, err
+		}
+		write = func(rec store.LinkRecord) error {
+			targets, variants, windows, dests := wireRules(rec)
+			fields := []string{
+				rec.Slug,
+				rec.URL,
+				rec.User,
+				rec.CreatedAt.UTC().Format(time.RFC3339),
+				strconv.FormatInt(rec.Clicks, 10),
+				formatDaily(rec.Daily),
+				rec.OriginalURL,
+				rec.PasswordHash,
+				strconv.FormatBool(rec.Interstitial),
+				"",
+				rec.TakedownReason,
+			}
+			if rec.TakedownStatus != 0 {
+				fields[9] = strconv.FormatInt(rec.TakedownStatus, 10)
+			}
+			for _, list := range []any{targets, variants, windows, dests} {
+				cell, err := jsonCell(list)
+				if err != nil {
+					return err
+				}
+				fields = append(fields, cell)
+			}
+			return cw.Write(fields)
+		}
+	} else {
+		enc := json.NewEncoder(bw)
+		enc.SetEscapeHTML(false)
+		write = func(rec store.LinkRecord) error { return enc.Encode(toWire(rec)) }
+	}
+
+	n := 0
+	var after int64
+	for {
+		links, err := st.ListLinks(ctx, db.ListLinksParams{AfterID: after, Limit: pageSize})
+		if err != nil {
+			return n, err
+		}
+		for _, l := range links {
+			rec, err := record(ctx, st, l)
+			if err != nil {
+				return n, err
+			}
+			if err := write(rec); err != nil {
+				return n, err
+			}
+			n++
+			after = l.ID
+		}
+		if len(links) < pageSize {
+			break
+		}
+	}
+
+	if cw != nil {
+		cw.Flush()
+		if err := cw.Error(); err != nil {
+			return n, err
+		}
+	}
+	return n, bw.Flush()
+}
+
+// record reads everything of l that an import needs to bring it back.
+func record(ctx context.Context, st store.Store, l db.Link) (store.LinkRecord, error) {
+	rec := store.LinkRecord{
+		Slug:           l.Slug,
+		URL:            l.Url,
+		User:           l.User.String,
+		CreatedAt:      l.CreatedAt,
+		Clicks:         l.Clicks.Int64,
+		OriginalURL:    l.OriginalUrl.String,
+		PasswordHash:   l.PasswordHash.String,
+		Interstitial:   l.Interstitial,
+		TakedownStatus: l.TakedownStatus.Int64,
+		TakedownReason: l.TakedownReason.String,
+	}
+	daily, err := st.ListDailyClicks(ctx, l.Slug)
+	if err != nil {
+		return rec, err
+	}
+	for _, d := range daily {
+		rec.Daily = append(rec.Daily, store.DailyCount{Day: d.Day, Clicks: d.Clicks.Int64})
+	}
+
+	targets, err := st.GetLinkTargets(ctx, l.Slug)
+	if err != nil {
+		return rec, err
+	}
+	for _, t := range targets {
+		rec.Targets = append(rec.Targets, store.TargetRecord{Kind: t.Kind, Value: t.Value, URL: t.Url})
+	}
+	variants, err := st.GetLinkVariants(ctx, l.Slug)
+	if err != nil {
+		return rec, err
+	}
+	for _, v := range variants {
+		rec.Variants = append(rec.Variants, store.VariantRecord{Name: v.Name, URL: v.Url, Weight: v.Weight, Clicks: v.Clicks.Int64})
+	}
+	windows, err := st.GetLinkSchedules(ctx, l.Slug)
+	if err != nil {
+		return rec, err
+	}
+	for _, w := range windows {
+		rec.Schedules = append(rec.Schedules, store.ScheduleRecord{StartsAt: w.StartsAt.Time, EndsAt: w.EndsAt.Time, URL: w.Url})
+	}
+	if rec.Destinations, err = st.ListLinkDestinations(ctx, l.Slug); err != nil {
+		return rec, err
+	}
+	return rec, nil
+}
+
+// jsonCell encodes a list for a CSV cell; an empty list is an empty cell.
+func jsonCell(list any) (string, error) {
+	b, err := json.Marshal(list)
+	if err != nil || string(b) == "null" {
+		return "", err
+	}
+	return string(b), nil
+}
@@ -0,0 +1,551 @@
+package transfer
+
+import (
+	"bufio"
+	"context"
+	"database/sql"
+	"encoding/csv"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"net/url"
+	"regexp"
+	"strconv"
+	"strings"
+	"time"
+
+	"shotr/helpers"
+	"shotr/store"
+)
+
+// What to do when an imported slug is already taken.
+const (
+	OnConflictSkip      = "skip"
+	OnConflictOverwrite = "overwrite"
+	OnConflictRename    = "rename"
+)
+
+// maxRenames bounds the search for a free slug-2, slug-3, ... name.
+const maxRenames = 100
+
+// importSlug is what an imported slug may look like. It is looser than
+// vanity slugs because other shorteners allow underscores, but a slug with
+// the shape of a signed one must verify; see validate.
+var importSlug = regexp.MustCompile(`^[A-Za-z0-9_-]{1,64}$`)
+
+// reservedSlugs can't be imported, as link.Create refuses them.
+var reservedSlugs = map[string]bool{"api": true, "healthz": true, "admin": true}
+
+// Options controls an import.
+type Options struct {
+	Format     string
+	OnConflict string
+	DryRun     bool // count what would happen without writing anything
+
+	// Canonical returns the spelling of an imported destination to store.
+	// An error makes the row invalid. Nil stores URLs as they are.
+	Canonical func(raw string) (string, error)
+
+	// Check vets every destination of a record about to be written as
+	// slug, looking its chains up in st, which holds the rows imported so
+	// far. A *helpers.PolicyError makes the row invalid; any other error
+	// stops the import. Nil checks nothing.
+	Check func(ctx context.Context, st store.Store, slug string, dests []string) error
+
+	// Keyring verifies imported slugs shaped like signed ones; without it
+	// they are invalid, as the server would answer 404 for them.
+	Keyring *helpers.Keyring
+
+	// Invalid is told about each row that can't be imported; the import
+	// carries on without it.
+	Invalid func(line int, err error)
+}
+
+// Summary counts what an import did, or would do in a dry run.
+type Summary struct {
+	Read        int `json:"read"`
+	Created     int `json:"created"`
+	Overwritten int `json:"overwritten"`
+	Renamed     int `json:"renamed"`
+	Skipped     int `json:"skipped"`
+	Invalid     int `json:"invalid"`
+}
+
+// row is one parsed input record and where it came from.
+type row struct {
+	line int
+	rec  store.LinkRecord
+}
+
+// Import reads links from r and writes them pageSize at a time, each page
+// in one transaction.
+func Import(ctx context.Context, st store.Store, r io.Reader, opt Options) (Summary, error) {
+	var sum Summary
+	if err := checkFormat(opt.Format); err != nil {
+		return sum, err
+	}
+	switch opt.OnConflict {
+	case OnConflictSkip, OnConflictOverwrite, OnConflictRename:
+	default:
+		return sum, fmt.Errorf("unknown conflict policy %q (want skip, overwrite or rename)", opt.OnConflict)
+	}
+	if opt.Invalid == nil {
+		opt.Invalid = func(int, error) {}
+	}
+
+	var next func() (row, error)
+	if opt.Format == FormatCSV {
+		cr, err := newCSVReader(r)
+		if err != nil {
+			return sum, err
+		}
+		next = cr.next
+	} else {
+		next = newJSONLReader(r).next
+	}
+
+	im := &importer{opt: opt, sum: &sum}
+	if opt.DryRun {
+		// nothing is written, so remember which slugs the run has used
+		im.claimed = make(map[string]bool)
+	}
+
+	page := make([]row, 0, pageSize)
+	flush := func() error {
+		if len(page) == 0 {
+			return nil
+		}
+		var err error
+		if opt.DryRun {
+			err = im.put(ctx, st, page)
+		} else {
+			err = st.WithTx(ctx, func(tx store.Store) error { return im.put(ctx, tx, page) })
+		}
+		page = page[:0]
+		return err
+	}
+
+	for {
+		rw, err := next()
+		if errors.Is(err, io.EOF) {
+			break
+		}
+		var bad *rowError
+		if errors.As(err, &bad) {
+			sum.Read++
+			sum.Invalid++
+			opt.Invalid(bad.line, bad.err)
+			continue
+		}
+		if err != nil {
+			return sum, err
+		}
+		sum.Read++
+		if err := validate(&rw.rec, opt.Keyring); err != nil {
+			sum.Invalid++
+			opt.Invalid(rw.line, err)
+			continue
+		}
+		if opt.Canonical != nil {
+			if err := canonicalize(&rw.rec, opt.Canonical); err != nil {
+				sum.Invalid++
+				opt.Invalid(rw.line, err)
+				continue
+			}
+		}
+		page = append(page, rw)
+		if len(page) == pageSize {
+			if err := flush(); err != nil {
+				return sum, err
+			}
+		}
+	}
+	return sum, flush()
+}
+
+type importer struct {
+	opt     Options
+	sum     *Summary
+	claimed map[string]bool // dry runs only
+}
+
+// put applies the conflict policy to each row of a page and writes it.
+// Counts only reach the summary once the page is written.
+func (im *importer) put(ctx context.Context, st store.Store, page []row) error {
+	var s Summary
+	for _, rw := range page {
+		rec := rw.rec
+		taken, err := im.taken(ctx, st, rec.Slug)
+		if err != nil {
+			return fmt.Errorf("line %d: %w", rw.line, err)
+		}
+		if taken {
+			switch im.opt.OnConflict {
+			case OnConflictSkip:
+				s.Skipped++
+				continue
+			case OnConflictRename:
+				slug, err := im.rename(ctx, st, rec.Slug)
+				if err != nil {
+					return fmt.Errorf("line %d: %w", rw.line, err)
+				}
+				rec.Slug = slug
+			}
+		}
+
+		if im.opt.Check != nil {
+			err := im.opt.Check(ctx, st, rec.Slug, destinations(rec))
+			var pe *helpers.PolicyError
+			if errors.As(err, &pe) {
+				s.Invalid++
+				im.opt.Invalid(rw.line, err)
+				continue
+			}
+			if err != nil {
+				return fmt.Errorf("line %d: %w", rw.line, err)
+			}
+		}
+		switch {
+		case !taken:
+			s.Created++
+		case im.opt.OnConflict == OnConflictOverwrite:
+			s.Overwritten++
+		default:
+			s.Renamed++
+		}
+
+		if im.claimed != nil {
+			im.claimed[rec.Slug] = true
+			continue
+		}
+		if err := st.PutLink(ctx, rec); err != nil {
+			return fmt.Errorf("line %d: %w", rw.line, err)
+		}
+	}
+	im.sum.Created += s.Created
+	im.sum.Overwritten += s.Overwritten
+	im.sum.Renamed += s.Renamed
+	im.sum.Skipped += s.Skipped
+	im.sum.Invalid += s.Invalid
+	return nil
+}
+
+func (im *importer) taken(ctx context.Context, st store.Store, slug string) (bool, error) {
+	if im.claimed[slug] {
+		return true, nil
+	}
+	_, err := st.GetLink(ctx, slug)
+	if errors.Is(err, sql.ErrNoRows) {
+		return false, nil
+	}
+	return err == nil, err
+}
+
+// rename finds the first free slug-2, slug-3, ...
+func (im *importer) rename(ctx context.Context, st store.Store, slug string) (string, error) {
+	for i := 2; i < maxRenames+2; i++ {
+		cand := fmt.Sprintf("%s-%d", slug, i)
+		if len(cand) > 64 {
+			break
+		}
+		taken, err := im.taken(ctx, st, cand)
+		if err != nil {
+			return "", err
+		}
+		if !taken {
+			return cand, nil
+		}
+	}
+	return "", fmt.Errorf("no free name for slug %q", slug)
+}
+
+// validate checks a record and fills in defaults.
+func validate(rec *store.LinkRecord, keyring *helpers.Keyring) error {
+	if !importSlug.MatchString(rec.Slug) {
+		return fmt.Errorf("invalid slug %q", rec.Slug)
+	}
+	if reservedSlugs[strings.ToLower(rec.Slug)] {
+		return fmt.Errorf("slug %q is reserved", rec.Slug)
+	}
+	if helpers.IsSigned(rec.Slug) && !keyring.Verify(rec.Slug) {
+		return fmt.Errorf("slug %q looks signed but doesn't verify with this server's keys", rec.Slug)
+	}
+	for _, d := range destinations(*rec) {
+		u, err := url.Parse(d)
+		if err != nil || (u.Scheme != "http" && u.Scheme != "https") || u.Host == "" {
+			return fmt.Errorf("invalid url %q", d)
+		}
+	}
+	if rec.Clicks < 0 {
+		return errors.New("negative clicks")
+	}
+	switch rec.TakedownStatus {
+	case 0, 410, 451:
+	default:
+		return fmt.Errorf("bad takedown status %d", rec.TakedownStatus)
+	}
+	if rec.CreatedAt.IsZero() {
+		rec.CreatedAt = time.Now().UTC()
+	}
+	return nil
+}
+
+// destinations is every URL the link can redirect to.
+func destinations(rec store.LinkRecord) []string {
+	out := []string{rec.URL}
+	for _, t := range rec.Targets {
+		out = append(out, t.URL)
+	}
+	for _, v := range rec.Variants {
+		out = append(out, v.URL)
+	}
+	for _, w := range rec.Schedules {
+		out = append(out, w.URL)
+	}
+	return out
+}
+
+// canonicalize respells every destination of rec with canonical.
+func canonicalize(rec *store.LinkRecord, canonical func(string) (string, error)) error {
+	urls := []*string{&rec.URL}
+	for i := range rec.Targets {
+		urls = append(urls, &rec.Targets[i].URL)
+	}
+	for i := range rec.Variants {
+		urls = append(urls, &rec.Variants[i].URL)
+	}
+	for i := range rec.Schedules {
+		urls = append(urls, &rec.Schedules[i].URL)
+	}
+	for _, u := range urls {
+		c, err := canonical(*u)
+		if err != nil {
+			return err
+		}
+		*u = c
+	}
+	return nil
+}
+
+// rowError is a malformed input row; the import skips it.
+type rowError struct {
+	line int
+	err  error
+}
+
+func (e *rowError) Error() string { return fmt.Sprintf("line %d: %v", e.line, e.err) }
+
+// --- JSON Lines ---
+
+type jsonlReader struct {
+	sc   *bufio.Scanner
+	line int
+}
+
+func newJSONLReader(r io.Reader) *jsonlReader {
+	sc := bufio.NewScanner(r)
+	sc.Buffer(make([]byte, 64*1024), 4*1024*1024)
+	return &jsonlReader{sc: sc}
+}
+
+func (j *jsonlReader) next() (row, error) {
+	for j.sc.Scan() {
+		j.line++
+		text := strings.TrimSpace(j.sc.Text())
+		if text == "" {
+			continue
+		}
+		var w wireRecord
+		if err := json.Unmarshal([]byte(text), &w); err != nil {
+			return row{}, &rowError{j.line, err}
+		}
+		rec, err := fromWire(w)
+		if err != nil {
+			return row{}, &rowError{j.line, err}
+		}
+		return row{line: j.line, rec: rec}, nil
+	}
+	if err := j.sc.Err(); err != nil {
+		return row{}, err
+	}
+	return row{}, io.EOF
+}
+
+// --- CSV ---
+
+// csvColumns maps header names, lower-cased with spaces and dashes as
+// underscores, to the field they hold. Besides shotr's own columns it
+// knows the names Bitly, Rebrandly, YOURLS, TinyURL and Short.io use.
+var csvColumns = map[string]string{
+	"slug": "slug", "short_url": "slug", "short_link": "slug", "shortlink": "slug",
+	"bitlink": "slug", "link": "slug", "keyword": "slug", "alias": "slug",
+	"back_half": "slug", "shorturl": "slug", "path": "slug",
+
+	"url": "url", "long_url": "url", "longurl": "url", "destination": "url",
+	"destination_url": "url", "original_url": "url", "target": "url", "target_url": "url",
+
+	"user": "user", "owner": "user", "created_by": "user",
+
+	"created_at": "created_at", "created": "created_at", "date": "created_at",
+	"timestamp": "created_at", "creation_date": "created_at", "date_created": "created_at",
+
+	"clicks": "clicks", "total_clicks": "clicks", "click_count": "clicks",
+	"visits": "clicks", "user_clicks": "clicks",
+
+	"daily": "daily",
+
+	// the rest only come from shotr's own CSV
+	"submitted_url": "submitted_url", "password_hash": "password_hash", "interstitial": "interstitial",
+	"takedown_status": "takedown_status", "takedown_reason": "takedown_reason",
+	"targets": "targets", "variants": "variants", "schedules": "schedules", "destinations": "destinations",
+}
+
+type csvReader struct {
+	r    *csv.Reader
+	cols map[string]int
+}
+
+func newCSVReader(r io.Reader) (*csvReader, error) {
+	cr := csv.NewReader(r)
+	cr.FieldsPerRecord = -1
+	cr.LazyQuotes = true
+	cr.TrimLeadingSpace = true
+
+	header, err := cr.Read()
+	if err != nil {
+		return nil, fmt.Errorf("read csv header: %w", err)
+	}
+	cols := make(map[string]int)
+	for i, h := range header {
+		h = strings.TrimPrefix(h, "\ufeff") // byte order mark from spreadsheet exports
+		h = strings.NewReplacer(" ", "_", "-", "_").Replace(strings.ToLower(strings.TrimSpace(h)))
+		if field, ok := csvColumns[h]; ok {
+			if _, dup := cols[field]; !dup {
+				cols[field] = i
+			}
+		}
+	}
+	if _, ok := cols["slug"]; !ok {
+		return nil, errors.New("csv has no slug or short link column")
+	}
+	if _, ok := cols["url"]; !ok {
+		return nil, errors.New("csv has no url or long url column")
+	}
+	return &csvReader{r: cr, cols: cols}, nil
+}
+
+func (c *csvReader) next() (row, error) {
+	fields, err := c.r.Read()
+	if err != nil {
+		var pe *csv.ParseError
+		if errors.As(err, &pe) {
+			return row{}, &rowError{pe.Line, pe.Err}
+		}
+		return row{}, err
+	}
+	line, _ := c.r.FieldPos(0)
+	get := func(field string) string {
+		if i, ok := c.cols[field]; ok && i < len(fields) {
+			return strings.TrimSpace(fields[i])
+		}
+		return ""
+	}
+
+	rec := store.LinkRecord{
+		Slug:           slugFromLink(get("slug")),
+		URL:            get("url"),
+		User:           get("user"),
+		OriginalURL:    get("submitted_url"),
+		PasswordHash:   get("password_hash"),
+		TakedownReason: get("takedown_reason"),
+	}
+	if rec.CreatedAt, err = parseTime(get("created_at")); err != nil {
+		return row{}, &rowError{line, err}
+	}
+	if rec.Clicks, err = parseCount(get("clicks")); err != nil {
+		return row{}, &rowError{line, err}
+	}
+	if rec.Daily, err = parseDaily(get("daily")); err != nil {
+		return row{}, &rowError{line, err}
+	}
+	if v := get("interstitial"); v != "" {
+		if rec.Interstitial, err = strconv.ParseBool(v); err != nil {
+			return row{}, &rowError{line, fmt.Errorf("bad interstitial %q", v)}
+		}
+	}
+	if v := get("takedown_status"); v != "" {
+		if rec.TakedownStatus, err = strconv.ParseInt(v, 10, 64); err != nil {
+			return row{}, &rowError{line, fmt.Errorf("bad takedown status %q", v)}
+		}
+	}
+
+	var w wireRecord
+	for field, list := range map[string]any{"targets": &w.Targets, "variants": &w.Variants, "schedules": &w.Schedules, "destinations": &w.Destinations} {
+		if v := get(field); v != "" {
+			if err := json.Unmarshal([]byte(v), list); err != nil {
+				return row{}, &rowError{line, fmt.Errorf("bad %s: %w", field, err)}
+			}
+		}
+	}
+	setRules(&rec, w.Targets, w.Variants, w.Schedules, w.Destinations)
+	return row{line: line, rec: rec}, nil
+}
+
+// slugFromLink turns a short link such as "bit.ly/3abc" or
+// "https://rebrand.ly/abc?x=1" into its slug; a bare slug is kept.
+func slugFromLink(s string) string {
+	if i := strings.IndexAny(s, "?#"); i >= 0 {
+		s = s[:i]
+	}
+	s = strings.TrimRight(s, "/")
+	if i := strings.LastIndex(s, "/"); i >= 0 {
+		s = s[i+1:]
+	}
+	return s
+}
+
+// timeLayouts are the creation time formats seen in exports, most
+// specific first.
+var timeLayouts = []string{
+	time.RFC3339Nano,
+	"2006-01-02 15:04:05Z07:00",
+	"2006-01-02 15:04:05",
+	"2006-01-02T15:04:05",
+	"2006-01-02 15:04",
+	"2006-01-02",
+	"01/02/2006 15:04:05",
+	"01/02/2006 15:04",
+	"01/02/2006",
+	"Jan 2, 2006",
+}
+
+// parseTime reads a creation time; empty means unknown, which import
+// replaces with now.
+func parseTime(s string) (time.Time, error) {
+	if s == "" {
+		return time.Time{}, nil
+	}
+	for _, layout := range timeLayouts {
+		if t, err := time.Parse(layout, s); err == nil {
+			return t.UTC(), nil
+		}
+	}
+	if secs, err := strconv.ParseInt(s, 10, 64); err == nil {
+		return time.Unix(secs, 0).UTC(), nil
+	}
+	return time.Time{}, fmt.Errorf("unrecognised time %q", s)
+}
+
+// parseCount reads a click count, allowing thousands separators.
+func parseCount(s string) (int64, error) {
+	s = strings.ReplaceAll(strings.TrimSpace(s), ",", "")
+	if s == "" {
+		return 0, nil
+	}
+	n, err := strconv.ParseInt(s, 10, 64)
+	if err != nil || n < 0 {
+		return 0, fmt.Errorf("bad click count %q", s)
+	}
+	return n, nil
+}
@@ -0,0 +1,223 @@
+// Package transfer exports links with their click history and imports
+// them back, from shotr's own JSON Lines and CSV formats or from other
+// shorteners' CSV exports. Both directions stream a page at a time, so
+// the size of the data set doesn't matter.
+package transfer
+
+import (
+	"fmt"
+	"path/filepath"
+	"strings"
+	"time"
+
+	"shotr/db"
+	"shotr/store"
+)
+
+// Formats shotr reads and writes.
+const (
+	FormatJSONL = "jsonl"
+	FormatCSV   = "csv"
+)
+
+// pageSize is how many links are read, or written in one transaction, at
+// a time.
+const pageSize = 500
+
+// dayLayout is how days of click history are written.
+const dayLayout = "2006-01-02"
+
+// FormatFor guesses the format from a file name, defaulting to JSON Lines.
+func FormatFor(name string) string {
+	if strings.EqualFold(filepath.Ext(name), ".csv") {
+		return FormatCSV
+	}
+	return FormatJSONL
+}
+
+func checkFormat(format string) error {
+	switch format {
+	case FormatJSONL, FormatCSV:
+		return nil
+	}
+	return fmt.Errorf("unknown format %q (want %s or %s)", format, FormatJSONL, FormatCSV)
+}
+
+// wireRecord is a link as written to JSON Lines.
+type wireRecord struct {
+	Slug      string      `json:"slug"`
+	URL       string      `json:"url"`
+	User      string      `json:"user,omitempty"`
+	CreatedAt time.Time   `json:"created_at"`
+	Clicks    int64       `json:"clicks"`
+	Daily     []wireDaily `json:"daily,omitempty"`
+
+	OriginalURL    string `json:"original_url,omitempty"`
+	PasswordHash   string `json:"password_hash,omitempty"`
+	Interstitial   bool   `json:"interstitial,omitempty"`
+	TakedownStatus int64  `json:"takedown_status,omitempty"`
+	TakedownReason string `json:"takedown_reason,omitempty"`
+
+	Targets      []wireTarget      `json:"targets,omitempty"`
+	Variants     []wireVariant     `json:"variants,omitempty"`
+	Schedules    []wireSchedule    `json:"schedules,omitempty"`
+	Destinations []wireDestination `json:"destinations,omitempty"`
+}
+
+type wireDaily struct {
+	Day    string `json:"day"`
+	Clicks int64  `json:"clicks"`
+}
+
+type wireTarget struct {
+	Kind  string `json:"kind"`
+	Value string `json:"value"`
+	URL   string `json:"url"`
+}
+
+type wireVariant struct {
+	Name   string `json:"name"`
+	URL    string `json:"url"`
+	Weight int64  `json:"weight"`
+	Clicks int64  `json:"clicks,omitempty"`
+}
+
+type wireSchedule struct {
+	StartsAt *time.Time `json:"starts_at,omitempty"`
+	EndsAt   *time.Time `json:"ends_at,omitempty"`
+	URL      string     `json:"url"`
+}
+
+type wireDestination struct {
+	Owner  string `json:"owner"`
+	URLKey string `json:"url_key"`
+}
+
+func toWire(rec store.LinkRecord) wireRecord {
+	w := wireRecord{
+		Slug:           rec.Slug,
+		URL:            rec.URL,
+		User:           rec.User,
+		CreatedAt:      rec.CreatedAt.UTC(),
+		Clicks:         rec.Clicks,
+		OriginalURL:    rec.OriginalURL,
+		PasswordHash:   rec.PasswordHash,
+		Interstitial:   rec.Interstitial,
+		TakedownStatus: rec.TakedownStatus,
+		TakedownReason: rec.TakedownReason,
+	}
+	for _, d := range rec.Daily {
+		w.Daily = append(w.Daily, wireDaily{Day: d.Day.UTC().Format(dayLayout), Clicks: d.Clicks})
+	}
+	w.Targets, w.Variants, w.Schedules, w.Destinations = wireRules(rec)
+	return w
+}
+
+func wireRules(rec store.LinkRecord) ([]wireTarget, []wireVariant, []wireSchedule, []wireDestination) {
+	var (
+		targets  []wireTarget
+		variants []wireVariant
+		windows  []wireSchedule
+		dests    []wireDestination
+	)
+	for _, t := range rec.Targets {
+		targets = append(targets, wireTarget(t))
+	}
+	for _, v := range rec.Variants {
+		variants = append(variants, wireVariant(v))
+	}
+	for _, s := range rec.Schedules {
+		w := wireSchedule{URL: s.URL}
+		if !s.StartsAt.IsZero() {
+			t := s.StartsAt.UTC()
+			w.StartsAt = &t
+		}
+		if !s.EndsAt.IsZero() {
+			t := s.EndsAt.UTC()
+			w.EndsAt = &t
+		}
+		windows = append(windows, w)
+	}
+	for _, d := range rec.Destinations {
+		dests = append(dests, wireDestination{Owner: d.Owner, URLKey: d.UrlKey})
+	}
+	return targets, variants, windows, dests
+}
+
+func fromWire(w wireRecord) (store.LinkRecord, error) {
+	rec := store.LinkRecord{
+		Slug:           w.Slug,
+		URL:            w.URL,
+		User:           w.User,
+		CreatedAt:      w.CreatedAt,
+		Clicks:         w.Clicks,
+		OriginalURL:    w.OriginalURL,
+		PasswordHash:   w.PasswordHash,
+		Interstitial:   w.Interstitial,
+		TakedownStatus: w.TakedownStatus,
+		TakedownReason: w.TakedownReason,
+	}
+	for _, d := range w.Daily {
+		day, err := time.Parse(dayLayout, d.Day)
+		if err != nil {
+			return rec, fmt.Errorf("bad day %q", d.Day)
+		}
+		rec.Daily = append(rec.Daily, store.DailyCount{Day: day, Clicks: d.Clicks})
+	}
+	setRules(&rec, w.Targets, w.Variants, w.Schedules, w.Destinations)
+	return rec, nil
+}
+
+func setRules(rec *store.LinkRecord, targets []wireTarget, variants []wireVariant, windows []wireSchedule, dests []wireDestination) {
+	for _, t := range targets {
+		rec.Targets = append(rec.Targets, store.TargetRecord(t))
+	}
+	for _, v := range variants {
+		rec.Variants = append(rec.Variants, store.VariantRecord(v))
+	}
+	for _, w := range windows {
+		s := store.ScheduleRecord{URL: w.URL}
+		if w.StartsAt != nil {
+			s.StartsAt = *w.StartsAt
+		}
+		if w.EndsAt != nil {
+			s.EndsAt = *w.EndsAt
+		}
+		rec.Schedules = append(rec.Schedules, s)
+	}
+	for _, d := range dests {
+		rec.Destinations = append(rec.Destinations, db.ListLinkDestinationsRow{Owner: d.Owner, UrlKey: d.URLKey})
+	}
+}
+
+// formatDaily packs click history into one CSV cell: "day=clicks;...".
+func formatDaily(daily []store.DailyCount) string {
+	parts := make([]string, len(daily))
+	for i, d := range daily {
+		parts[i] = fmt.Sprintf("%s=%d", d.Day.UTC().Format(dayLayout), d.Clicks)
+	}
+	return strings.Join(parts, ";")
+}
+
+func parseDaily(s string) ([]store.DailyCount, error) {
+	var out []store.DailyCount
+	for _, part := range strings.Split(s, ";") {
+		if part = strings.TrimSpace(part); part == "" {
+			continue
+		}
+		day, n, ok := strings.Cut(part, "=")
+		if !ok {
+			return nil, fmt.Errorf("bad daily entry %q", part)
+		}
+		d, err := time.Parse(dayLayout, day)
+		if err != nil {
+			return nil, fmt.Errorf("bad day %q", day)
+		}
+		clicks, err := parseCount(n)
+		if err != nil {
+			return nil, err
+		}
+		out = append(out, store.DailyCount{Day: d, Clicks: clicks})
+	}
+	return out, nil
+}
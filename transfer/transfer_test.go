@@ -0,0 +1,149 @@
+package transfer
+
+import (
+	"bytes"
+	"context"
+	"reflect"
+	"strings"
+	"testing"
+	"time"
+
+	"shotr/db"
+	"shotr/helpers"
+	"shotr/store"
+)
+
+// fullRecord sets everything a link can carry.
+func fullRecord(slug string) store.LinkRecord {
+	created := time.Date(2026, 3, 1, 12, 0, 0, 0, time.UTC)
+	return store.LinkRecord{
+		Slug:           slug,
+		URL:            "https://example.com/",
+		User:           "alice",
+		CreatedAt:      created,
+		Clicks:         9,
+		Daily:          []store.DailyCount{{Day: created.Truncate(24 * time.Hour), Clicks: 9}},
+		OriginalURL:    "https://EXAMPLE.com",
+		PasswordHash:   "$2a$10$hash",
+		Interstitial:   true,
+		TakedownStatus: 410,
+		TakedownReason: "phishing",
+		Targets:        []store.TargetRecord{{Kind: "country", Value: "DE", URL: "https://example.com/de"}},
+		Variants: []store.VariantRecord{
+			{Name: "a", URL: "https://example.com/a", Weight: 1, Clicks: 4},
+			{Name: "b", URL: "https://example.com/b", Weight: 2},
+		},
+		Schedules:    []store.ScheduleRecord{{EndsAt: created.Add(time.Hour), URL: "https://example.com/soon"}},
+		Destinations: []db.ListLinkDestinationsRow{{Owner: "alice", UrlKey: "https://example.com/"}},
+	}
+}
+
+func TestRoundTripKeepsEverything(t *testing.T) {
+	for _, format := range []string{FormatJSONL, FormatCSV} {
+		t.Run(format, func(t *testing.T) {
+			ctx := context.Background()
+			src := store.NewMemory()
+			want := fullRecord("abc")
+			if err := src.PutLink(ctx, want); err != nil {
+				t.Fatal(err)
+			}
+			var buf bytes.Buffer
+			if _, err := Export(ctx, src, &buf, format); err != nil {
+				t.Fatal(err)
+			}
+
+			dst := store.NewMemory()
+			// overwrite a link that has none of it, so nothing survives by accident
+			if err := dst.PutLink(ctx, store.LinkRecord{Slug: "abc", URL: "https://old.example/", CreatedAt: time.Now()}); err != nil {
+				t.Fatal(err)
+			}
+			sum, err := Import(ctx, dst, &buf, Options{Format: format, OnConflict: OnConflictOverwrite})
+			if err != nil {
+				t.Fatal(err)
+			}
+			if sum.Overwritten != 1 || sum.Invalid != 0 {
+				t.Fatalf("summary = %+v, want one overwrite", sum)
+			}
+
+			links, err := dst.ListLinks(ctx, db.ListLinksParams{Limit: 10})
+			if err != nil || len(links) != 1 {
+				t.Fatalf("links = %+v, %v", links, err)
+			}
+			got, err := record(ctx, dst, links[0])
+			if err != nil {
+				t.Fatal(err)
+			}
+			if !reflect.DeepEqual(got, want) {
+				t.Errorf("imported\n%+v\nwant\n%+v", got, want)
+			}
+		})
+	}
+}
+
+func TestImportRejectsUnverifiedSignedSlugs(t *testing.T) {
+	keyring, err := helpers.ParseKeyring("k:0123456789abcdef0123")
+	if err != nil {
+		t.Fatal(err)
+	}
+	signed := keyring.Sign("abc")
+	in := `{"slug":"abc_XXXXXXXXXXX","url":"https://example.com/"}` + "\n" +
+		`{"slug":"` + signed + `","url":"https://example.com/"}` + "\n"
+
+	for _, tc := range []struct {
+		name    string
+		keyring *helpers.Keyring
+		created int
+	}{
+		{"no keys", nil, 0},
+		{"own keys", keyring, 1},
+	} {
+		t.Run(tc.name, func(t *testing.T) {
+			var invalid []string
+			sum, err := Import(context.Background(), store.NewMemory(), strings.NewReader(in), Options{
+				Format:     FormatJSONL,
+				OnConflict: OnConflictSkip,
+				Keyring:    tc.keyring,
+				Invalid:    func(line int, err error) { invalid = append(invalid, err.Error()) },
+			})
+			if err != nil {
+				t.Fatal(err)
+			}
+			if sum.Created != tc.created || sum.Invalid != 2-tc.created {
+				t.Errorf("summary = %+v (%v), want %d created", sum, invalid, tc.created)
+			}
+		})
+	}
+}
+
+func TestImportChecksEveryDestination(t *testing.T) {
+	in := `{"slug":"ok","url":"https://Example.com"}` + "\n" +
+		`{"slug":"loop","url":"https://example.com/","variants":[{"name":"a","url":"https://sho.rt/loop","weight":1}]}` + "\n"
+	var checked []string
+	st := store.NewMemory()
+	sum, err := Import(context.Background(), st, strings.NewReader(in), Options{
+		Format:     FormatJSONL,
+		OnConflict: OnConflictSkip,
+		Canonical:  func(raw string) (string, error) { return helpers.CanonicalURL(raw, nil) },
+		Check: func(ctx context.Context, st store.Store, slug string, dests []string) error {
+			checked = append(checked, dests...)
+			for _, d := range dests {
+				if d == "https://sho.rt/"+slug {
+					return &helpers.PolicyError{Code: helpers.PolicyRedirectLoop, Reason: "loop"}
+				}
+			}
+			return nil
+		},
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if sum.Created != 1 || sum.Invalid != 1 {
+		t.Errorf("summary = %+v, want ok created and loop invalid", sum)
+	}
+	if want := []string{"https://example.com/", "https://example.com/", "https://sho.rt/loop"}; !reflect.DeepEqual(checked, want) {
+		t.Errorf("checked %v, want canonical URLs %v", checked, want)
+	}
+	if link, err := st.GetLink(context.Background(), "ok"); err != nil || link.Url != "https://example.com/" {
+		t.Errorf("ok = %+v, %v; want the canonical URL", link, err)
+	}
+}
@@ -0,0 +1,71 @@
+package workers
+
+import (
+	"context"
+	"database/sql"
+	"time"
+
+	"go.uber.org/zap"
+
+	"shotr/store"
+)
+
+// BackupWorker snapshots the sqlite database on a fixed interval and keeps
+// the newest few snapshots.
+type BackupWorker struct {
+	conn     *sql.DB
+	dir      string
+	keep     int
+	interval time.Duration
+	log      *zap.Logger
+	stop     chan struct{}
+	closed   chan struct{}
+}
+
+// NewBackupWorker creates the worker; it does nothing until Start.
+func NewBackupWorker(conn *sql.DB, dir string, keep int, interval time.Duration, log *zap.Logger) *BackupWorker {
+	return &BackupWorker{
+		conn:     conn,
+		dir:      dir,
+		keep:     keep,
+		interval: interval,
+		log:      log,
+		stop:     make(chan struct{}),
+		closed:   make(chan struct{}),
+	}
+}
+
+func (w *BackupWorker) Start() { go w.loop() }
+
+func (w *BackupWorker) Stop() {
+	close(w.stop)
+	<-w.closed
+}
+
+func (w *BackupWorker) loop() {
+	ticker := time.NewTicker(w.interval)
+	defer ticker.Stop()
+	defer close(w.closed)
+
+	for {
+		select {
+		case <-w.stop:
+			return
+		case <-ticker.C:
+			w.backup()
+		}
+	}
+}
+
+func (w *BackupWorker) backup() {
+	ctx, cancel := context.WithTimeout(context.Background(), w.interval)
+	defer cancel()
+
+	start := time.Now()
+	path, err := store.Backup(ctx, w.conn, w.dir, w.keep)
+	if err != nil {
+		w.log.Error("scheduled backup failed", zap.String("dir", w.dir), zap.Error(err))
+		return
+	}
+	w.log.Info("scheduled backup written", zap.String("path", path), zap.Duration("took", time.Since(start)))
+}
@@ -3,26 +3,28 @@ package workers
 import (
 	"context"
 	"database/sql"
-	"fmt"
-	"strings"
 	"time"
 
 	"github.com/avast/retry-go"
 	"go.uber.org/zap"
 
 	"shotr/db"
+	"shotr/store"
 )
 
 // ClickEvent represents one click for a slug.
 type ClickEvent struct {
-	Slug string
-	Time time.Time
+	Slug     string
+	Time     time.Time
+	Country  string // ISO code from GeoIP; empty when unknown
+	Variant  string // split-test variant served; empty when none
+	Language string // language mapping served; empty when the link has none
+	Source   string // how the visitor arrived, e.g. "qr"; empty for plain visits
 }
 
 // ClickWorker batches click events and writes them to DB using single upserts.
 type ClickWorker struct {
-	db            *sql.DB       // raw DB handle for multi-row upserts
-	q             *db.Queries   // keep if you want to call other generated queries
+	store         store.ClickStore
 	log           *zap.Logger
 	in            chan ClickEvent
 	batchSize     int
@@ -30,11 +32,10 @@ type ClickWorker struct {
 	closed        chan struct{}
 }
 
-// NewClickWorker creates the worker. Requires sqlDB (the *sql.DB you opened).
-func NewClickWorker(sqlDB *sql.DB, q *db.Queries, log *zap.Logger, batchSize int, flushInterval time.Duration, buffer int) *ClickWorker {
+// NewClickWorker creates the worker on top of the click store.
+func NewClickWorker(st store.ClickStore, log *zap.Logger, batchSize int, flushInterval time.Duration, buffer int) *ClickWorker {
 	return &ClickWorker{
-		db:            sqlDB,
-		q:             q,
+		store:         st,
 		log:           log,
 		in:            make(chan ClickEvent, buffer),
 		batchSize:     batchSize,
@@ -59,86 +60,31 @@ func (w *ClickWorker) Enqueue(ev ClickEvent) bool {
 	}
 }
 
-// buildUpsertLinks builds a multi-row upsert for links table.
-// returns query string and args slice.
-func buildUpsertLinks(rows map[string]int64) (string, []interface{}) {
-	n := len(rows)
-	// VALUES (?, ?), (?, ?) ...
-	v := make([]string, 0, n)
-	args := make([]interface{}, 0, n*2)
-	for slug, cnt := range rows {
-		v = append(v, "(?, ?)")
-		args = append(args, slug, cnt)
-	}
-	q := fmt.Sprintf(
-		"INSERT INTO links (slug, clicks) VALUES %s ON CONFLICT(slug) DO UPDATE SET clicks = clicks + excluded.clicks;",
-		strings.Join(v, ","),
-	)
-	return q, args
-}
-
-// buildUpsertDaily builds multi-row upsert for daily_clicks (uses date('now')).
-func buildUpsertDaily(rows map[string]int64) (string, []interface{}) {
-	n := len(rows)
-	v := make([]string, 0, n)
-	args := make([]interface{}, 0, n*2)
-	for slug, cnt := range rows {
-		// VALUES (?, date('now'), ?)
-		v = append(v, "(?, date('now'), ?)")
-		args = append(args, slug, cnt)
-	}
-	q := fmt.Sprintf(
-		"INSERT INTO daily_clicks (slug, day, clicks) VALUES %s ON CONFLICT(slug, day) DO UPDATE SET clicks = clicks + excluded.clicks;",
-		strings.Join(v, ","),
-	)
-	return q, args
-}
-
 func (w *ClickWorker) loop() {
 	ticker := time.NewTicker(w.flushInterval)
 	defer ticker.Stop()
 	defer close(w.closed)
 
-	counts := make(map[string]int64)
+	pending := store.NewClickBatch()
 	total := 0
 
 	flush := func() {
 		if total == 0 {
 			return
 		}
-		toFlush := counts
-		counts = make(map[string]int64)
+		batch := pending
+		pending = store.NewClickBatch()
 		total = 0
 
 		ctx, cancel := context.WithTimeout(context.Background(), 6*time.Second)
 		defer cancel()
 
-		// Build SQL and args
-		linksQ, linksArgs := buildUpsertLinks(toFlush)
-		dailyQ, dailyArgs := buildUpsertDaily(toFlush)
-
-		// Perform both upserts inside one transaction with retries
+		// write the whole batch in one transaction, with retries
+		var orphaned []string
 		err := retry.Do(
-			func() error {
-				tx, err := w.db.BeginTx(ctx, nil)
-				if err != nil {
-					return err
-				}
-				// execute links upsert
-				if _, err := tx.ExecContext(ctx, linksQ, linksArgs...); err != nil {
-					_ = tx.Rollback()
-					return err
-				}
-				// execute daily upsert
-				if _, err := tx.ExecContext(ctx, dailyQ, dailyArgs...); err != nil {
-					_ = tx.Rollback()
-					return err
-				}
-				if err := tx.Commit(); err != nil {
-					_ = tx.Rollback()
-					return err
-				}
-				return nil
+			func() (err error) {
+				orphaned, err = w.store.FlushClicks(ctx, batch)
+				return err
 			},
 			retry.Attempts(3),
 			retry.Delay(125*time.Millisecond),
@@ -150,11 +96,12 @@ func (w *ClickWorker) loop() {
 
 		if err != nil {
 			// If this fails repeatedly, fallback to per-slug updates to try to preserve counts.
-			w.log.Error("multi-upsert failed; attempting per-slug fallback", zap.Int("unique_slugs", len(toFlush)), zap.Error(err))
-			w.perSlugFallback(ctx, toFlush)
+			w.log.Error("multi-upsert failed; attempting per-slug fallback", zap.Int("unique_slugs", len(batch.Links)), zap.Error(err))
+			w.perSlugFallback(ctx, batch)
 			return
 		}
-		w.log.Debug("multi-upsert flushed", zap.Int("unique_slugs", len(toFlush)))
+		w.reportOrphaned(batch, orphaned)
+		w.log.Debug("multi-upsert flushed", zap.Int("unique_slugs", len(batch.Links)))
 	}
 
 	for {
@@ -164,7 +111,19 @@ func (w *ClickWorker) loop() {
 				flush()
 				return
 			}
-			counts[ev.Slug]++
+			pending.Links[ev.Slug]++
+			if ev.Country != "" {
+				pending.Countries[store.BreakdownKey{Slug: ev.Slug, Value: ev.Country}]++
+			}
+			if ev.Language != "" {
+				pending.Languages[store.BreakdownKey{Slug: ev.Slug, Value: ev.Language}]++
+			}
+			if ev.Source != "" {
+				pending.Sources[store.BreakdownKey{Slug: ev.Slug, Value: ev.Source}]++
+			}
+			if ev.Variant != "" {
+				pending.Variants[store.BreakdownKey{Slug: ev.Slug, Value: ev.Variant}]++
+			}
 			total++
 			if total >= w.batchSize {
 				flush()
@@ -177,25 +136,98 @@ func (w *ClickWorker) loop() {
 	}
 }
 
+// reportOrphaned logs clicks that were dropped because their link no
+// longer exists, e.g. it was deleted while the clicks sat in the queue.
+func (w *ClickWorker) reportOrphaned(b store.ClickBatch, orphaned []string) {
+	if len(orphaned) == 0 {
+		return
+	}
+	var clicks int64
+	for _, slug := range orphaned {
+		clicks += b.Links[slug]
+	}
+	w.log.Warn("dropped clicks for missing links", zap.Strings("slugs", orphaned), zap.Int64("clicks", clicks))
+}
+
 // perSlugFallback tries to write each slug individually (less efficient) if multi-upsert fails.
-func (w *ClickWorker) perSlugFallback(ctx context.Context, rows map[string]int64) {
-	for slug, cnt := range rows {
+func (w *ClickWorker) perSlugFallback(ctx context.Context, b store.ClickBatch) {
+	orphan := make(map[string]bool)
+	for slug, cnt := range b.Links {
 		if cnt <= 0 {
 			continue
 		}
-		// try add click
-		if err := w.q.AddClick(ctx, db.AddClickParams{
+		// try add click; a missing link gets none of its counters written
+		n, err := w.store.AddClick(ctx, db.AddClickParams{
 			Clicks: sql.NullInt64{Int64: cnt, Valid: true},
 			Slug:   slug,
-		}); err != nil {
+		})
+		if err != nil {
 			w.log.Error("fallback AddClick failed", zap.String("slug", slug), zap.Int64("count", cnt), zap.Error(err))
+		} else if n == 0 {
+			orphan[slug] = true
+			continue
 		}
 		// try daily
-		if err := w.q.SaveDailyClicks(ctx, db.SaveDailyClicksParams{
+		if err := w.store.SaveDailyClicks(ctx, db.SaveDailyClicksParams{
 			Slug:   slug,
 			Clicks: sql.NullInt64{Int64: cnt, Valid: true},
 		}); err != nil {
 			w.log.Error("fallback SaveDailyClicks failed", zap.String("slug", slug), zap.Int64("count", cnt), zap.Error(err))
 		}
 	}
-}
\ No newline at end of file
+	for k, cnt := range b.Countries {
+		if orphan[k.Slug] {
+			continue
+		}
+		if err := w.store.SaveCountryClicks(ctx, db.SaveCountryClicksParams{
+			Slug:    k.Slug,
+			Country: k.Value,
+			Clicks:  sql.NullInt64{Int64: cnt, Valid: true},
+		}); err != nil {
+			w.log.Error("fallback SaveCountryClicks failed", zap.String("slug", k.Slug), zap.String("country", k.Value), zap.Int64("count", cnt), zap.Error(err))
+		}
+	}
+	for k, cnt := range b.Languages {
+		if orphan[k.Slug] {
+			continue
+		}
+		if err := w.store.SaveLanguageClicks(ctx, db.SaveLanguageClicksParams{
+			Slug:     k.Slug,
+			Language: k.Value,
+			Clicks:   sql.NullInt64{Int64: cnt, Valid: true},
+		}); err != nil {
+			w.log.Error("fallback SaveLanguageClicks failed", zap.String("slug", k.Slug), zap.String("language", k.Value), zap.Int64("count", cnt), zap.Error(err))
+		}
+	}
+	for k, cnt := range b.Sources {
+		if orphan[k.Slug] {
+			continue
+		}
+		if err := w.store.SaveSourceClicks(ctx, db.SaveSourceClicksParams{
+			Slug:   k.Slug,
+			Source: k.Value,
+			Clicks: sql.NullInt64{Int64: cnt, Valid: true},
+		}); err != nil {
+			w.log.Error("fallback SaveSourceClicks failed", zap.String("slug", k.Slug), zap.String("source", k.Value), zap.Int64("count", cnt), zap.Error(err))
+		}
+	}
+	for k, cnt := range b.Variants {
+		if orphan[k.Slug] {
+			continue
+		}
+		if err := w.store.AddVariantClick(ctx, db.AddVariantClickParams{
+			Clicks: sql.NullInt64{Int64: cnt, Valid: true},
+			Slug:   k.Slug,
+			Name:   k.Value,
+		}); err != nil {
+			w.log.Error("fallback AddVariantClick failed", zap.String("slug", k.Slug), zap.String("variant", k.Value), zap.Int64("count", cnt), zap.Error(err))
+		}
+	}
+	if len(orphan) > 0 {
+		slugs := make([]string, 0, len(orphan))
+		for slug := range orphan {
+			slugs = append(slugs, slug)
+		}
+		w.reportOrphaned(b, slugs)
+	}
+}
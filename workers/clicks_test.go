@@ -0,0 +1,154 @@
+package workers
+
+import (
+	"context"
+	"database/sql"
+	"path/filepath"
+	"testing"
+	"time"
+
+	_ "github.com/mattn/go-sqlite3"
+	"go.uber.org/zap"
+
+	"shotr/db"
+	"shotr/migrations"
+	"shotr/store"
+)
+
+// sqliteStore is a migrated sqlite file in the test's temp dir holding a
+// link for each of slugs.
+func sqliteStore(t *testing.T, slugs ...string) *store.SQL {
+	t.Helper()
+	conn, err := sql.Open("sqlite3", filepath.Join(t.TempDir(), "shotr.db")+"?_journal_mode=WAL&_txlock=immediate&_busy_timeout=5000")
+	if err != nil {
+		t.Fatal(err)
+	}
+	conn.SetMaxOpenConns(1)
+	t.Cleanup(func() { conn.Close() })
+	ctx := context.Background()
+	if err := migrations.SQLite.Up(ctx, conn, zap.NewNop()); err != nil {
+		t.Fatal(err)
+	}
+	st := store.NewSQL(conn, nil)
+	for _, slug := range slugs {
+		if _, err := st.AddLink(ctx, db.AddLinkParams{Slug: slug, Url: "https://example.com/"}); err != nil {
+			t.Fatal(err)
+		}
+	}
+	return st
+}
+
+func clicksOf(t *testing.T, st store.Store, slug string) int64 {
+	t.Helper()
+	link, err := st.GetLinkStats(context.Background(), slug)
+	if err != nil {
+		t.Fatal(err)
+	}
+	return link.Clicks.Int64
+}
+
+func TestClickWorkerFlushesOnStop(t *testing.T) {
+	st := sqliteStore(t, "abc", "def")
+	// neither the size nor the ticker triggers a flush, only Stop does
+	w := NewClickWorker(st, zap.NewNop(), 1000, time.Hour, 100)
+	w.Start()
+	for i := 0; i < 3; i++ {
+		w.Enqueue(ClickEvent{Slug: "abc", Country: "DE"})
+	}
+	w.Enqueue(ClickEvent{Slug: "def", Source: "qr"})
+	w.Stop()
+
+	if n := clicksOf(t, st, "abc"); n != 3 {
+		t.Errorf("abc clicks = %d, want 3", n)
+	}
+	if n := clicksOf(t, st, "def"); n != 1 {
+		t.Errorf("def clicks = %d, want 1", n)
+	}
+	countries, err := st.GetCountryClicks(context.Background(), "abc")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(countries) != 1 || countries[0].Country != "DE" || countries[0].Clicks != 3 {
+		t.Errorf("abc countries = %+v, want DE 3", countries)
+	}
+}
+
+func TestClickWorkerFlushesFullBatch(t *testing.T) {
+	st := sqliteStore(t, "abc")
+	w := NewClickWorker(st, zap.NewNop(), 5, time.Hour, 100)
+	w.Start()
+	defer w.Stop()
+	for i := 0; i < 5; i++ {
+		w.Enqueue(ClickEvent{Slug: "abc"})
+	}
+
+	deadline := time.Now().Add(5 * time.Second)
+	for clicksOf(t, st, "abc") != 5 {
+		if time.Now().After(deadline) {
+			t.Fatalf("abc clicks = %d after a full batch, want 5", clicksOf(t, st, "abc"))
+		}
+		time.Sleep(10 * time.Millisecond)
+	}
+}
+
+func TestClickWorkerDropsOrphanedClicks(t *testing.T) {
+	st := sqliteStore(t, "kept", "gone")
+	ctx := context.Background()
+	gone, err := st.GetLink(ctx, "gone")
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	w := NewClickWorker(st, zap.NewNop(), 1000, time.Hour, 100)
+	w.Start()
+	w.Enqueue(ClickEvent{Slug: "kept", Country: "DE"})
+	w.Enqueue(ClickEvent{Slug: "gone", Country: "DE", Language: "en"})
+	// the link goes away while its click is still queued
+	if err := st.DeleteLinkByID(ctx, gone.ID); err != nil {
+		t.Fatal(err)
+	}
+	w.Stop()
+
+	if n := clicksOf(t, st, "kept"); n != 1 {
+		t.Errorf("kept clicks = %d, want 1", n)
+	}
+	if _, err := st.GetLink(ctx, "gone"); err != sql.ErrNoRows {
+		t.Errorf("GetLink(gone): err = %v, want sql.ErrNoRows; the flush must not recreate it", err)
+	}
+	if countries, _ := st.GetCountryClicks(ctx, "gone"); len(countries) != 0 {
+		t.Errorf("gone has country clicks %+v", countries)
+	}
+}
+
+func TestPerSlugFallbackSkipsOrphans(t *testing.T) {
+	st := sqliteStore(t, "kept")
+	w := NewClickWorker(st, zap.NewNop(), 1000, time.Hour, 100)
+	ctx := context.Background()
+
+	b := store.NewClickBatch()
+	for _, slug := range []string{"kept", "gone"} {
+		b.Links[slug] = 2
+		b.Countries[store.BreakdownKey{Slug: slug, Value: "DE"}] = 2
+		b.Sources[store.BreakdownKey{Slug: slug, Value: "qr"}] = 2
+	}
+	w.perSlugFallback(ctx, b)
+
+	if n := clicksOf(t, st, "kept"); n != 2 {
+		t.Errorf("kept clicks = %d, want 2", n)
+	}
+	if countries, _ := st.GetCountryClicks(ctx, "kept"); len(countries) != 1 || countries[0].Clicks != 2 {
+		t.Errorf("kept countries = %+v, want DE 2", countries)
+	}
+	if _, err := st.GetLink(ctx, "gone"); err != sql.ErrNoRows {
+		t.Errorf("GetLink(gone): err = %v, want sql.ErrNoRows", err)
+	}
+	if daily, _ := st.GetDailyClicks(ctx, "gone"); len(daily) != 0 {
+		t.Errorf("gone has daily clicks %+v", daily)
+	}
+	if countries, _ := st.GetCountryClicks(ctx, "gone"); len(countries) != 0 {
+		t.Errorf("gone has country clicks %+v", countries)
+	}
+	if sources, _ := st.GetSourceClicks(ctx, "gone"); len(sources) != 0 {
+		t.Errorf("gone has source clicks %+v", sources)
+	}
+}